@@ -1,7 +1,11 @@
 package boot
 
 import (
+	"context"
 	"fmt"
+	"github.com/Constantine-Kostikas/GitQuick/internal/cache"
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
 	"github.com/Constantine-Kostikas/GitQuick/internal/git"
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
 	"os"
@@ -12,6 +16,9 @@ type System struct {
 	isGitRepo  bool
 	remoteUrl  string
 	Platform   platform.Platform
+	Config     config.Config
+	RepoPath   string // the repository's path on its forge, e.g. "owner/repo"
+	CacheDir   string // the repository's on-disk cache, empty without one
 	Errors     []error
 }
 
@@ -23,17 +30,39 @@ func Bootstrap() System {
 		system.Errors = append(system.Errors, fmt.Errorf("error getting current directory: %v\n", err))
 	}
 
-	isGitRepo := git.IsGitRepo(workingDir)
-	if !isGitRepo {
-		system.Errors = append(system.Errors, fmt.Errorf("error: Not a git repository. Run gitHelper from inside a git repo"))
+	// Catch a missing git, repo, remote, CLI or login before the dashboard
+	// opens. gq doctor runs the network checks as well.
+	for _, check := range Preflight(context.Background(), workingDir, false) {
+		if check.State == CheckFailed {
+			system.Errors = append(system.Errors, check.Err())
+		}
 	}
+	if system.Errors != nil {
+		return system
+	}
+
+	// Work from the top of the repo, wherever in it gq was started
+	root, err := git.RepoRoot(context.Background(), workingDir)
+	if err != nil {
+		system.Errors = append(system.Errors, err)
+		return system
+	}
+	workingDir = root
+	isGitRepo := true
 
-	remoteURL, err := git.GetRemoteURL(workingDir)
+	cfg, err := config.Load(root)
+	if err != nil {
+		system.Errors = append(system.Errors, err)
+		return system
+	}
+	cmd.DefaultTimeout = cfg.Timeouts.Command
+
+	remoteURL, err := git.GetRemoteURL(context.Background(), workingDir)
 	if err != nil {
 		system.Errors = append(system.Errors, fmt.Errorf("error getting remote URL: %v", err))
 	}
 
-	gitPlatform, err := platform.NewPlatform(workingDir, remoteURL)
+	gitPlatform, err := platform.NewPlatformNamed(platformName(remoteURL, cfg.Hosts), workingDir)
 	if err != nil {
 		system.Errors = append(system.Errors, fmt.Errorf("error getting platform: %v", err))
 	}
@@ -42,6 +71,11 @@ func Bootstrap() System {
 	system.isGitRepo = isGitRepo
 	system.remoteUrl = remoteURL
 	system.Platform = gitPlatform
+	system.Config = cfg
+	system.RepoPath = remotePath(remoteURL)
+	if cache.UserDir() != "" && remoteURL != "" {
+		system.CacheDir = cache.RepoDir(remoteHost(remoteURL), system.RepoPath)
+	}
 
 	return system
 }
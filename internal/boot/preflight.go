@@ -0,0 +1,267 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// CheckState is the outcome of a preflight check
+type CheckState int
+
+const (
+	CheckOK CheckState = iota
+	CheckFailed
+	CheckSkipped // an earlier check it depends on failed
+)
+
+// Check is the result of one preflight check
+type Check struct {
+	Name   string
+	State  CheckState
+	Detail string // version, account or what went wrong
+	Fix    string // what to do about a failure
+}
+
+// Err describes a failed check as an error
+func (c Check) Err() error {
+	if c.Fix == "" {
+		return fmt.Errorf("%s: %s", c.Name, c.Detail)
+	}
+	return fmt.Errorf("%s: %s\n  %s", c.Name, c.Detail, c.Fix)
+}
+
+// minGitVersion is the oldest git with every flag gq uses, such as
+// fetch --no-write-fetch-head
+var minGitVersion = [2]int{2, 29}
+
+// cliInstall says where to get each forge CLI
+var cliInstall = map[string]string{
+	"gh":   "https://cli.github.com",
+	"glab": "https://gitlab.com/gitlab-org/cli#installation",
+}
+
+// Preflight checks what gq needs in the repository containing dir: git, the
+// repo, its config and origin remote, the gh or glab CLI for the remote's
+// host and that it has a token for that host. With network set the login is
+// checked with the forge instead, and so is that the remote can be reached.
+// Checks that depend on a failed one are skipped.
+func Preflight(ctx context.Context, dir string, network bool) []Check {
+	var checks []Check
+	failed := false
+	add := func(c Check) {
+		if failed {
+			c = Check{Name: c.Name, State: CheckSkipped}
+		}
+		if c.State == CheckFailed {
+			failed = true
+		}
+		checks = append(checks, c)
+	}
+
+	add(checkGit(ctx, dir))
+
+	root, err := git.RepoRoot(ctx, dir)
+	switch {
+	case err == nil:
+		add(Check{Name: "repository", Detail: root})
+		dir = root
+	case errors.Is(err, git.ErrBareRepo):
+		add(Check{Name: "repository", State: CheckFailed, Detail: dir + " is a bare repository",
+			Fix: "gq checks branches out, run it from a clone or a worktree with a work tree"})
+	case errors.Is(err, git.ErrNoWorkTree):
+		add(Check{Name: "repository", State: CheckFailed, Detail: dir + " is inside the git directory",
+			Fix: "Run gq from the work tree instead"})
+	case errors.Is(err, git.ErrNotARepo):
+		add(Check{Name: "repository", State: CheckFailed, Detail: dir + " is not in a git repository",
+			Fix: "Run gq from inside a git repository"})
+	default:
+		add(Check{Name: "repository", State: CheckFailed, Detail: err.Error()})
+	}
+
+	cfg, err := config.Load(root)
+	if err != nil {
+		add(Check{Name: "config", State: CheckFailed, Detail: err.Error(),
+			Fix: "Fix these settings, gq config prints the configuration in effect"})
+	} else {
+		add(Check{Name: "config", Detail: strings.Join(cfg.Sources, ", ")})
+	}
+
+	remoteURL, err := git.GetRemoteURL(ctx, dir)
+	if err != nil && !failed {
+		add(Check{Name: "remote", State: CheckFailed, Detail: "no origin remote",
+			Fix: "Add one with git remote add origin <url>"})
+	} else {
+		add(Check{Name: "remote", Detail: "origin " + remoteURL})
+	}
+
+	name := platformName(remoteURL, cfg.Hosts)
+	host := remoteHost(remoteURL)
+	cli := "gh"
+	switch {
+	case name == "gitlab":
+		cli = "glab"
+		add(Check{Name: "platform", Detail: "GitLab (" + host + ")"})
+	case name == "github":
+		add(Check{Name: "platform", Detail: "GitHub (" + host + ")"})
+	default:
+		add(Check{Name: "platform", State: CheckFailed, Detail: platform.ErrUnknownPlatform.Error(),
+			Fix: "For a self-hosted forge, map its host in the config: [hosts] \"" + host + "\" = \"gitlab\""})
+	}
+
+	add(checkCLI(ctx, dir, cli))
+	if network {
+		add(checkAuth(ctx, dir, cli, host, cfg.Timeouts.Network))
+		add(checkReachable(ctx, dir, cfg.Timeouts.Network))
+	} else {
+		add(checkToken(ctx, dir, cli, host))
+	}
+	return checks
+}
+
+// gitVersion matches the version in git --version, e.g. "git version 2.43.0"
+var gitVersion = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// checkGit checks that git is installed and new enough
+func checkGit(ctx context.Context, dir string) Check {
+	c := Check{Name: "git"}
+	out, err := cmd.Run(ctx, dir, "git", "--version")
+	if err != nil {
+		c.State, c.Detail, c.Fix = CheckFailed, err.Error(), "Install git from https://git-scm.com/downloads"
+		return c
+	}
+	c.Detail = firstLine(string(out))
+	if m := gitVersion.FindStringSubmatch(c.Detail); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		if major < minGitVersion[0] || (major == minGitVersion[0] && minor < minGitVersion[1]) {
+			c.State = CheckFailed
+			c.Fix = fmt.Sprintf("gq needs git %d.%d or newer", minGitVersion[0], minGitVersion[1])
+		}
+	}
+	return c
+}
+
+// checkCLI checks that the forge CLI is installed
+func checkCLI(ctx context.Context, dir, cli string) Check {
+	c := Check{Name: cli}
+	out, err := cmd.Run(ctx, dir, cli, "--version")
+	if err != nil {
+		c.State, c.Detail, c.Fix = CheckFailed, err.Error(), "Install it from "+cliInstall[cli]
+		return c
+	}
+	c.Detail = firstLine(string(out))
+	return c
+}
+
+// loggedInAs matches the account in gh and glab auth status output, e.g.
+// "Logged in to github.com account alice" or "Logged in to gitlab.com as alice"
+var loggedInAs = regexp.MustCompile(`Logged in to \S+ (?:account|as) (\S+)`)
+
+// checkAuth checks that the forge CLI is logged in to host
+func checkAuth(ctx context.Context, dir, cli, host string, timeout time.Duration) Check {
+	c := Check{Name: cli + " auth"}
+	out, err := cmd.RunWithTimeout(ctx, dir, timeout, cli, "auth", "status", "--hostname", host)
+	if err != nil {
+		c.State, c.Detail = CheckFailed, "not logged in to "+host
+		c.Fix = "Run " + cli + " auth login --hostname " + host
+		return c
+	}
+	c.Detail = "logged in to " + host
+	if m := loggedInAs.FindStringSubmatch(string(out)); m != nil {
+		c.Detail += " as " + m[1]
+	}
+	return c
+}
+
+// tokenTimeout bounds the startup login check, which only reads the CLI's
+// own config
+const tokenTimeout = 5 * time.Second
+
+// tokenArgs are the arguments that make each forge CLI print its token for a
+// host without asking the forge
+var tokenArgs = map[string]func(host string) []string{
+	"gh":   func(host string) []string { return []string{"auth", "token", "--hostname", host} },
+	"glab": func(host string) []string { return []string{"config", "get", "token", "--host", host} },
+}
+
+// checkToken checks that the forge CLI has a token for host, without using
+// the network, so that gq starts offline too. The token isn't kept.
+func checkToken(ctx context.Context, dir, cli, host string) Check {
+	c := Check{Name: cli + " auth"}
+	out, err := cmd.RunWithTimeout(ctx, dir, tokenTimeout, cli, tokenArgs[cli](host)...)
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		c.State, c.Detail = CheckFailed, "not logged in to "+host
+		c.Fix = "Run " + cli + " auth login --hostname " + host
+		return c
+	}
+	c.Detail = "has a token for " + host
+	return c
+}
+
+// checkReachable checks that the origin remote answers
+func checkReachable(ctx context.Context, dir string, timeout time.Duration) Check {
+	c := Check{Name: "network"}
+	start := time.Now()
+	if _, err := cmd.RunWithTimeout(ctx, dir, timeout, "git", "ls-remote", "--exit-code", "origin", "HEAD"); err != nil {
+		c.State, c.Detail = CheckFailed, "origin can't be reached: "+err.Error()
+		c.Fix = "Check your network connection and your access to the repository"
+		return c
+	}
+	c.Detail = "origin answered in " + time.Since(start).Round(10*time.Millisecond).String()
+	return c
+}
+
+// platformName returns the platform of a remote: the one its host is mapped
+// to in the config, or else the one its URL names
+func platformName(remoteURL string, hosts map[string]string) string {
+	if name, ok := hosts[strings.ToLower(remoteHost(remoteURL))]; ok {
+		return name
+	}
+	return platform.DetectPlatformFromURL(remoteURL)
+}
+
+// remoteHost returns the host of a remote URL, for https, ssh and scp-like
+// (git@host:owner/repo) forms
+func remoteHost(remote string) string {
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	// scp-like: [user@]host:path
+	host, _, ok := strings.Cut(remote, ":")
+	if !ok {
+		return ""
+	}
+	if _, h, ok := strings.Cut(host, "@"); ok {
+		host = h
+	}
+	return host
+}
+
+// remotePath returns the repository's path on its host, e.g. "owner/repo",
+// from a remote URL
+func remotePath(remote string) string {
+	var path string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		path = u.Path
+	} else if _, p, ok := strings.Cut(remote, ":"); ok {
+		path = p
+	}
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,163 @@
+package boot
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRemoteHost(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"https://github.com/owner/repo.git", "github.com"},
+		{"https://user@gitlab.example.com:8443/group/repo", "gitlab.example.com"},
+		{"ssh://git@github.com/owner/repo.git", "github.com"},
+		{"git@gitlab.com:group/sub/repo.git", "gitlab.com"},
+		{"github.com:owner/repo", "github.com"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := remoteHost(tt.remote); got != tt.want {
+			t.Errorf("remoteHost(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestRemotePath(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"https://github.com/owner/repo.git", "owner/repo"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/repo.git", "group/sub/repo"},
+		{"git@gitlab.com:group/sub/repo.git", "group/sub/repo"},
+		{"github.com:owner/repo", "owner/repo"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := remotePath(tt.remote); got != tt.want {
+			t.Errorf("remotePath(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	states := func(checks []Check) map[string]CheckState {
+		m := make(map[string]CheckState)
+		for _, c := range checks {
+			m[c.Name] = c.State
+		}
+		return m
+	}
+
+	t.Run("not a repository", func(t *testing.T) {
+		got := states(Preflight(ctx, t.TempDir(), false))
+		if got["git"] != CheckOK {
+			t.Errorf("git = %v, want ok", got["git"])
+		}
+		if got["repository"] != CheckFailed {
+			t.Errorf("repository = %v, want failed", got["repository"])
+		}
+		for _, name := range []string{"config", "remote", "platform", "gh"} {
+			if got[name] != CheckSkipped {
+				t.Errorf("%s = %v, want skipped", name, got[name])
+			}
+		}
+	})
+
+	t.Run("unsupported remote", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, args := range [][]string{
+			{"init", "--quiet"},
+			{"remote", "add", "origin", "https://example.com/owner/repo.git"},
+		} {
+			if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		checks := Preflight(ctx, dir, true)
+		got := states(checks)
+		if got["remote"] != CheckOK || got["platform"] != CheckFailed {
+			t.Errorf("remote = %v, platform = %v, want ok and failed", got["remote"], got["platform"])
+		}
+		// Nothing after the failed platform check touches the network
+		if got["gh auth"] != CheckSkipped || got["network"] != CheckSkipped {
+			t.Errorf("gh auth = %v, network = %v, want skipped", got["gh auth"], got["network"])
+		}
+		if len(checks) != 8 {
+			t.Errorf("got %d checks, want 8", len(checks))
+		}
+
+		// A host mapped in the config is recognized
+		config := filepath.Join(xdg, "gq", "config.toml")
+		if err := os.MkdirAll(filepath.Dir(config), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(config, []byte("[hosts]\n\"example.com\" = \"gitlab\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got = states(Preflight(ctx, dir, false))
+		if got["platform"] != CheckOK || got["glab"] == CheckSkipped {
+			t.Errorf("with a host mapping platform = %v, glab = %v", got["platform"], got["glab"])
+		}
+	})
+}
+
+func TestPreflight_Token(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gh is a shell script")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", "git@github.com:owner/repo.git"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	// A gh that answers gh auth token with whatever $GH_FAKE_TOKEN holds
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1 $2\" = \"auth token\" ]; then\n  [ -n \"$GH_FAKE_TOKEN\" ] || { echo 'no oauth token found for github.com' >&2; exit 1; }\n  echo \"$GH_FAKE_TOKEN\"\nfi\necho 'gh version 2.60.0'\n"
+	if err := os.WriteFile(filepath.Join(bin, "gh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	auth := func() Check {
+		for _, c := range Preflight(context.Background(), dir, false) {
+			if c.Name == "gh auth" {
+				return c
+			}
+		}
+		t.Fatal("no gh auth check")
+		return Check{}
+	}
+
+	t.Setenv("GH_FAKE_TOKEN", "")
+	if c := auth(); c.State != CheckFailed || !strings.Contains(c.Fix, "gh auth login --hostname github.com") {
+		t.Errorf("without a token got %+v, want a failed check", c)
+	}
+	t.Setenv("GH_FAKE_TOKEN", "gho_secret")
+	if c := auth(); c.State != CheckOK || strings.Contains(c.Detail, "gho_secret") {
+		t.Errorf("with a token got %+v, want an ok check that hides it", c)
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Store keeps JSON values on disk under string keys, each in its own file
+// with the time it was saved. Reads and writes are best effort: a missing,
+// unreadable or corrupt entry is a miss.
+type Store struct {
+	dir string
+	now func() time.Time
+}
+
+// entry is a stored value and when it was saved
+type entry struct {
+	Saved time.Time       `json:"saved"`
+	Value json.RawMessage `json:"value"`
+}
+
+// UserDir returns gq's directory under the user's cache directory,
+// $XDG_CACHE_HOME or ~/.cache on Linux
+func UserDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gq")
+}
+
+// RepoDir returns the cache directory of a repository on host, path being
+// its path on the host, e.g. "owner/repo"
+func RepoDir(host, path string) string {
+	parts := []string{UserDir(), url.PathEscape(strings.ToLower(host))}
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part != "" && part != "." && part != ".." {
+			parts = append(parts, url.PathEscape(part))
+		}
+	}
+	return filepath.Join(parts...)
+}
+
+// Open returns the store in dir, created on the first write
+func Open(dir string) *Store {
+	return &Store{dir: dir, now: time.Now}
+}
+
+// file returns the path of key's entry
+func (s *Store) file(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Get decodes key's value into v and returns when it was saved
+func (s *Store) Get(key string, v any) (time.Time, bool) {
+	data, err := os.ReadFile(s.file(key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return time.Time{}, false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return time.Time{}, false
+	}
+	return e.Saved, true
+}
+
+// Put saves v under key, replacing the entry atomically
+func (s *Store) Put(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Saved: s.now(), Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.file(key))
+}
+
+// Delete removes the entries of keys
+func (s *Store) Delete(keys ...string) {
+	for _, key := range keys {
+		os.Remove(s.file(key))
+	}
+}
+
+// DeletePrefix removes every entry whose key starts with prefix
+func (s *Store) DeletePrefix(prefix string) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if key, err := url.PathUnescape(name); err == nil && strings.HasPrefix(key, prefix) {
+			os.Remove(filepath.Join(s.dir, f.Name()))
+		}
+	}
+}
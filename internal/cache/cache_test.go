@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := Open(filepath.Join(t.TempDir(), "repo"))
+	s.now = func() time.Time { return now }
+
+	var got []string
+	if _, ok := s.Get("mrs/@me", &got); ok {
+		t.Fatal("Get on an empty store hit")
+	}
+	for _, key := range []string{"mrs/@me", "mrs/alice", "detail/7"} {
+		if err := s.Put(key, []string{key}); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+	saved, ok := s.Get("mrs/@me", &got)
+	if !ok || !saved.Equal(now) || len(got) != 1 || got[0] != "mrs/@me" {
+		t.Fatalf("Get = %v, %v, %v", got, saved, ok)
+	}
+
+	s.DeletePrefix("mrs/")
+	if _, ok := s.Get("mrs/alice", &got); ok {
+		t.Error("DeletePrefix kept mrs/alice")
+	}
+	if _, ok := s.Get("detail/7", &got); !ok {
+		t.Error("DeletePrefix removed detail/7")
+	}
+	s.Delete("detail/7")
+	if _, ok := s.Get("detail/7", &got); ok {
+		t.Error("Delete kept detail/7")
+	}
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	s := Open(t.TempDir())
+	if err := s.Put("repo", "not a number"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if _, ok := s.Get("repo", &n); ok {
+		t.Error("Get decoded a string into an int")
+	}
+}
+
+func TestRepoDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	tests := []struct {
+		host, path string
+		want       string
+	}{
+		{"GitHub.com", "owner/repo", "/cache/gq/github.com/owner/repo"},
+		{"gitlab.example.com:8443", "/group/sub/repo/", "/cache/gq/gitlab.example.com:8443/group/sub/repo"},
+		{"github.com", "../../etc", "/cache/gq/github.com/etc"},
+	}
+	for _, tt := range tests {
+		if got := RepoDir(tt.host, tt.path); got != tt.want {
+			t.Errorf("RepoDir(%q, %q) = %q, want %q", tt.host, tt.path, got, tt.want)
+		}
+	}
+}
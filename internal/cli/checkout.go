@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/ui"
+)
+
+// checkoutResult is a finished checkout, as gq checkout --json prints it
+type checkoutResult struct {
+	Number       int    `json:"number"`
+	Branch       string `json:"branch"`
+	Head         string `json:"head"`
+	PreviousHead string `json:"previousHead,omitempty"`
+	Upstream     string `json:"upstream,omitempty"`
+	Pulled       int    `json:"pulled"`
+	ForcePushed  bool   `json:"forcePushed"`
+	Reset        bool   `json:"reset"`
+	NeedsReset   bool   `json:"needsReset"` // kept rather than reset, run again with --reset
+	StashedFrom  string `json:"stashedFrom,omitempty"`
+	Restored     bool   `json:"restored"`
+}
+
+// checkoutFailure is a failed checkout, as gq checkout --json prints it when
+// the uncommitted changes were stashed before it failed
+type checkoutFailure struct {
+	Number      int    `json:"number"`
+	Error       string `json:"error"`
+	StashedFrom string `json:"stashedFrom"`
+}
+
+// checkout checks an MR's branch out the way the dashboard does: it fetches
+// only what it needs, updates the branch as gq.pullStrategy says and records
+// the checkout in the history
+func checkout(e *env, args []string) int {
+	fs := e.newFlagSet()
+	stash := fs.Bool("stash", false, "stash uncommitted changes, untracked files included, before checking out")
+	reset := fs.Bool("reset", false, "with the reset pull strategy, reset the branch to its upstream without asking")
+	asJSON := fs.Bool("json", false, "print JSON")
+	number, code, ok := e.parseNumber(fs, args)
+	if !ok {
+		return code
+	}
+
+	if !e.connect() {
+		return ExitFailure
+	}
+	ctx, repoPath := e.ctx, e.repoPath
+	mr, err := e.platform.GetMR(ctx, number)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	files, err := git.Status(ctx, repoPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	if len(files) > 0 && !*stash {
+		fmt.Fprintf(e.stderr, "gq: %d uncommitted %s, commit them or run again with --stash\n",
+			len(files), plural(len(files), "change", "changes"))
+		return ExitFailure
+	}
+
+	strategy, err := git.GetPullStrategy(ctx, repoPath)
+	if err != nil {
+		return e.fail(err)
+	}
+	opts := git.CheckoutOptions{Strategy: strategy}
+	if info, err := e.platform.GetRepoInfo(ctx); err == nil {
+		opts.BaseBranch = info.DefaultBranch
+	}
+
+	// Steps go to stderr so that stdout stays parseable
+	report := func(p git.Progress) {
+		switch p.State {
+		case git.StepDone:
+			fmt.Fprintf(e.stderr, "✓ %s\n", p.Label)
+		case git.StepFailed:
+			fmt.Fprintf(e.stderr, "✗ %s\n", p.Label)
+		}
+	}
+
+	from, _ := git.GetCurrentBranch(ctx, repoPath)
+	var stashedFrom string
+	if len(files) > 0 {
+		if stashedFrom, err = git.AutoStash(ctx, repoPath, true, report); err != nil {
+			return e.fail(err)
+		}
+	}
+	// Past the stash a failure has to say where the changes went
+	fail := func(err error) int {
+		code := e.fail(err)
+		if stashedFrom == "" {
+			return code
+		}
+		fmt.Fprintf(e.stderr, "gq: your uncommitted changes are stashed as \"gq autostash from %s\", gq restores them when you check %s out again, or run git stash pop\n",
+			stashedFrom, stashedFrom)
+		if *asJSON {
+			_ = writeJSON(e.stdout, checkoutFailure{Number: mr.Number, Error: ui.DescribeError(err), StashedFrom: stashedFrom})
+		}
+		return code
+	}
+	var result git.CheckoutResult
+	if mr.CrossRepo && mr.HeadRef != "" {
+		// Fork branches aren't on origin, fetch those through the MR ref
+		result, err = git.CheckoutMRHead(ctx, repoPath, git.MRHead{
+			Number: mr.Number,
+			Ref:    mr.HeadRef,
+			Branch: mr.Branch,
+			Owner:  mr.HeadOwner,
+		}, opts, report)
+	} else {
+		result, err = git.CheckoutWithProgress(ctx, repoPath, mr.Branch, opts, report)
+	}
+	result.StashedFrom = stashedFrom
+	if err != nil {
+		return fail(err)
+	}
+	if result.NeedsReset && *reset {
+		result.NeedsReset = false
+		if result, err = git.ResetToUpstream(ctx, repoPath, result, report); err != nil {
+			return fail(err)
+		}
+	}
+	_ = git.RecordCheckout(ctx, repoPath, from, git.HistoryEntry{Branch: result.Branch, MR: mr.Number})
+	if !result.NeedsReset {
+		// Bring back what was stashed when this branch was last left
+		if result.Restored, err = git.RestoreAutoStash(ctx, repoPath, result.Branch, report); err != nil {
+			return fail(err)
+		}
+	}
+
+	if *asJSON {
+		if err := writeJSON(e.stdout, checkoutResult{
+			Number:       mr.Number,
+			Branch:       result.Branch,
+			Head:         result.Head,
+			PreviousHead: result.PreviousHead,
+			Upstream:     result.Upstream,
+			Pulled:       result.Pulled,
+			ForcePushed:  result.ForcePushed,
+			Reset:        result.Reset,
+			NeedsReset:   result.NeedsReset,
+			StashedFrom:  result.StashedFrom,
+			Restored:     result.Restored,
+		}); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(e.stdout, "Checked out #%d on %s at %s\n", mr.Number, result.Branch, result.Head)
+	if result.Pulled > 0 {
+		fmt.Fprintf(e.stdout, "Pulled %d %s from %s\n", result.Pulled, plural(result.Pulled, "commit", "commits"), result.Upstream)
+	}
+	if result.ForcePushed {
+		fmt.Fprintf(e.stdout, "%s was force-pushed\n", result.Upstream)
+	}
+	if result.NeedsReset {
+		fmt.Fprintf(e.stdout, "Kept the branch as it was, run again with --reset to reset it to %s (%d local %s would be dropped)\n",
+			result.Upstream, result.LocalOnly, plural(result.LocalOnly, "commit", "commits"))
+	}
+	if result.StashedFrom != "" {
+		fmt.Fprintf(e.stdout, "Stashed the changes on %s\n", result.StashedFrom)
+	}
+	if result.Restored {
+		fmt.Fprintln(e.stdout, "Restored the changes stashed when you left this branch")
+	}
+	return ExitOK
+}
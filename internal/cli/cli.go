@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/boot"
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+	"github.com/Constantine-Kostikas/GitQuick/internal/ui"
+)
+
+// Exit codes of gq subcommands
+const (
+	ExitOK       = 0
+	ExitFailure  = 1 // the command failed
+	ExitUsage    = 2 // bad arguments
+	ExitNotFound = 3 // the MR doesn't exist, or nothing matched
+)
+
+// command is a gq subcommand
+type command struct {
+	name    string
+	args    string // usage after the name
+	summary string
+	run     func(e *env, args []string) int
+}
+
+// commands lists the subcommands in the order usage shows them
+var commands = []command{
+	{"list", "[--author A] [--state S] [--label L]... [--limit N] [--json]", "List MRs", list},
+	{"show", "<number> [--json]", "Show an MR with its files", show},
+	{"checkout", "<number> [--stash] [--reset] [--json]", "Check an MR out", checkout},
+	{"open", "<number> [--json]", "Open an MR in the browser", open},
+	{"commits", "<number> [--json]", "List an MR's commits", commits},
+	{"config", "", "Print the configuration in effect here and where it came from", showConfig},
+	{"doctor", "", "Check git, gh or glab, login and network for this repository", doctor},
+}
+
+// usage describes every subcommand
+func usage() string {
+	var b strings.Builder
+	b.WriteString("Usage: gq [command]\n\nWith no command gq opens the dashboard.\n\nCommands:\n")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", c.name, c.args, c.summary)
+	}
+	fmt.Fprintf(w, "  help\tShow this help\n")
+	_ = w.Flush()
+	b.WriteString("\nExit codes: 0 success, 1 failure, 2 usage error, 3 nothing found\n")
+	return b.String()
+}
+
+// env is what a subcommand runs with. The repository and its platform are
+// only looked up once the arguments are known to be valid.
+type env struct {
+	ctx      context.Context
+	command  command // the subcommand being run
+	stdout   io.Writer
+	stderr   io.Writer
+	load     func() (boot.System, error)
+	platform platform.Platform
+	repoPath string
+	config   config.Config
+}
+
+// Run runs the subcommand named by args[0] and returns its exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return run(&env{ctx: ctx, stdout: stdout, stderr: stderr, load: bootstrap}, args)
+}
+
+// run dispatches args to their subcommand
+func run(e *env, args []string) int {
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(e.stdout, usage())
+		return ExitOK
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			e.command = c
+			return c.run(e, args[1:])
+		}
+	}
+	fmt.Fprintf(e.stderr, "gq: unknown command %q\n\n%s", args[0], usage())
+	return ExitUsage
+}
+
+// bootstrap finds the repository gq was started in, its config and platform
+func bootstrap() (boot.System, error) {
+	system := boot.Bootstrap()
+	return system, errors.Join(system.Errors...)
+}
+
+// connect loads the repository, its config and platform, reporting a failure
+func (e *env) connect() bool {
+	system, err := e.load()
+	if err != nil {
+		fmt.Fprintf(e.stderr, "gq: %v\n", err)
+		return false
+	}
+	e.platform, e.repoPath, e.config = system.Platform, system.WorkingDir, system.Config
+	return true
+}
+
+// fail reports err and returns the exit code for it
+func (e *env) fail(err error) int {
+	fmt.Fprintf(e.stderr, "gq: %s\n", ui.DescribeError(err))
+	if errors.Is(err, platform.ErrNotFound) {
+		return ExitNotFound
+	}
+	return ExitFailure
+}
+
+// usageError reports a bad argument and returns ExitUsage
+func (e *env) usageError(fs *flag.FlagSet, format string, a ...any) int {
+	fmt.Fprintf(e.stderr, "gq %s: %s\n", fs.Name(), fmt.Sprintf(format, a...))
+	fs.Usage()
+	return ExitUsage
+}
+
+// newFlagSet creates the flag set of the subcommand, printing its usage to
+// stderr instead of exiting
+func (e *env) newFlagSet() *flag.FlagSet {
+	c := e.command
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: gq %s %s\n", c.name, c.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags anywhere among args, as in "gq show 42 --json",
+// and returns the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// numberArg parses the single MR number a subcommand takes
+func numberArg(positional []string) (int, error) {
+	if len(positional) != 1 {
+		return 0, errors.New("expects one MR number")
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(positional[0], "#"))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not an MR number", positional[0])
+	}
+	return n, nil
+}
+
+// parseNumber parses the flags of a subcommand taking one MR number. When ok
+// is false the subcommand should exit with code.
+func (e *env) parseNumber(fs *flag.FlagSet, args []string) (n, code int, ok bool) {
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0, ExitOK, false
+	}
+	if err != nil {
+		return 0, ExitUsage, false
+	}
+	if n, err = numberArg(positional); err != nil {
+		return 0, e.usageError(fs, "%v", err), false
+	}
+	return n, ExitOK, true
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// newTable returns a writer aligning tab-separated columns
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// stringList is a repeatable flag, also splitting values on commas
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
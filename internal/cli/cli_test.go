@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/boot"
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// fakePlatform serves canned MRs; methods the tests don't use panic
+type fakePlatform struct {
+	platform.Platform
+	mrs     []platform.MR
+	commits []platform.Commit
+	query   platform.MRQuery
+}
+
+func (f *fakePlatform) SearchMRs(_ context.Context, q platform.MRQuery) ([]platform.MR, error) {
+	f.query = q
+	return f.mrs, nil
+}
+
+func (f *fakePlatform) GetMR(_ context.Context, number int) (platform.MR, error) {
+	for _, mr := range f.mrs {
+		if mr.Number == number {
+			return mr, nil
+		}
+	}
+	return platform.MR{}, notFound()
+}
+
+func (f *fakePlatform) GetMRDetail(_ context.Context, number int) (platform.MRDetail, error) {
+	return platform.MRDetail{
+		Number: number,
+		Body:   "Fixes the thing",
+		Files:  []platform.FileChange{{Path: "main.go", Additions: 3, Deletions: 1}},
+		Labels: []string{"bug"},
+	}, nil
+}
+
+func (f *fakePlatform) GetMRStatus(context.Context, int) (string, error) {
+	return "draft", nil
+}
+
+func (f *fakePlatform) GetMRCommits(context.Context, int) ([]platform.Commit, error) {
+	return f.commits, nil
+}
+
+// notFound is the error gh gives for a missing PR, classified
+func notFound() error {
+	return &platform.Error{CLI: "gh", Kind: platform.ErrNotFound, Err: &cmd.Error{Name: "gh", Stderr: "no pull requests found"}}
+}
+
+func (f *fakePlatform) GetRepoInfo(context.Context) (platform.RepoInfo, error) {
+	return platform.RepoInfo{}, notFound()
+}
+
+// runCLI runs args against p and returns the exit code and output
+func runCLI(p platform.Platform, args ...string) (int, string, string) {
+	return runCLIIn(p, "/repo", args...)
+}
+
+// runCLIIn runs args against p in the repository at dir
+func runCLIIn(p platform.Platform, dir string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	cfg := config.Default()
+	cfg.Defaults.Author = "carol"
+	e := &env{
+		ctx:    context.Background(),
+		stdout: &stdout,
+		stderr: &stderr,
+		load: func() (boot.System, error) {
+			return boot.System{Platform: p, WorkingDir: dir, Config: cfg}, nil
+		},
+	}
+	code := run(e, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	p := &fakePlatform{}
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"bogus"}, ExitUsage},
+		{[]string{"help"}, ExitOK},
+		{[]string{"show"}, ExitUsage},
+		{[]string{"show", "abc"}, ExitUsage},
+		{[]string{"show", "1", "2"}, ExitUsage},
+		{[]string{"commits", "--nope", "1"}, ExitUsage},
+		{[]string{"list", "--state", "weird"}, ExitUsage},
+		{[]string{"list", "extra"}, ExitUsage},
+		{[]string{"doctor", "extra"}, ExitUsage},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := runCLI(p, tt.args...); code != tt.want {
+			t.Errorf("gq %v: exit code %d, want %d\n%s", tt.args, code, tt.want, stderr)
+		}
+	}
+
+	_, stdout, _ := runCLI(p, "help")
+	for _, name := range []string{"list", "show", "checkout", "open", "commits", "config", "doctor"} {
+		if !strings.Contains(stdout, "  "+name+" ") {
+			t.Errorf("help doesn't list %s:\n%s", name, stdout)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	p := &fakePlatform{mrs: []platform.MR{
+		{Number: 42, Title: "Fix login", Branch: "fix-login", Status: "open"},
+		{Number: 7, Title: "Docs", Branch: "docs", Status: "draft"},
+	}}
+
+	code, stdout, _ := runCLI(p, "list", "--author", "alice", "--label", "bug,ui", "--label", "p1", "--state", "all")
+	if code != ExitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	want := platform.MRQuery{Author: "alice", State: "all", Labels: []string{"bug", "ui", "p1"}}
+	if !reflect.DeepEqual(p.query, want) {
+		t.Errorf("query = %+v, want %+v", p.query, want)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "#") || !strings.Contains(lines[1], "fix-login") {
+		t.Errorf("unexpected table:\n%s", stdout)
+	}
+
+	// The author defaults to the config's, --author "" lists anyone's
+	runCLI(p, "list")
+	if p.query.Author != "carol" {
+		t.Errorf("default author = %q, want the config's", p.query.Author)
+	}
+	runCLI(p, "list", "--author", "")
+	if p.query.Author != "" {
+		t.Errorf("--author \"\" gave author %q", p.query.Author)
+	}
+
+	code, stdout, _ = runCLI(p, "list", "--json")
+	var mrs []platform.MR
+	if code != ExitOK || json.Unmarshal([]byte(stdout), &mrs) != nil || !reflect.DeepEqual(mrs, p.mrs) {
+		t.Errorf("list --json: exit code %d, output %s", code, stdout)
+	}
+	if !strings.Contains(stdout, `"number": 42`) {
+		t.Errorf("JSON fields aren't lowercase: %s", stdout)
+	}
+
+	code, stdout, _ = runCLI(&fakePlatform{}, "list", "--json")
+	if code != ExitNotFound || strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("empty list: exit code %d, output %q", code, stdout)
+	}
+}
+
+func TestShow(t *testing.T) {
+	p := &fakePlatform{mrs: []platform.MR{{Number: 42, Title: "Fix login", Branch: "fix-login", Status: "open"}}}
+
+	// Flags may follow the number
+	code, stdout, _ := runCLI(p, "show", "42", "--json")
+	if code != ExitOK {
+		t.Fatalf("exit code %d", code)
+	}
+	var shown map[string]any
+	if err := json.Unmarshal([]byte(stdout), &shown); err != nil {
+		t.Fatal(err)
+	}
+	if shown["number"] != 42.0 || shown["status"] != "draft" || shown["body"] != "Fixes the thing" {
+		t.Errorf("unexpected JSON: %s", stdout)
+	}
+
+	code, stdout, _ = runCLI(p, "show", "#42")
+	if code != ExitOK || !strings.Contains(stdout, "#42 Fix login") || !strings.Contains(stdout, "main.go") {
+		t.Errorf("exit code %d, output:\n%s", code, stdout)
+	}
+
+	code, _, stderr := runCLI(p, "show", "99")
+	if code != ExitNotFound || !strings.Contains(stderr, "Not found") {
+		t.Errorf("missing MR: exit code %d, stderr %q", code, stderr)
+	}
+}
+
+func TestCommits(t *testing.T) {
+	p := &fakePlatform{
+		mrs:     []platform.MR{{Number: 42}},
+		commits: []platform.Commit{{SHA: "0123456789abcdef", Message: "Fix it\n\nDetails", Author: "alice", Date: "2026-01-02"}},
+	}
+	code, stdout, _ := runCLI(p, "commits", "42")
+	if code != ExitOK || !strings.Contains(stdout, "0123456") || strings.Contains(stdout, "Details") {
+		t.Errorf("exit code %d, output:\n%s", code, stdout)
+	}
+
+	p.commits = nil
+	if code, _, _ := runCLI(p, "commits", "42"); code != ExitNotFound {
+		t.Errorf("no commits: exit code %d, want %d", code, ExitNotFound)
+	}
+}
+
+func TestCheckout_FailsAfterStash(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(kv, "test")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(kv, "test@example.com")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "initial"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// There's no origin to fetch the MR's branch from
+	p := &fakePlatform{mrs: []platform.MR{{Number: 42, Branch: "feature"}}}
+	code, stdout, stderr := runCLIIn(p, repo, "checkout", "42", "--stash", "--json")
+	if code != ExitFailure || !strings.Contains(stderr, `stashed as "gq autostash from main"`) {
+		t.Errorf("exit code %d, stderr:\n%s", code, stderr)
+	}
+	var got checkoutFailure
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil || got.Number != 42 || got.StashedFrom != "main" || got.Error == "" {
+		t.Errorf("JSON %+v, %v from:\n%s", got, err, stdout)
+	}
+}
+
+func TestPrintChecks(t *testing.T) {
+	var out bytes.Buffer
+	printChecks(&out, []boot.Check{
+		{Name: "git", Detail: "git version 2.43.0"},
+		{Name: "gh", State: boot.CheckFailed, Detail: "gh is not installed or not on PATH", Fix: "Install it from https://cli.github.com"},
+		{Name: "gh auth", State: boot.CheckSkipped},
+	})
+	got := out.String()
+	for _, want := range []string{
+		"✓ git        git version 2.43.0",
+		"✗ gh         gh is not installed",
+		"Install it from https://cli.github.com",
+		"- gh auth    skipped",
+		"1 check failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+)
+
+// showConfig prints the merged configuration in effect in the current
+// directory, or what's wrong with it
+func showConfig(e *env, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(e.stderr, "Usage: gq config")
+		return ExitUsage
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(e.stderr, "gq: %v\n", err)
+		return ExitFailure
+	}
+
+	// Outside a repository only the user's config applies
+	root, _ := git.RepoRoot(e.ctx, dir)
+	cfg, err := config.Load(root)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "gq: invalid config:\n%v\n", err)
+		return ExitFailure
+	}
+	fmt.Fprint(e.stdout, cfg.Format())
+	formatGitSettings(e.stdout, git.Settings(e.ctx, dir))
+	return ExitOK
+}
+
+// formatGitSettings prints the settings gq reads from git config, commented
+// out so that the output still loads as a config file
+func formatGitSettings(w io.Writer, settings []git.Setting) {
+	fmt.Fprintln(w, "\n# Read from git config, change them with git config gq.<name> <value>")
+	fmt.Fprintln(w, "# [git]")
+	for _, s := range settings {
+		source := "default"
+		if s.Origin != "" {
+			source = "from " + s.Origin
+		}
+		fmt.Fprintf(w, "# %s = %s # %s\n", strings.TrimPrefix(s.Key, "gq."), strconv.Quote(s.Value), source)
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/boot"
+)
+
+// doctor runs every preflight check, network ones included, prints a
+// checklist and fails if any check failed
+func doctor(e *env, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(e.stderr, "Usage: gq doctor")
+		return ExitUsage
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(e.stderr, "gq: %v\n", err)
+		return ExitFailure
+	}
+
+	checks := boot.Preflight(e.ctx, dir, true)
+	printChecks(e.stdout, checks)
+	for _, c := range checks {
+		if c.State == boot.CheckFailed {
+			return ExitFailure
+		}
+	}
+	return ExitOK
+}
+
+// printChecks writes one line per check, with the fix under each failure
+func printChecks(w io.Writer, checks []boot.Check) {
+	failed := 0
+	for _, c := range checks {
+		mark := "✓"
+		switch c.State {
+		case boot.CheckFailed:
+			mark = "✗"
+			failed++
+		case boot.CheckSkipped:
+			mark = "-"
+			c.Detail = "skipped"
+		}
+		// Continuation lines, such as each invalid setting, line up
+		detail := strings.ReplaceAll(c.Detail, "\n", "\n"+strings.Repeat(" ", 13))
+		fmt.Fprintf(w, "%s %-10s %s\n", mark, c.Name, detail)
+		if c.State == boot.CheckFailed && c.Fix != "" {
+			fmt.Fprintf(w, "  %-10s %s\n", "", c.Fix)
+		}
+	}
+	if failed == 0 {
+		fmt.Fprintln(w, "\nAll checks passed")
+	} else {
+		fmt.Fprintf(w, "\n%d %s failed\n", failed, plural(failed, "check", "checks"))
+	}
+}
+
+// plural picks the singular or plural form for n
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
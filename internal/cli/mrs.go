@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+	"github.com/Constantine-Kostikas/GitQuick/internal/ui"
+)
+
+// list prints the MRs matching the author, state and label flags
+func list(e *env, args []string) int {
+	fs := e.newFlagSet()
+	var q platform.MRQuery
+	var labels stringList
+	fs.StringVar(&q.Author, "author", "", "author's username, @me for you, empty for anyone (default defaults.author from the config)")
+	fs.StringVar(&q.State, "state", "open", "open, draft, merged, closed or all")
+	fs.Var(&labels, "label", "only MRs with this label, repeatable")
+	fs.IntVar(&q.Limit, "limit", 0, "most MRs to list, 0 for the CLI's default")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		return e.usageError(fs, "unexpected argument %q", positional[0])
+	}
+	switch q.State {
+	case "open", "draft", "merged", "closed", "all":
+	default:
+		return e.usageError(fs, "unknown state %q", q.State)
+	}
+	if q.Limit < 0 {
+		return e.usageError(fs, "--limit can't be negative")
+	}
+	q.Labels = labels
+	authorSet := false
+	fs.Visit(func(f *flag.Flag) { authorSet = authorSet || f.Name == "author" })
+
+	if !e.connect() {
+		return ExitFailure
+	}
+	if !authorSet {
+		q.Author = e.config.Defaults.Author
+	}
+	mrs, err := e.platform.SearchMRs(e.ctx, q)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *asJSON {
+		if mrs == nil {
+			mrs = []platform.MR{}
+		}
+		if err := writeJSON(e.stdout, mrs); err != nil {
+			return e.fail(err)
+		}
+	} else if len(mrs) > 0 {
+		w := newTable(e.stdout)
+		fmt.Fprintln(w, "#\tSTATUS\tBRANCH\tTITLE")
+		for _, mr := range mrs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", mr.Number, mr.Status, mr.Branch, mr.Title)
+		}
+		_ = w.Flush()
+	}
+	if len(mrs) == 0 {
+		if !*asJSON {
+			fmt.Fprintln(e.stderr, "No MRs found")
+		}
+		return ExitNotFound
+	}
+	return ExitOK
+}
+
+// shownMR is an MR with its details, as gq show --json prints it
+type shownMR struct {
+	platform.MR
+	Body      string                `json:"body"`
+	Files     []platform.FileChange `json:"files"`
+	Additions int                   `json:"additions"`
+	Deletions int                   `json:"deletions"`
+	Reviewers []string              `json:"reviewers"`
+	Assignees []string              `json:"assignees"`
+	Labels    []string              `json:"labels"`
+}
+
+// show prints an MR with its description and changed files
+func show(e *env, args []string) int {
+	fs := e.newFlagSet()
+	asJSON := fs.Bool("json", false, "print JSON")
+	number, code, ok := e.parseNumber(fs, args)
+	if !ok {
+		return code
+	}
+
+	if !e.connect() {
+		return ExitFailure
+	}
+	mr, err := e.platform.GetMR(e.ctx, number)
+	if err != nil {
+		return e.fail(err)
+	}
+	detail, err := e.platform.GetMRDetail(e.ctx, number)
+	if err != nil {
+		return e.fail(err)
+	}
+	if status, err := e.platform.GetMRStatus(e.ctx, number); err == nil {
+		// Lists don't tell drafts apart on every platform, the status does
+		mr.Status = status
+	}
+
+	shown := shownMR{
+		MR:        mr,
+		Body:      detail.Body,
+		Files:     detail.Files,
+		Additions: detail.Additions,
+		Deletions: detail.Deletions,
+		Reviewers: detail.Reviewers,
+		Assignees: detail.Assignees,
+		Labels:    detail.Labels,
+	}
+	if *asJSON {
+		if err := writeJSON(e.stdout, shown); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(e.stdout, "#%d %s\n\n", mr.Number, mr.Title)
+	w := newTable(e.stdout)
+	fmt.Fprintf(w, "Status:\t%s\n", mr.Status)
+	fmt.Fprintf(w, "Branch:\t%s\n", mr.Branch)
+	fmt.Fprintf(w, "URL:\t%s\n", mr.URL)
+	fmt.Fprintf(w, "Reviewers:\t%s\n", joinOrNone(shown.Reviewers))
+	fmt.Fprintf(w, "Assignees:\t%s\n", joinOrNone(shown.Assignees))
+	fmt.Fprintf(w, "Labels:\t%s\n", joinOrNone(shown.Labels))
+	_ = w.Flush()
+	if body := strings.TrimSpace(shown.Body); body != "" {
+		fmt.Fprintf(e.stdout, "\n%s\n", body)
+	}
+
+	fmt.Fprintf(e.stdout, "\nFiles (%d, +%d -%d):\n", len(shown.Files), shown.Additions, shown.Deletions)
+	w = newTable(e.stdout)
+	for _, f := range shown.Files {
+		fmt.Fprintf(w, "  +%d\t-%d\t%s\n", f.Additions, f.Deletions, f.Path)
+	}
+	_ = w.Flush()
+	return ExitOK
+}
+
+// open opens an MR in the browser
+func open(e *env, args []string) int {
+	fs := e.newFlagSet()
+	asJSON := fs.Bool("json", false, "print the URL as JSON")
+	number, code, ok := e.parseNumber(fs, args)
+	if !ok {
+		return code
+	}
+
+	if !e.connect() {
+		return ExitFailure
+	}
+	mr, err := e.platform.GetMR(e.ctx, number)
+	if err != nil {
+		return e.fail(err)
+	}
+	if err := ui.OpenBrowser(mr.URL); err != nil {
+		return e.fail(fmt.Errorf("opening %s: %w", mr.URL, err))
+	}
+
+	if *asJSON {
+		if err := writeJSON(e.stdout, map[string]any{"number": mr.Number, "url": mr.URL}); err != nil {
+			return e.fail(err)
+		}
+		return ExitOK
+	}
+	fmt.Fprintf(e.stdout, "Opened %s\n", mr.URL)
+	return ExitOK
+}
+
+// commits lists an MR's commits
+func commits(e *env, args []string) int {
+	fs := e.newFlagSet()
+	asJSON := fs.Bool("json", false, "print JSON")
+	number, code, ok := e.parseNumber(fs, args)
+	if !ok {
+		return code
+	}
+
+	if !e.connect() {
+		return ExitFailure
+	}
+	found, err := e.platform.GetMRCommits(e.ctx, number)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *asJSON {
+		if found == nil {
+			found = []platform.Commit{}
+		}
+		if err := writeJSON(e.stdout, found); err != nil {
+			return e.fail(err)
+		}
+	} else if len(found) > 0 {
+		w := newTable(e.stdout)
+		fmt.Fprintln(w, "SHA\tAUTHOR\tDATE\tMESSAGE")
+		for _, c := range found {
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", shortSHA(c.SHA), c.Author, c.Date, subject)
+		}
+		_ = w.Flush()
+	}
+	if len(found) == 0 {
+		if !*asJSON {
+			fmt.Fprintln(e.stderr, "No commits found")
+		}
+		return ExitNotFound
+	}
+	return ExitOK
+}
+
+// joinOrNone joins names with commas, or says there are none
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// shortSHA abbreviates a commit SHA as git does by default
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
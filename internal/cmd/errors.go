@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+var (
+	// ErrNotInstalled matches errors of commands whose program isn't on PATH
+	ErrNotInstalled = errors.New("not installed")
+	// ErrTimeout matches errors of commands that ran past their timeout
+	ErrTimeout = errors.New("timed out")
+)
+
+// Error is a command that couldn't be started, exited with an error or
+// timed out
+type Error struct {
+	Name     string // program that was run, e.g. "gh"
+	Args     []string
+	ExitCode int    // -1 if it didn't start or was killed
+	Stderr   string // what it wrote to stderr, trimmed; the last line when streamed
+	Err      error  // the underlying error
+}
+
+// Error keeps the "exit status N: stderr" form git and CLI users know
+func (e *Error) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrTimeout):
+		return fmt.Sprintf("%s %s", e.Name, e.Err)
+	case errors.Is(e.Err, exec.ErrNotFound):
+		return fmt.Sprintf("%s is not installed or not on PATH", e.Name)
+	case e.Stderr != "":
+		return e.Err.Error() + ": " + e.Stderr
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrNotInstalled for programs that weren't found
+func (e *Error) Is(target error) bool {
+	return target == ErrNotInstalled && errors.Is(e.Err, exec.ErrNotFound)
+}
+
+// newError wraps the error of a finished command with its exit code
+func newError(name string, args []string, stderr string, err error) *Error {
+	code := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+	return &Error{Name: name, Args: args, ExitCode: code, Stderr: stderr, Err: err}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package cmd
+
+import "os/exec"
+
+// setProcessGroup leaves cmd with the default cancellation, which kills the
+// process itself; there are no process groups to signal here. Children that
+// keep its output open are cut off after killGrace.
+func setProcessGroup(cmd *exec.Cmd) (stop func()) {
+	cmd.WaitDelay = killGrace
+	return func() {}
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts cmd in a new process group and makes cancellation
+// signal the whole group, so children such as git's remote helpers stop too.
+// The returned stop must be called once cmd.Wait returns.
+func setProcessGroup(cmd *exec.Cmd) (stop func()) {
+	var kill *time.Timer
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals the group. Processes get SIGTERM first so
+		// git can remove its lock files, and SIGKILL after killGrace if any
+		// of them, not just the leader, are still around.
+		pgid := -cmd.Process.Pid
+		kill = time.AfterFunc(killGrace, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+	return func() {
+		// Wait returns after Cancel does. Once the group is gone its id may
+		// be reused, so it mustn't be signalled any more.
+		if kill != nil {
+			kill.Stop()
+		}
+	}
+}
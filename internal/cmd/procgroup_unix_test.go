@@ -0,0 +1,26 @@
+//go:build unix
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunSimple_CancelKillsGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// Both the shell and its child ignore SIGTERM, and the child holds stderr
+	// open, so the command only returns once the whole group is killed
+	start := time.Now()
+	err := RunSimple(ctx, "", "sh", "-c", `trap "" TERM; sleep 30 & wait`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want a context.Canceled error", err)
+	}
+	if elapsed := time.Since(start); elapsed < killGrace || elapsed > killGrace+2*time.Second {
+		t.Errorf("cancelled command took %v to return, want about %v", elapsed, killGrace)
+	}
+}
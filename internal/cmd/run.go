@@ -1,71 +1,138 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"time"
 )
 
-// DefaultTimeout is the default timeout for commands
-const DefaultTimeout = 30 * time.Second
+// DefaultTimeout is the default timeout for commands, set from the config
+// at startup
+var DefaultTimeout = 30 * time.Second
+
+// killGrace is how long a cancelled command gets to exit after being asked to
+// stop before it is killed outright
+const killGrace = 3 * time.Second
 
 // Run executes a command with timeout and captures stderr on failure
-func Run(dir string, name string, args ...string) ([]byte, error) {
-	return RunWithTimeout(dir, DefaultTimeout, name, args...)
+func Run(ctx context.Context, dir string, name string, args ...string) ([]byte, error) {
+	return RunWithTimeout(ctx, dir, DefaultTimeout, name, args...)
 }
 
 // RunWithTimeout executes a command with a specified timeout and captures stderr on failure
-func RunWithTimeout(dir string, timeout time.Duration, name string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = dir
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("command timed out after %v", timeout)
-		}
-		stderrStr := strings.TrimSpace(stderr.String())
-		if stderrStr != "" {
-			return nil, fmt.Errorf("%s: %s", err.Error(), stderrStr)
-		}
+func RunWithTimeout(ctx context.Context, dir string, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	if err := run(ctx, dir, timeout, nil, &stdout, nil, name, args...); err != nil {
 		return nil, err
 	}
+	return stdout.Bytes(), nil
+}
 
+// RunWithInput executes a command with stdin fed from input
+func RunWithInput(ctx context.Context, dir string, input []byte, name string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	if err := run(ctx, dir, DefaultTimeout, bytes.NewReader(input), &stdout, nil, name, args...); err != nil {
+		return nil, err
+	}
 	return stdout.Bytes(), nil
 }
 
 // RunSimple executes a command without capturing output, with timeout
-func RunSimple(dir string, name string, args ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+func RunSimple(ctx context.Context, dir string, name string, args ...string) error {
+	return run(ctx, dir, DefaultTimeout, nil, nil, nil, name, args...)
+}
+
+// RunStreaming executes a command with timeout, passing each line it writes to
+// stderr to onLine as it arrives. Carriage returns end a line too, so progress
+// meters that redraw in place are reported on every update.
+func RunStreaming(ctx context.Context, dir string, onLine func(string), name string, args ...string) error {
+	return run(ctx, dir, DefaultTimeout, nil, nil, onLine, name, args...)
+}
+
+// run executes a command in its own process group. It stops when ctx is
+// cancelled or the timeout expires: the group is asked to terminate and is
+// killed if it hasn't exited after killGrace. Failures are returned as an
+// *Error carrying stderr, or its last line if it was streamed line by line
+// to onLine.
+func run(ctx context.Context, dir string, timeout time.Duration, stdin io.Reader, stdout io.Writer, onLine func(string), name string, args ...string) error {
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, name, args...)
+	cmd := exec.CommandContext(runCtx, name, args...)
 	cmd.Dir = dir
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	stop := setProcessGroup(cmd)
+	defer stop()
 
 	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	var lastLine string
+	var err error
+	if onLine == nil {
+		cmd.Stderr = &stderr
+		err = cmd.Run()
+	} else {
+		err = runStreaming(cmd, onLine, &lastLine)
+	}
 
-	err := cmd.Run()
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("command timed out after %v", DefaultTimeout)
+		if ctx.Err() != nil {
+			// Cancelled by the caller rather than failed
+			return fmt.Errorf("%s cancelled: %w", name, ctx.Err())
+		}
+		if runCtx.Err() == context.DeadlineExceeded {
+			return newError(name, args, "", fmt.Errorf("%w after %v", ErrTimeout, timeout))
 		}
 		stderrStr := strings.TrimSpace(stderr.String())
-		if stderrStr != "" {
-			return fmt.Errorf("%s: %s", err.Error(), stderrStr)
+		if onLine != nil {
+			stderrStr = lastLine
 		}
+		return newError(name, args, stderrStr, err)
+	}
+	return nil
+}
+
+// runStreaming runs cmd, scanning its stderr into onLine. The last line that
+// isn't a progress update is kept for the error message.
+func runStreaming(cmd *exec.Cmd, onLine func(string), lastLine *string) error {
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
 		return err
 	}
 
-	return nil
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanLinesOrCR)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		onLine(line)
+		if !strings.Contains(line, "%") {
+			*lastLine = line
+		}
+	}
+	return cmd.Wait()
+}
+
+// scanLinesOrCR is a bufio.SplitFunc that splits on \n or \r
+func scanLinesOrCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
 }
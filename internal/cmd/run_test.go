@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_Output(t *testing.T) {
+	out, err := Run(context.Background(), "", "go", "env", "GOOS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != runtime.GOOS {
+		t.Errorf("got %q, want %q", got, runtime.GOOS)
+	}
+}
+
+func TestRunSimple_Cancel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// The child sleep holds stdout/stderr open; only killing the whole group
+	// lets the command return promptly
+	start := time.Now()
+	err := RunSimple(ctx, "", "sh", "-c", "sleep 10 & wait")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want a context.Canceled error", err)
+	}
+	if elapsed := time.Since(start); elapsed > killGrace {
+		t.Errorf("cancelled command took %v to return", elapsed)
+	}
+}
+
+func TestRunStreaming_Lines(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var lines []string
+	err := RunStreaming(context.Background(), "", func(line string) {
+		lines = append(lines, line)
+	}, "sh", "-c", `printf 'Receiving:  10%%\rReceiving:  50%%\rReceiving: 100%%, done.\nfinished\n' >&2`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"Receiving:  10%", "Receiving:  50%", "Receiving: 100%, done.", "finished"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("got %q, want %q", lines, expected)
+	}
+}
+
+func TestRun_Error(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	_, err := Run(context.Background(), "", "sh", "-c", "echo 'HTTP 401: Bad credentials' >&2; exit 4")
+	var cmdErr *Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("got %T %v, want an *Error", err, err)
+	}
+	if cmdErr.Name != "sh" || cmdErr.ExitCode != 4 || cmdErr.Stderr != "HTTP 401: Bad credentials" {
+		t.Errorf("unexpected error fields %+v", cmdErr)
+	}
+	if got := err.Error(); got != "exit status 4: HTTP 401: Bad credentials" {
+		t.Errorf("got message %q", got)
+	}
+}
+
+func TestRun_NotInstalled(t *testing.T) {
+	err := RunSimple(context.Background(), "", "gq-no-such-program")
+	if !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("got %v, want ErrNotInstalled", err)
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Error("a missing program isn't a timeout")
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	_, err := RunWithTimeout(context.Background(), "", 50*time.Millisecond, "sleep", "5")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("got %v, want ErrTimeout", err)
+	}
+}
@@ -0,0 +1,558 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+	"github.com/Constantine-Kostikas/GitQuick/internal/theme"
+)
+
+// Config is gq's configuration: built-in defaults, overridden by the user's
+// config file, then by the repo's .gq.toml or .gq.yaml, then by environment
+// variables
+type Config struct {
+	Defaults Defaults
+	Timeouts Timeouts
+	Hosts    map[string]string // remote host -> "github" or "gitlab", for self-hosted forges
+	Tracker  Tracker
+	UI       UI
+	Cache    Cache
+	Poll     Poll
+	Webhook  Webhook
+	Keys     map[string][]string          // action, e.g. "dashboard.refresh" -> the keys replacing its defaults
+	Themes   map[string]map[string]string // custom theme -> color role or "base" -> value
+
+	Sources []string // files and variables the settings came from, in order
+}
+
+// Defaults are what the dashboard starts with
+type Defaults struct {
+	Author      string // MR author listed first, @me for you
+	WorktreeDir string // where MR worktrees go, empty for <repo>.worktrees
+}
+
+// Timeouts bound the commands gq runs
+type Timeouts struct {
+	Command time.Duration // each git, gh or glab command
+	Network time.Duration // gq doctor's login and reachability checks
+}
+
+// Tracker links ticket IDs in MR titles to an issue tracker
+type Tracker struct {
+	URL     string // tracker base URL, or a URL with {ticket} in it
+	Pattern string // finds the ticket in a title; its first group is the ticket ID
+}
+
+// UI holds display preferences
+type UI struct {
+	SideBySide bool   // open diffs side by side
+	Wrap       bool   // wrap long diff lines
+	Theme      string // a built-in theme, auto or one from Themes
+}
+
+// Cache controls the on-disk cache the dashboard starts from
+type Cache struct {
+	Enabled  bool
+	MRs      time.Duration // how long MR lists are used before asking the forge again
+	RepoInfo time.Duration
+	Authors  time.Duration
+	Details  time.Duration // MR details
+}
+
+// Poll controls how often the dashboard reloads the MR list by itself. It's
+// off by default since every poll costs API rate limit, and a running
+// webhook listener takes its place.
+type Poll struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// Webhook controls the listener for forge webhooks, forwarded by a tunnel
+// or relay
+type Webhook struct {
+	Enabled bool
+	Listen  string // host:port to listen on
+	Secret  string // shared with the forge, $GQ_WEBHOOK_SECRET overrides it
+}
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		Defaults: Defaults{Author: "@me"},
+		Timeouts: Timeouts{Command: 30 * time.Second, Network: 15 * time.Second},
+		Hosts:    map[string]string{},
+		Tracker:  Tracker{Pattern: `#([A-Z]+-\d+)`},
+		UI:       UI{Theme: theme.Auto},
+		Cache: Cache{
+			Enabled:  true,
+			MRs:      2 * time.Minute,
+			RepoInfo: 24 * time.Hour,
+			Authors:  24 * time.Hour,
+			Details:  10 * time.Minute,
+		},
+		Poll:    Poll{Interval: 2 * time.Minute},
+		Webhook: Webhook{Listen: "127.0.0.1:8765"},
+		Keys:    map[string][]string{},
+		Themes:  map[string]map[string]string{},
+		Sources: []string{"defaults"},
+	}
+}
+
+// TicketURL returns the tracker URL of ticket, empty if no tracker is set
+func (t Tracker) TicketURL(ticket string) string {
+	if t.URL == "" {
+		return ""
+	}
+	if strings.Contains(t.URL, "{ticket}") {
+		return strings.ReplaceAll(t.URL, "{ticket}", ticket)
+	}
+	// Jira's layout, what JIRA_URL always pointed at
+	return strings.TrimSuffix(t.URL, "/") + "/browse/" + ticket
+}
+
+// Error is an invalid setting in a config file
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return e.File + ": " + e.Msg
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// field is a setting: its dotted key, how to set it from a file and how to
+// print it
+type field struct {
+	key string
+	set func(c *Config, v any) error
+	get func(c Config) string
+}
+
+// fields lists the settings in the order gq config prints them. Hosts, keys
+// and themes are set through their own tables.
+var fields = []field{
+	{"defaults.author",
+		func(c *Config, v any) error { return setString(&c.Defaults.Author, v, false) },
+		func(c Config) string { return strconv.Quote(c.Defaults.Author) }},
+	{"defaults.worktree_dir",
+		func(c *Config, v any) error { return setString(&c.Defaults.WorktreeDir, v, true) },
+		func(c Config) string { return strconv.Quote(c.Defaults.WorktreeDir) }},
+	{"timeouts.command",
+		func(c *Config, v any) error { return setDuration(&c.Timeouts.Command, v) },
+		func(c Config) string { return strconv.Quote(c.Timeouts.Command.String()) }},
+	{"timeouts.network",
+		func(c *Config, v any) error { return setDuration(&c.Timeouts.Network, v) },
+		func(c Config) string { return strconv.Quote(c.Timeouts.Network.String()) }},
+	{"tracker.url",
+		func(c *Config, v any) error { return setString(&c.Tracker.URL, v, true) },
+		func(c Config) string { return strconv.Quote(c.Tracker.URL) }},
+	{"tracker.pattern",
+		func(c *Config, v any) error { return setPattern(&c.Tracker.Pattern, v) },
+		func(c Config) string { return strconv.Quote(c.Tracker.Pattern) }},
+	{"ui.side_by_side",
+		func(c *Config, v any) error { return setBool(&c.UI.SideBySide, v) },
+		func(c Config) string { return strconv.FormatBool(c.UI.SideBySide) }},
+	{"ui.wrap",
+		func(c *Config, v any) error { return setBool(&c.UI.Wrap, v) },
+		func(c Config) string { return strconv.FormatBool(c.UI.Wrap) }},
+	{"ui.theme",
+		func(c *Config, v any) error { return setString(&c.UI.Theme, v, false) },
+		func(c Config) string { return strconv.Quote(c.UI.Theme) }},
+	{"cache.enabled",
+		func(c *Config, v any) error { return setBool(&c.Cache.Enabled, v) },
+		func(c Config) string { return strconv.FormatBool(c.Cache.Enabled) }},
+	{"cache.mrs",
+		func(c *Config, v any) error { return setDuration(&c.Cache.MRs, v) },
+		func(c Config) string { return strconv.Quote(c.Cache.MRs.String()) }},
+	{"cache.repo",
+		func(c *Config, v any) error { return setDuration(&c.Cache.RepoInfo, v) },
+		func(c Config) string { return strconv.Quote(c.Cache.RepoInfo.String()) }},
+	{"cache.authors",
+		func(c *Config, v any) error { return setDuration(&c.Cache.Authors, v) },
+		func(c Config) string { return strconv.Quote(c.Cache.Authors.String()) }},
+	{"cache.details",
+		func(c *Config, v any) error { return setDuration(&c.Cache.Details, v) },
+		func(c Config) string { return strconv.Quote(c.Cache.Details.String()) }},
+	{"poll.enabled",
+		func(c *Config, v any) error { return setBool(&c.Poll.Enabled, v) },
+		func(c Config) string { return strconv.FormatBool(c.Poll.Enabled) }},
+	{"poll.interval",
+		func(c *Config, v any) error { return setDuration(&c.Poll.Interval, v) },
+		func(c Config) string { return strconv.Quote(c.Poll.Interval.String()) }},
+	{"webhook.enabled",
+		func(c *Config, v any) error { return setBool(&c.Webhook.Enabled, v) },
+		func(c Config) string { return strconv.FormatBool(c.Webhook.Enabled) }},
+	{"webhook.listen",
+		func(c *Config, v any) error { return setAddress(&c.Webhook.Listen, v) },
+		func(c Config) string { return strconv.Quote(c.Webhook.Listen) }},
+	{"webhook.secret",
+		func(c *Config, v any) error { return setString(&c.Webhook.Secret, v, true) },
+		func(c Config) string {
+			// gq config output gets pasted into issues
+			if c.Webhook.Secret == "" {
+				return `""`
+			}
+			return `"********" # hidden`
+		}},
+}
+
+// Platforms a host can be mapped to
+var platforms = []string{"github", "gitlab"}
+
+// UserDir returns gq's directory under $XDG_CONFIG_HOME, ~/.config by default
+func UserDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gq")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gq")
+}
+
+// Load reads the user's config file and the override in repoRoot, if any,
+// on top of the defaults. repoRoot may be empty outside a repository. Every
+// invalid setting is reported, joined into one error.
+func Load(repoRoot string) (Config, error) {
+	c := Default()
+	var errs []error
+
+	type candidate struct {
+		paths []string
+		repo  bool
+	}
+	var candidates []candidate
+	if dir := UserDir(); dir != "" {
+		candidates = append(candidates, candidate{paths: []string{
+			filepath.Join(dir, "config.toml"), filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.yml"),
+		}})
+	}
+	if repoRoot != "" {
+		candidates = append(candidates, candidate{paths: []string{
+			filepath.Join(repoRoot, ".gq.toml"), filepath.Join(repoRoot, ".gq.yaml"), filepath.Join(repoRoot, ".gq.yml"),
+		}, repo: true})
+	}
+	for _, cand := range candidates {
+		// The first file that exists wins
+		for _, path := range cand.paths {
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			errs = append(errs, c.apply(path, string(data), cand.repo)...)
+			c.Sources = append(c.Sources, path)
+			break
+		}
+	}
+
+	// The variables gq read before it had a config file still win
+	if v := os.Getenv("GQ_WORKTREE_DIR"); v != "" {
+		c.Defaults.WorktreeDir = v
+		c.Sources = append(c.Sources, "$GQ_WORKTREE_DIR")
+	}
+	if v := os.Getenv("JIRA_URL"); v != "" {
+		c.Tracker.URL = v
+		c.Sources = append(c.Sources, "$JIRA_URL")
+	}
+	if v := os.Getenv("GQ_WEBHOOK_SECRET"); v != "" {
+		c.Webhook.Secret = v
+		c.Sources = append(c.Sources, "$GQ_WEBHOOK_SECRET")
+	}
+	// Unverified webhooks would let anyone on the network rewrite the list
+	if c.Webhook.Enabled && c.Webhook.Secret == "" {
+		errs = append(errs, errors.New("webhook.secret: required with webhook.enabled, set it or GQ_WEBHOOK_SECRET"))
+	}
+
+	// A key may only clash with another once every file is read
+	if _, err := keymap.New(c.Keys); err != nil {
+		errs = append(errs, err)
+	}
+	// as may the theme and the custom theme it names
+	if _, err := theme.Resolve(c.UI.Theme, c.Themes, func() bool { return true }); err != nil {
+		errs = append(errs, fmt.Errorf("ui.theme: %w", err))
+	}
+	return c, errors.Join(errs...)
+}
+
+// userOnly are the settings a repo's config file can't change: a cloned repo
+// mustn't open a listener or choose the secret that verifies its events
+var userOnly = []string{"webhook."}
+
+// apply sets what the config file at path says. repo marks the override
+// checked into a repository, which can't set the userOnly settings.
+func (c *Config) apply(path, data string, repo bool) []error {
+	var entries []entry
+	var errs []error
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		entries, errs = parseYAML(path, data)
+	default:
+		entries, errs = parseTOML(path, data)
+	}
+
+	for _, e := range entries {
+		if repo && slices.ContainsFunc(userOnly, func(prefix string) bool { return strings.HasPrefix(e.key, prefix) }) {
+			errs = append(errs, &Error{File: path, Line: e.line, Msg: e.key + ": can only be set in the user config file"})
+			continue
+		}
+		if err := c.set(e.key, e.value); err != nil {
+			errs = append(errs, &Error{File: path, Line: e.line, Msg: err.Error()})
+		}
+	}
+	return errs
+}
+
+// set sets the setting at key
+func (c *Config) set(key string, v any) error {
+	if host, ok := strings.CutPrefix(key, "hosts."); ok {
+		var name string
+		if err := setString(&name, v, false); err != nil {
+			return fmt.Errorf("hosts.%q: %w", host, err)
+		}
+		if !slices.Contains(platforms, name) {
+			return fmt.Errorf("hosts.%q: platform must be github or gitlab, not %q", host, name)
+		}
+		c.Hosts[strings.ToLower(host)] = name
+		return nil
+	}
+	if action, ok := strings.CutPrefix(key, "keys."); ok {
+		if !keymap.Known(keymap.Action(action)) {
+			return fmt.Errorf("unknown key action %s", action)
+		}
+		keys, err := keyList(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.Keys[action] = keys
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(key, "themes."); ok {
+		return c.setThemeColor(rest, v)
+	}
+	for _, f := range fields {
+		if f.key == key {
+			if err := f.set(c, v); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %s", key)
+}
+
+// Format prints c as a TOML config file
+func (c Config) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Effective configuration, from: %s\n", strings.Join(c.Sources, ", "))
+	b.WriteString("# Config files use a subset of TOML or YAML: tables or nested mappings, quoted strings\n" +
+		"# (bare strings in YAML), integers, booleans, arrays of those and # comments. Inline tables,\n" +
+		"# arrays of tables, floats, dates, multi-line strings and YAML anchors aren't supported.\n")
+	table := ""
+	for _, f := range fields {
+		section, key, _ := strings.Cut(f.key, ".")
+		if section != table {
+			fmt.Fprintf(&b, "\n[%s]\n", section)
+			table = section
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, f.get(c))
+	}
+	c.formatHosts(&b)
+	c.formatKeys(&b)
+	c.formatThemes(&b)
+	return b.String()
+}
+
+// setThemeColor sets a color, or the base, of a custom theme from its
+// "<theme>.<role>" key
+func (c *Config) setThemeColor(key string, v any) error {
+	name, role, ok := strings.Cut(key, ".")
+	if !ok {
+		return fmt.Errorf("themes.%s: expected a table of colors", key)
+	}
+	if theme.Builtin(name) {
+		return fmt.Errorf("themes.%s: %s is a built-in theme, pick another name", name, name)
+	}
+	var value string
+	if err := setString(&value, v, false); err != nil {
+		return fmt.Errorf("themes.%s: %w", key, err)
+	}
+	switch {
+	case role == theme.Base:
+		if !theme.Builtin(value) {
+			return fmt.Errorf("themes.%s: base must be one of %s", key, strings.Join(theme.BuiltinNames(), ", "))
+		}
+	case slices.ContainsFunc(theme.Roles, func(r theme.Role) bool { return r.Name == role }):
+		if err := theme.ValidColor(value); err != nil {
+			return fmt.Errorf("themes.%s: %w", key, err)
+		}
+	default:
+		return fmt.Errorf("unknown theme color %s", role)
+	}
+	if c.Themes[name] == nil {
+		c.Themes[name] = make(map[string]string)
+	}
+	c.Themes[name][role] = value
+	return nil
+}
+
+// formatHosts prints the host mappings, sorted by host
+func (c Config) formatHosts(b *strings.Builder) {
+	b.WriteString("\n[hosts]\n")
+	hosts := make([]string, 0, len(c.Hosts))
+	for host := range c.Hosts {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	for _, host := range hosts {
+		fmt.Fprintf(b, "%s = %s\n", strconv.Quote(host), strconv.Quote(c.Hosts[host]))
+	}
+}
+
+// formatKeys prints the rebound actions, a table per context
+func (c Config) formatKeys(b *strings.Builder) {
+	actions := make([]string, 0, len(c.Keys))
+	for action := range c.Keys {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+	table := ""
+	for _, action := range actions {
+		ctx, name, _ := strings.Cut(action, ".")
+		if ctx != table {
+			fmt.Fprintf(b, "\n[keys.%s]\n", ctx)
+			table = ctx
+		}
+		quoted := make([]string, len(c.Keys[action]))
+		for i, key := range c.Keys[action] {
+			quoted[i] = strconv.Quote(key)
+		}
+		fmt.Fprintf(b, "%s = [%s]\n", name, strings.Join(quoted, ", "))
+	}
+}
+
+// formatThemes prints the custom themes, base first and then the colors in
+// palette order
+func (c Config) formatThemes(b *strings.Builder) {
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		colors := c.Themes[name]
+		fmt.Fprintf(b, "\n[themes.%s]\n", bareKey(name))
+		if base, ok := colors[theme.Base]; ok {
+			fmt.Fprintf(b, "%s = %s\n", theme.Base, strconv.Quote(base))
+		}
+		for _, role := range theme.Roles {
+			if color, ok := colors[role.Name]; ok {
+				fmt.Fprintf(b, "%s = %s\n", role.Name, strconv.Quote(color))
+			}
+		}
+	}
+}
+
+// bareKey returns key as TOML needs it, quoted unless it's a bare key
+func bareKey(key string) string {
+	if key != "" && strings.Trim(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") == "" {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// keyList reads a key or a list of keys
+func keyList(v any) ([]string, error) {
+	items, ok := v.([]any)
+	if !ok {
+		items = []any{v}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("needs at least one key")
+	}
+	keys := make([]string, len(items))
+	for i, item := range items {
+		if err := setString(&keys[i], item, false); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func setString(dst *string, v any, empty bool) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("expected a string, got %v", v)
+	}
+	if !empty && strings.TrimSpace(s) == "" {
+		return errors.New("can't be empty")
+	}
+	*dst = s
+	return nil
+}
+
+func setBool(dst *bool, v any) error {
+	b, ok := v.(bool)
+	if !ok {
+		return fmt.Errorf("expected true or false, got %v", v)
+	}
+	*dst = b
+	return nil
+}
+
+func setDuration(dst *time.Duration, v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("expected a duration such as \"30s\", got %v", v)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	if d <= 0 {
+		return fmt.Errorf("must be positive, got %s", s)
+	}
+	*dst = d
+	return nil
+}
+
+func setAddress(dst *string, v any) error {
+	var s string
+	if err := setString(&s, v, false); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		return fmt.Errorf("expected host:port such as \"127.0.0.1:8765\", got %q", s)
+	}
+	*dst = s
+	return nil
+}
+
+func setPattern(dst *string, v any) error {
+	var s string
+	if err := setString(&s, v, false); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return errors.New("pattern needs a group around the ticket ID")
+	}
+	*dst = s
+	return nil
+}
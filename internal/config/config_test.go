@@ -0,0 +1,287 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTOML(t *testing.T) {
+	data := `# gq config
+[defaults]
+author = "alice" # trailing comment
+worktree_dir = '/tmp/wt'
+
+[hosts]
+"git.corp.example" = "gitlab"
+
+[tracker]
+pattern = "#(\\w+-\\d+)"
+labels = ["a", "b # not a comment", 3, true]
+
+[keys.dashboard]
+refresh = [
+  "r",      # the default
+  "ctrl+r", # and one more
+]
+`
+	entries, errs := parseTOML("config.toml", data)
+	if errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := []entry{
+		{"defaults.author", "alice", 3},
+		{"defaults.worktree_dir", "/tmp/wt", 4},
+		{"hosts.git.corp.example", "gitlab", 7},
+		{"tracker.pattern", `#(\w+-\d+)`, 10},
+		{"tracker.labels", []any{"a", "b # not a comment", int64(3), true}, 11},
+		{"keys.dashboard.refresh", []any{"r", "ctrl+r"}, 14},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("got %+v\nwant %+v", entries, want)
+	}
+
+	_, errs = parseTOML("config.toml", "[defaults\nauthor = alice\nnope\n")
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), errs)
+	}
+	if got := errs[1].Error(); got != "config.toml:2: author: invalid value alice, strings need quotes" {
+		t.Errorf("error = %q", got)
+	}
+
+	_, errs = parseTOML("config.toml", "[keys.dashboard]\nrefresh = [\"r\",\n")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "config.toml:2: refresh: unterminated array") {
+		t.Errorf("errors = %v, want the unterminated array reported", errs)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := `# gq config
+defaults:
+  author: alice   # trailing comment
+  worktree_dir: "/tmp/wt"
+hosts:
+  "git.corp.example": gitlab
+ui:
+  wrap: true
+tracker:
+  url: https://jira.example.com
+  labels:
+    - a
+    - 'b'
+  ids: [1, 2]
+`
+	entries, errs := parseYAML("config.yaml", data)
+	if errs != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := []entry{
+		{"defaults.author", "alice", 3},
+		{"defaults.worktree_dir", "/tmp/wt", 4},
+		{"hosts.git.corp.example", "gitlab", 6},
+		{"ui.wrap", true, 8},
+		{"tracker.url", "https://jira.example.com", 10},
+		{"tracker.labels", []any{"a", "b"}, 12},
+		{"tracker.ids", []any{int64(1), int64(2)}, 14},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("got %+v\nwant %+v", entries, want)
+	}
+}
+
+// writeFile writes data to path, creating its directory
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	xdg := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GQ_WORKTREE_DIR", "")
+	t.Setenv("JIRA_URL", "")
+	t.Setenv("GQ_WEBHOOK_SECRET", "")
+
+	c, err := Load(repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c, Default()) {
+		t.Errorf("without files got %+v, want the defaults", c)
+	}
+
+	writeFile(t, filepath.Join(xdg, "gq", "config.toml"), `
+[defaults]
+author = "alice"
+[timeouts]
+command = "1m"
+[tracker]
+url = "https://jira.example.com"
+[hosts]
+"git.corp.example" = "gitlab"
+[cache]
+mrs = "30s"
+[poll]
+enabled = true
+interval = "45s"
+[keys.dashboard]
+refresh = ["ctrl+r", "F5"]
+`)
+	writeFile(t, filepath.Join(repo, ".gq.yaml"), `
+defaults:
+  author: bob
+ui:
+  side_by_side: true
+  theme: paper
+keys:
+  detail:
+    diff: x
+themes:
+  paper:
+    base: light
+    accent: "#268bd2"
+cache:
+  enabled: false
+`)
+	t.Setenv("JIRA_URL", "https://tickets.example.com/issue/{ticket}")
+
+	c, err = Load(repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Default()
+	want.Defaults.Author = "bob" // the repo overrides the user
+	want.Timeouts.Command = time.Minute
+	want.Tracker.URL = "https://tickets.example.com/issue/{ticket}" // the variable overrides both
+	want.Hosts = map[string]string{"git.corp.example": "gitlab"}
+	want.UI.SideBySide = true
+	want.UI.Theme = "paper"
+	want.Cache.Enabled = false
+	want.Cache.MRs = 30 * time.Second
+	want.Poll.Enabled = true
+	want.Poll.Interval = 45 * time.Second
+	want.Themes = map[string]map[string]string{"paper": {"base": "light", "accent": "#268bd2"}}
+	want.Keys = map[string][]string{"dashboard.refresh": {"ctrl+r", "F5"}, "detail.diff": {"x"}}
+	want.Sources = []string{"defaults", filepath.Join(xdg, "gq", "config.toml"), filepath.Join(repo, ".gq.yaml"), "$JIRA_URL"}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v\nwant %+v", c, want)
+	}
+	if got := c.Tracker.TicketURL("ABC-1"); got != "https://tickets.example.com/issue/ABC-1" {
+		t.Errorf("TicketURL = %q", got)
+	}
+
+	// What gq config prints loads back as the same configuration
+	formatted := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, formatted, c.Format())
+	again := Default()
+	if errs := again.apply(formatted, c.Format(), false); errs != nil {
+		t.Fatalf("formatted config doesn't load: %v\n%s", errs, c.Format())
+	}
+	again.Sources = c.Sources
+	if !reflect.DeepEqual(again, c) {
+		t.Errorf("round trip got %+v\nwant %+v", again, c)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GQ_WEBHOOK_SECRET", "")
+	path := filepath.Join(xdg, "gq", "config.toml")
+	writeFile(t, path, `[defaults]
+author = ""
+colour = "blue"
+[timeouts]
+command = "soon"
+network = 5
+[hosts]
+"git.corp.example" = "bitbucket"
+[tracker]
+pattern = "[A-Z]+-\\d+"
+[ui]
+wrap = "yes"
+theme = "sepia"
+[keys.dashboard]
+open = "r"
+find = []
+[keys.detail]
+zoom = "z"
+[themes.light]
+accent = "1"
+[themes.mine]
+accent = "red"
+base = "solarized"
+glow = "1"
+[webhook]
+enabled = true
+listen = "8765"
+`)
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		path + ":2: defaults.author: can't be empty",
+		path + ":3: unknown setting defaults.colour",
+		path + `:5: timeouts.command: invalid duration "soon"`,
+		path + ":6: timeouts.network: expected a duration",
+		path + `:8: hosts."git.corp.example": platform must be github or gitlab`,
+		path + ":10: tracker.pattern: pattern needs a group around the ticket ID",
+		path + ":12: ui.wrap: expected true or false",
+		path + ":16: keys.dashboard.find: needs at least one key",
+		path + ":18: unknown key action detail.zoom",
+		`keys.dashboard: "r" is bound to both open and refresh`,
+		path + ":20: themes.light: light is a built-in theme, pick another name",
+		path + `:22: themes.mine.accent: invalid color "red"`,
+		path + ":23: themes.mine.base: base must be one of auto, dark, high-contrast, light",
+		path + ":24: unknown theme color glow",
+		`ui.theme: unknown theme "sepia"`,
+		path + `:27: webhook.listen: expected host:port such as "127.0.0.1:8765", got "8765"`,
+		"webhook.secret: required with webhook.enabled",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("errors don't include %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_RepoWebhook(t *testing.T) {
+	xdg := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GQ_WEBHOOK_SECRET", "")
+	path := filepath.Join(repo, ".gq.toml")
+	writeFile(t, path, `[webhook]
+enabled = true
+listen = "0.0.0.0:8765"
+secret = "public"
+`)
+
+	// A cloned repo can't start a listener
+	c, err := Load(repo)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		path + ":2: webhook.enabled: can only be set in the user config file",
+		path + ":3: webhook.listen: can only be set in the user config file",
+		path + ":4: webhook.secret: can only be set in the user config file",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("errors don't include %q:\n%v", want, err)
+		}
+	}
+	if c.Webhook != Default().Webhook {
+		t.Errorf("repo file changed the webhook settings: %+v", c.Webhook)
+	}
+}
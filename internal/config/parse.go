@@ -0,0 +1,299 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// entry is one setting read from a file, under its dotted key, e.g.
+// "timeouts.command"
+type entry struct {
+	key   string
+	value any // string, int64, bool or []any of those
+	line  int
+}
+
+// parseTOML reads the subset of TOML gq's config uses: [tables], key = value
+// pairs with strings, integers, booleans and arrays of those, which may span
+// lines, and comments
+func parseTOML(file, data string) ([]entry, []error) {
+	var entries []entry
+	var errs []error
+	table := ""
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				errs = append(errs, &Error{File: file, Line: n, Msg: "invalid table header " + line})
+				continue
+			}
+			name, err := parseKey(strings.TrimSpace(line[1 : len(line)-1]))
+			if err != nil {
+				errs = append(errs, &Error{File: file, Line: n, Msg: err.Error()})
+				continue
+			}
+			table = name + "."
+			continue
+		}
+
+		rawKey, rawValue, ok := cutOutsideQuotes(line, '=')
+		if !ok {
+			errs = append(errs, &Error{File: file, Line: n, Msg: "expected key = value"})
+			continue
+		}
+		key, err := parseKey(strings.TrimSpace(rawKey))
+		if err != nil {
+			errs = append(errs, &Error{File: file, Line: n, Msg: err.Error()})
+			continue
+		}
+		rawValue = strings.TrimSpace(rawValue)
+		// An array goes on until its brackets close
+		for openBrackets(rawValue) > 0 && i+1 < len(lines) {
+			i++
+			rawValue += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		value, err := parseValue(rawValue, false)
+		if err != nil {
+			errs = append(errs, &Error{File: file, Line: n, Msg: key + ": " + err.Error()})
+			continue
+		}
+		entries = append(entries, entry{key: table + key, value: value, line: n})
+	}
+	return entries, errs
+}
+
+// parseYAML reads the subset of YAML gq's config uses: nested mappings by
+// indentation, scalars, flow [a, b] lists, "- item" lists and comments
+func parseYAML(file, data string) ([]entry, []error) {
+	type level struct {
+		indent int
+		prefix string
+	}
+	var entries []entry
+	var errs []error
+	stack := []level{{indent: -1}}
+	lists := make(map[string]int) // key of a "- item" list -> its index in entries
+
+	for i, raw := range strings.Split(data, "\n") {
+		n := i + 1
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			errs = append(errs, &Error{File: file, Line: n, Msg: "indent with spaces, not tabs"})
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok || trimmed == "-" {
+			// A list item belongs to the innermost key opened above it
+			for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+				stack = stack[:len(stack)-1]
+			}
+			key := strings.TrimSuffix(stack[len(stack)-1].prefix, ".")
+			if key == "" {
+				errs = append(errs, &Error{File: file, Line: n, Msg: "list item outside a key"})
+				continue
+			}
+			value, err := parseValue(strings.TrimSpace(item), true)
+			if err != nil {
+				errs = append(errs, &Error{File: file, Line: n, Msg: key + ": " + err.Error()})
+				continue
+			}
+			idx, ok := lists[key]
+			if !ok {
+				idx = len(entries)
+				lists[key] = idx
+				entries = append(entries, entry{key: key, value: []any{}, line: n})
+			}
+			entries[idx].value = append(entries[idx].value.([]any), value)
+			continue
+		}
+
+		for indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		rawKey, rawValue, ok := cutOutsideQuotes(trimmed, ':')
+		if !ok {
+			errs = append(errs, &Error{File: file, Line: n, Msg: "expected key: value"})
+			continue
+		}
+		key, err := parseKey(strings.TrimSpace(rawKey))
+		if err != nil {
+			errs = append(errs, &Error{File: file, Line: n, Msg: err.Error()})
+			continue
+		}
+		key = stack[len(stack)-1].prefix + key
+		rawValue = strings.TrimSpace(rawValue)
+		if rawValue == "" {
+			// Opens a nested mapping or a list
+			stack = append(stack, level{indent: indent, prefix: key + "."})
+			continue
+		}
+		value, err := parseValue(rawValue, true)
+		if err != nil {
+			errs = append(errs, &Error{File: file, Line: n, Msg: key + ": " + err.Error()})
+			continue
+		}
+		entries = append(entries, entry{key: key, value: value, line: n})
+	}
+	return entries, errs
+}
+
+// parseKey reads a bare or quoted key, or a dotted key of those
+func parseKey(s string) (string, error) {
+	var parts []string
+	for s != "" {
+		var part string
+		switch s[0] {
+		case '"', '\'':
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return "", fmt.Errorf("unterminated key %s", s)
+			}
+			part, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			part, s = strings.TrimSpace(s[:end]), s[end:]
+			if part == "" || strings.ContainsAny(part, " \t\"'") {
+				return "", fmt.Errorf("invalid key %q", part)
+			}
+		}
+		parts = append(parts, part)
+		s = strings.TrimSpace(s)
+		if s != "" {
+			if s[0] != '.' {
+				return "", fmt.Errorf("invalid key near %s", s)
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("missing key")
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// parseValue reads a quoted string, boolean, integer or array.
+// YAML also allows bare strings.
+func parseValue(s string, yaml bool) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unterminated array %s", s)
+		}
+		items := []any{}
+		rest := strings.TrimSpace(s[1 : len(s)-1])
+		for rest != "" {
+			item, tail, _ := cutOutsideQuotes(rest, ',')
+			v, err := parseValue(strings.TrimSpace(item), yaml)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			rest = strings.TrimSpace(tail)
+		}
+		return items, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if yaml {
+		return s, nil
+	}
+	return nil, fmt.Errorf("invalid value %s, strings need quotes", s)
+}
+
+// stripComment drops a # comment that isn't inside quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// openBrackets returns how many of s's brackets outside quotes are unclosed
+func openBrackets(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// cutOutsideQuotes cuts s around the first sep that isn't inside quotes
+func cutOutsideQuotes(s string, sep byte) (before, after string, found bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
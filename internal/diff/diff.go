@@ -0,0 +1,249 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LineKind identifies what a diff line does
+type LineKind int
+
+const (
+	Context LineKind = iota
+	Added
+	Removed
+)
+
+// Line is a single line of a hunk
+type Line struct {
+	Kind   LineKind
+	Text   string // line content without the leading +, - or space
+	OldNum int    // line number in the old file, 0 for added lines
+	NewNum int    // line number in the new file, 0 for removed lines
+}
+
+// Hunk is a contiguous block of changes
+type Hunk struct {
+	Header   string // raw "@@ -a,b +c,d @@ section" line
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Section  string // function/section context after the second @@
+	Lines    []Line
+}
+
+// File is the diff of a single file
+type File struct {
+	OldPath   string
+	NewPath   string
+	IsNew     bool
+	IsDeleted bool
+	Binary    bool
+	Hunks     []Hunk
+}
+
+// Path returns the path the file is known by after the change
+func (f File) Path() string {
+	if f.IsDeleted || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// Stats returns the number of added and removed lines
+func (f File) Stats() (additions, deletions int) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case Added:
+				additions++
+			case Removed:
+				deletions++
+			}
+		}
+	}
+	return additions, deletions
+}
+
+// Parse parses a git-style unified diff (as produced by git diff, gh pr diff
+// or glab mr diff) into per-file hunks
+func Parse(patch string) []File {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	lines := strings.Split(patch, "\n")
+
+	var files []File
+	var file *File
+	var hunk *Hunk
+	oldNum, newNum := 0, 0
+	remOld, remNew := 0, 0 // lines left to read in the current hunk
+
+	flushHunk := func() {
+		if file != nil && hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if file != nil {
+			files = append(files, *file)
+		}
+		file = nil
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			file = &File{}
+			file.OldPath, file.NewPath = parseGitHeaderPaths(strings.TrimPrefix(line, "diff --git "))
+
+		case file == nil:
+			// Preamble before the first file (e.g. commit headers from --patch)
+			continue
+
+		case hunk != nil && (remOld > 0 || remNew > 0) && hunkLine(line):
+			switch {
+			case strings.HasPrefix(line, "+"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: Added, Text: line[1:], NewNum: newNum})
+				newNum++
+				remNew--
+			case strings.HasPrefix(line, "-"):
+				hunk.Lines = append(hunk.Lines, Line{Kind: Removed, Text: line[1:], OldNum: oldNum})
+				oldNum++
+				remOld--
+			default:
+				hunk.Lines = append(hunk.Lines, Line{Kind: Context, Text: strings.TrimPrefix(line, " "), OldNum: oldNum, NewNum: newNum})
+				oldNum++
+				newNum++
+				remOld--
+				remNew--
+			}
+
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			h, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			hunk = &h
+			oldNum, newNum = h.OldStart, h.NewStart
+			remOld, remNew = h.OldCount, h.NewCount
+
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+			continue
+
+		case strings.HasPrefix(line, "--- "):
+			if p := stripPrefix(strings.TrimPrefix(line, "--- ")); p != "" {
+				file.OldPath = p
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if p := stripPrefix(strings.TrimPrefix(line, "+++ ")); p != "" {
+				file.NewPath = p
+			}
+		case strings.HasPrefix(line, "new file mode"):
+			file.IsNew = true
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.IsDeleted = true
+		case strings.HasPrefix(line, "rename from "):
+			file.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			file.Binary = true
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+// FindFile returns the index of the file with the given path, or -1
+func FindFile(files []File, path string) int {
+	for i, f := range files {
+		if f.Path() == path || f.OldPath == path || f.NewPath == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// hunkLine reports whether line can be a body line of a hunk. Empty lines
+// count as context because some tools strip the trailing space.
+func hunkLine(line string) bool {
+	if line == "" {
+		return true
+	}
+	switch line[0] {
+	case '+', '-', ' ':
+		return true
+	}
+	return false
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ section"
+func parseHunkHeader(line string) (Hunk, bool) {
+	rest := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return Hunk{}, false
+	}
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 {
+		return Hunk{}, false
+	}
+	oldStart, oldCount, ok1 := parseRange(strings.TrimPrefix(ranges[0], "-"))
+	newStart, newCount, ok2 := parseRange(strings.TrimPrefix(ranges[1], "+"))
+	if !ok1 || !ok2 {
+		return Hunk{}, false
+	}
+	return Hunk{
+		Header:   line,
+		OldStart: oldStart,
+		OldCount: oldCount,
+		NewStart: newStart,
+		NewCount: newCount,
+		Section:  strings.TrimSpace(rest[end+3:]),
+	}, true
+}
+
+// parseRange parses "start,count" or "start" (count defaults to 1)
+func parseRange(s string) (start, count int, ok bool) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	count = 1
+	if hasCount {
+		count, err = strconv.Atoi(countStr)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, count, true
+}
+
+// parseGitHeaderPaths extracts paths from "a/old b/new"
+func parseGitHeaderPaths(s string) (string, string) {
+	// Paths with spaces are ambiguous here, the ---/+++ lines refine them later
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+// stripPrefix removes the a/ or b/ prefix from a ---/+++ path,
+// returning "" for /dev/null
+func stripPrefix(p string) string {
+	p = strings.TrimSuffix(p, "\t")
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		return p[2:]
+	}
+	return p
+}
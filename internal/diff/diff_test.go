@@ -0,0 +1,189 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+const samplePatch = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,5 @@ package main
+ import "fmt"
+-func old() {}
++func new() {}
++-- a line starting with dashes
+
+ func main() {}
+@@ -10 +11 @@ func main() {}
+-x
++y
+\ No newline at end of file
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 3333333..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/old_name.go b/new_name.go
+similarity index 90%
+rename from old_name.go
+rename to new_name.go
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(samplePatch)
+	if len(files) != 4 {
+		t.Fatalf("got %d files, want 4", len(files))
+	}
+
+	main := files[0]
+	if main.Path() != "main.go" {
+		t.Errorf("Path: got %q, want main.go", main.Path())
+	}
+	if len(main.Hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(main.Hunks))
+	}
+
+	first := main.Hunks[0]
+	if first.OldStart != 1 || first.OldCount != 4 || first.NewStart != 1 || first.NewCount != 5 {
+		t.Errorf("hunk ranges: got -%d,%d +%d,%d", first.OldStart, first.OldCount, first.NewStart, first.NewCount)
+	}
+	if first.Section != "package main" {
+		t.Errorf("Section: got %q, want %q", first.Section, "package main")
+	}
+
+	expected := []Line{
+		{Kind: Context, Text: `import "fmt"`, OldNum: 1, NewNum: 1},
+		{Kind: Removed, Text: "func old() {}", OldNum: 2},
+		{Kind: Added, Text: "func new() {}", NewNum: 2},
+		{Kind: Added, Text: "-- a line starting with dashes", NewNum: 3},
+		{Kind: Context, Text: "", OldNum: 3, NewNum: 4},
+		{Kind: Context, Text: "func main() {}", OldNum: 4, NewNum: 5},
+	}
+	if !reflect.DeepEqual(first.Lines, expected) {
+		t.Errorf("lines:\n got %+v\nwant %+v", first.Lines, expected)
+	}
+
+	second := main.Hunks[1]
+	if second.OldStart != 10 || second.OldCount != 1 || second.NewStart != 11 || second.NewCount != 1 {
+		t.Errorf("single-line hunk ranges: got -%d,%d +%d,%d", second.OldStart, second.OldCount, second.NewStart, second.NewCount)
+	}
+	if len(second.Lines) != 2 {
+		t.Errorf("got %d lines in second hunk, want 2", len(second.Lines))
+	}
+
+	adds, dels := main.Stats()
+	if adds != 3 || dels != 2 {
+		t.Errorf("Stats: got +%d -%d, want +3 -2", adds, dels)
+	}
+
+	gone := files[1]
+	if !gone.IsDeleted || gone.Path() != "gone.txt" {
+		t.Errorf("deleted file: got IsDeleted=%v Path=%q", gone.IsDeleted, gone.Path())
+	}
+
+	renamed := files[2]
+	if renamed.OldPath != "old_name.go" || renamed.NewPath != "new_name.go" || len(renamed.Hunks) != 0 {
+		t.Errorf("rename: got %+v", renamed)
+	}
+
+	if !files[3].Binary {
+		t.Error("expected logo.png to be binary")
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	files := Parse(samplePatch)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"main.go", 0},
+		{"gone.txt", 1},
+		{"new_name.go", 2},
+		{"old_name.go", 2},
+		{"missing.go", -1},
+	}
+
+	for _, tt := range tests {
+		if got := FindFile(files, tt.path); got != tt.expected {
+			t.Errorf("FindFile(%q) = %d, want %d", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	if files := Parse(""); len(files) != 0 {
+		t.Errorf("got %d files, want 0", len(files))
+	}
+}
+
+func TestPairLines(t *testing.T) {
+	lines := []Line{
+		{Kind: Context},
+		{Kind: Removed}, // 1
+		{Kind: Removed}, // 2
+		{Kind: Added},   // 3
+		{Kind: Context},
+		{Kind: Removed}, // 5
+		{Kind: Added},   // 6
+		{Kind: Added},   // 7
+	}
+
+	got := PairLines(lines)
+	expected := map[int]int{1: 3, 5: 6}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	oldSpans, newSpans := WordDiff("return foo(a, b)", "return bar(a, c)")
+
+	expectedOld := []Span{{Start: 7, End: 10}, {Start: 14, End: 15}}
+	expectedNew := []Span{{Start: 7, End: 10}, {Start: 14, End: 15}}
+	if !reflect.DeepEqual(oldSpans, expectedOld) {
+		t.Errorf("old spans: got %v, want %v", oldSpans, expectedOld)
+	}
+	if !reflect.DeepEqual(newSpans, expectedNew) {
+		t.Errorf("new spans: got %v, want %v", newSpans, expectedNew)
+	}
+}
+
+func TestWordDiff_NothingInCommon(t *testing.T) {
+	oldSpans, newSpans := WordDiff("alpha", "beta gamma")
+	if oldSpans != nil || newSpans != nil {
+		t.Errorf("expected no spans, got %v %v", oldSpans, newSpans)
+	}
+}
+
+func TestSplitRows(t *testing.T) {
+	lines := []Line{
+		{Kind: Context}, // 0
+		{Kind: Removed}, // 1
+		{Kind: Removed}, // 2
+		{Kind: Added},   // 3
+		{Kind: Context}, // 4
+		{Kind: Added},   // 5
+		{Kind: Added},   // 6
+	}
+
+	got := SplitRows(lines)
+	expected := []SplitRow{
+		{Old: 0, New: 0},
+		{Old: 1, New: 3},
+		{Old: 2, New: -1},
+		{Old: 4, New: 4},
+		{Old: -1, New: 5},
+		{Old: -1, New: 6},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,42 @@
+package diff
+
+// SplitRow is one row of a side-by-side diff: indexes into the hunk's lines
+// for the old and new side, -1 where that side has no line
+type SplitRow struct {
+	Old int
+	New int
+}
+
+// SplitRows aligns hunk lines for a side-by-side view. Context lines appear
+// on both sides, removed lines are paired with the added lines that follow
+// them, and whichever side runs out first is padded.
+func SplitRows(lines []Line) []SplitRow {
+	var rows []SplitRow
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Context {
+			rows = append(rows, SplitRow{Old: i, New: i})
+			i++
+			continue
+		}
+		var dels, adds []int
+		for i < len(lines) && lines[i].Kind == Removed {
+			dels = append(dels, i)
+			i++
+		}
+		for i < len(lines) && lines[i].Kind == Added {
+			adds = append(adds, i)
+			i++
+		}
+		for k := 0; k < len(dels) || k < len(adds); k++ {
+			row := SplitRow{Old: -1, New: -1}
+			if k < len(dels) {
+				row.Old = dels[k]
+			}
+			if k < len(adds) {
+				row.New = adds[k]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
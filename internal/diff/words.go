@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a byte range [Start, End) within a line
+type Span struct {
+	Start int
+	End   int
+}
+
+// maxWordTokens bounds the LCS table for very long lines
+const maxWordTokens = 300
+
+// PairLines pairs removed lines with the added lines that replace them.
+// Within each run of removals directly followed by a run of additions the
+// i-th removed line is paired with the i-th added line. The result maps the
+// index of a removed line in lines to the index of its added counterpart.
+func PairLines(lines []Line) map[int]int {
+	pairs := make(map[int]int)
+	for i := 0; i < len(lines); {
+		if lines[i].Kind != Removed {
+			i++
+			continue
+		}
+		delStart := i
+		for i < len(lines) && lines[i].Kind == Removed {
+			i++
+		}
+		addStart := i
+		for i < len(lines) && lines[i].Kind == Added {
+			i++
+		}
+		for k := 0; delStart+k < addStart && addStart+k < i; k++ {
+			pairs[delStart+k] = addStart + k
+		}
+	}
+	return pairs
+}
+
+// WordDiff returns the changed spans of old and new at word granularity.
+// When the lines have (almost) nothing in common no spans are returned,
+// since emphasising the whole line adds nothing over the line coloring.
+func WordDiff(old, new string) (oldSpans, newSpans []Span) {
+	a := splitWords(old)
+	b := splitWords(new)
+	if len(a) == 0 || len(b) == 0 || len(a) > maxWordTokens || len(b) > maxWordTokens {
+		return nil, nil
+	}
+
+	// Longest common subsequence over word tokens
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	common := 0
+	keepA := make([]bool, len(a))
+	keepB := make([]bool, len(b))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].text == b[j].text:
+			keepA[i], keepB[j] = true, true
+			if !isSpace(a[i].text) {
+				common++
+			}
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	if common == 0 {
+		return nil, nil
+	}
+
+	return changedSpans(a, keepA), changedSpans(b, keepB)
+}
+
+type wordToken struct {
+	text  string
+	start int
+}
+
+// splitWords splits s into identifier-like words, whitespace runs and
+// single punctuation characters
+func splitWords(s string) []wordToken {
+	var tokens []wordToken
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		start := i
+		switch {
+		case isWordRune(r):
+			for i < len(s) {
+				r, size = utf8.DecodeRuneInString(s[i:])
+				if !isWordRune(r) {
+					break
+				}
+				i += size
+			}
+		case unicode.IsSpace(r):
+			for i < len(s) {
+				r, size = utf8.DecodeRuneInString(s[i:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				i += size
+			}
+		default:
+			i += size
+		}
+		tokens = append(tokens, wordToken{text: s[start:i], start: start})
+	}
+	return tokens
+}
+
+// changedSpans merges consecutive tokens that are not part of the LCS
+func changedSpans(tokens []wordToken, keep []bool) []Span {
+	var spans []Span
+	for i, t := range tokens {
+		if keep[i] {
+			continue
+		}
+		end := t.start + len(t.text)
+		if n := len(spans); n > 0 && spans[n-1].End == t.start {
+			spans[n-1].End = end
+			continue
+		}
+		spans = append(spans, Span{Start: t.start, End: end})
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// CheckoutOptions tunes how a checkout fetches and updates the branch
+type CheckoutOptions struct {
+	Strategy   PullStrategy
+	BaseBranch string // fetched along with the target, e.g. the default branch; empty to skip
+}
+
+// fetchFilterKey is the git config key holding an optional partial-clone
+// filter for checkout fetches, e.g. blob:none
+const fetchFilterKey = "gq.fetchFilter"
+
+// trackingRefspec returns the refspec that fetches branch on origin into
+// its remote-tracking ref
+func trackingRefspec(branch string) string {
+	return "+refs/heads/" + branch + ":refs/remotes/origin/" + branch
+}
+
+// branchUpstream returns the remote and the branch on it that a local branch
+// tracks. A branch that doesn't exist yet will be created from origin.
+func branchUpstream(ctx context.Context, path, branch string) (remote, remoteBranch string, ok bool) {
+	if revParse(ctx, path, "refs/heads/"+branch) == "" {
+		return "origin", branch, true
+	}
+	remoteOut, err := cmd.Run(ctx, path, "git", "config", "--get", "branch."+branch+".remote")
+	if err != nil {
+		return "", "", false
+	}
+	mergeOut, err := cmd.Run(ctx, path, "git", "config", "--get", "branch."+branch+".merge")
+	if err != nil {
+		return "", "", false
+	}
+	merge := strings.TrimSpace(string(mergeOut))
+	if !strings.HasPrefix(merge, "refs/heads/") {
+		return "", "", false
+	}
+	return strings.TrimSpace(string(remoteOut)), strings.TrimPrefix(merge, "refs/heads/"), true
+}
+
+// fetchRefs fetches only the given refspecs from remote, as the "fetch" step.
+// The filter in gq.fetchFilter is applied if set; a partial clone's own filter
+// applies anyway and is mentioned in the label. The done label reports how
+// long the fetch took.
+func fetchRefs(ctx context.Context, path string, s stepper, remote, what string, refspecs ...string) error {
+	args := []string{"fetch", "--progress"}
+	label := "Fetching " + what
+
+	filter := configValue(ctx, path, fetchFilterKey)
+	if filter != "" {
+		args = append(args, "--filter="+filter)
+	} else {
+		filter = configValue(ctx, path, "remote."+remote+".partialclonefilter")
+	}
+	if filter != "" {
+		label += " (" + filter + ")"
+	}
+	args = append(args, remote)
+	args = append(args, refspecs...)
+
+	s.start("fetch", label)
+	start := time.Now()
+	if err := s.runWithProgress(ctx, path, "fetch", label, args...); err != nil {
+		return s.fail("fetch", err)
+	}
+	s.done("fetch", fmt.Sprintf("Fetched %s in %s", what, formatElapsed(time.Since(start))))
+	return nil
+}
+
+// configValue returns a git config value, or "" if it isn't set
+func configValue(ctx context.Context, path, key string) string {
+	out, err := cmd.Run(ctx, path, "git", "config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// formatElapsed formats a duration for progress labels, e.g. "850ms" or "2.4s"
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
@@ -1,26 +1,22 @@
 package git
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
 )
 
-// IsGitRepo checks if the given path is inside a git repository
+// IsGitRepo checks if the given path is inside a git repository's work tree
 func IsGitRepo(path string) bool {
-	gitPath := filepath.Join(path, ".git")
-	info, err := os.Stat(gitPath)
-	if err != nil {
-		return false
-	}
-	return info.IsDir()
+	_, err := RepoRoot(context.Background(), path)
+	return err == nil
 }
 
 // GetRemoteURL returns the origin remote URL for the repo at path
-func GetRemoteURL(path string) (string, error) {
-	out, err := cmd.Run(path, "git", "remote", "get-url", "origin")
+func GetRemoteURL(ctx context.Context, path string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "remote", "get-url", "origin")
 	if err != nil {
 		return "", err
 	}
@@ -28,8 +24,8 @@ func GetRemoteURL(path string) (string, error) {
 }
 
 // GetCurrentBranch returns the current branch name
-func GetCurrentBranch(path string) (string, error) {
-	out, err := cmd.Run(path, "git", "rev-parse", "--abbrev-ref", "HEAD")
+func GetCurrentBranch(ctx context.Context, path string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", err
 	}
@@ -51,8 +47,8 @@ func (e *CheckoutError) Unwrap() error {
 }
 
 // IsDirty checks if the working tree has uncommitted changes
-func IsDirty(path string) (bool, error) {
-	out, err := cmd.Run(path, "git", "status", "--porcelain")
+func IsDirty(ctx context.Context, path string) (bool, error) {
+	out, err := cmd.Run(ctx, path, "git", "status", "--porcelain")
 	if err != nil {
 		return false, err
 	}
@@ -60,21 +56,232 @@ func IsDirty(path string) (bool, error) {
 }
 
 // Checkout fetches, checks out the branch, and pulls
-func Checkout(path, branch string) error {
+func Checkout(ctx context.Context, path, branch string) error {
+	_, err := CheckoutWithProgress(ctx, path, branch, CheckoutOptions{Strategy: PullMerge}, nil)
+	return err
+}
+
+// CheckoutWithProgress fetches the branch, checks it out, and updates it from
+// its upstream as opts.Strategy says, reporting each step (and git's own
+// fetch progress) to report if it isn't nil
+func CheckoutWithProgress(ctx context.Context, path, branch string, opts CheckoutOptions, report func(Progress)) (CheckoutResult, error) {
+	s := stepper{report: report}
+	result := CheckoutResult{Branch: branch, PreviousHead: shortHead(ctx, path)}
+
+	// Remember where the upstream was to spot force-pushes
+	remote, remoteBranch, tracked := branchUpstream(ctx, path, branch)
+	upstreamRef := "refs/remotes/" + remote + "/" + remoteBranch
+	var remoteBefore string
+	if tracked {
+		remoteBefore = revParse(ctx, path, upstreamRef)
+	}
+
+	// Fetch only the upstream branch, and the base branch alongside it
+	fetchRemote := "origin"
+	var refspecs, names []string
+	if tracked {
+		fetchRemote = remote
+		refspecs = append(refspecs, "+refs/heads/"+remoteBranch+":"+upstreamRef)
+		names = append(names, remote+"/"+remoteBranch)
+	}
+	if base := opts.BaseBranch; base != "" && fetchRemote == "origin" && !(tracked && remoteBranch == base) {
+		refspecs = append(refspecs, trackingRefspec(base))
+		names = append(names, "origin/"+base)
+	}
+	if len(refspecs) > 0 {
+		if err := fetchRefs(ctx, path, s, fetchRemote, strings.Join(names, " and "), refspecs...); err != nil {
+			return result, err
+		}
+	}
+
+	// Checkout
+	s.start("checkout", "Checking out "+branch)
+	if err := cmd.RunSimple(ctx, path, "git", "checkout", branch); err != nil {
+		return result, s.fail("checkout", err)
+	}
+	s.done("checkout", "Checked out "+branch)
+
+	// Update
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--abbrev-ref", "@{upstream}")
+	if err != nil {
+		// A local-only branch has nothing to pull
+		result.Head = shortHead(ctx, path)
+		s.done("pull", "No upstream to pull from")
+		return result, nil
+	}
+	upstreamName := strings.TrimSpace(string(out))
+	remoteAfter := revParse(ctx, path, "@{upstream}")
+	noteRemote(ctx, path, &result, remoteBefore, remoteAfter)
+	if err := updateBranch(ctx, path, s, opts.Strategy, remoteAfter, upstreamName, &result); err != nil {
+		return result, err
+	}
+	result.Head = shortHead(ctx, path)
+	return result, nil
+}
+
+// MRHead identifies the head of an MR that is fetched through its
+// pull/merge-request ref rather than its branch
+type MRHead struct {
+	Number int
+	Ref    string // ref on origin pointing at the MR head, e.g. refs/pull/42/head
+	Branch string // head branch name in the source repo
+	Owner  string // fork owner, used to namespace the local branch
+}
+
+// CheckoutMRHead fetches an MR head into a local branch and checks it out.
+// This works for MRs from forks, whose branches don't exist on origin. The
+// branch tracks the MR ref so later pulls pick up new commits. Steps are
+// reported to report if it isn't nil. A merge strategy fast-forwards only,
+// merging into a branch that mirrors the MR would just make it diverge.
+func CheckoutMRHead(ctx context.Context, path string, head MRHead, opts CheckoutOptions, report func(Progress)) (CheckoutResult, error) {
+	s := stepper{report: report}
+	result := CheckoutResult{PreviousHead: shortHead(ctx, path)}
+
+	name, existing, err := pickLocalBranch(ctx, path, LocalBranchName(head), head.Ref)
+	if err != nil {
+		return result, s.fail("checkout", err)
+	}
+	result.Branch = name
+	strategy := opts.Strategy
+	if strategy == PullMerge {
+		strategy = PullFFOnly
+	}
+	// The branch mirrors the MR head, so its tip is where the MR was last seen
+	var remoteBefore string
+	if existing {
+		remoteBefore = revParse(ctx, path, "refs/heads/"+name)
+	}
+
 	// Fetch
-	if err := cmd.RunSimple(path, "git", "fetch", "origin"); err != nil {
-		return &CheckoutError{Step: "fetch", Err: err}
+	fetched, err := fetchMRHead(ctx, path, s, head, opts.BaseBranch, &result)
+	if err != nil {
+		return result, err
+	}
+
+	if existing {
+		// A branch from an earlier checkout of this MR: update it
+		s.start("checkout", "Checking out "+name)
+		if err := cmd.RunSimple(ctx, path, "git", "checkout", name); err != nil {
+			return result, s.fail("checkout", err)
+		}
+		s.done("checkout", "Checked out "+name)
+
+		noteRemote(ctx, path, &result, remoteBefore, fetched)
+		if err := updateBranch(ctx, path, s, strategy, fetched, head.Ref, &result); err != nil {
+			return result, err
+		}
+		result.Head = shortHead(ctx, path)
+		return result, nil
 	}
 
 	// Checkout
-	if err := cmd.RunSimple(path, "git", "checkout", branch); err != nil {
-		return &CheckoutError{Step: "checkout", Err: err}
+	s.start("checkout", "Creating "+name)
+	if err := cmd.RunSimple(ctx, path, "git", "checkout", "-b", name, fetched); err != nil {
+		return result, s.fail("checkout", err)
 	}
+	s.done("checkout", "Created "+name)
 
-	// Pull
-	if err := cmd.RunSimple(path, "git", "pull"); err != nil {
-		return &CheckoutError{Step: "pull", Err: err}
+	// Track the MR ref so "git pull" works on the new branch
+	s.start("track", "Setting upstream to "+head.Ref)
+	if err := cmd.RunSimple(ctx, path, "git", "config", "branch."+name+".remote", "origin"); err != nil {
+		return result, s.fail("track", err)
+	}
+	if err := cmd.RunSimple(ctx, path, "git", "config", "branch."+name+".merge", head.Ref); err != nil {
+		return result, s.fail("track", err)
 	}
+	s.done("track", "Tracking "+head.Ref)
+
+	result.Head = shortHead(ctx, path)
+	return result, nil
+}
+
+// fetchMRHead fetches an MR ref, and the base branch alongside it, and
+// returns the SHA of the MR head. A head prefetched in the background makes
+// the fetch nearly free, and stands in for it when origin can't be reached.
+func fetchMRHead(ctx context.Context, path string, s stepper, head MRHead, base string, result *CheckoutResult) (string, error) {
+	prefetched := revParse(ctx, path, PrefetchRef(head.Number))
+	refspecs, what := []string{head.Ref}, head.Ref
+	if base != "" {
+		refspecs = append(refspecs, trackingRefspec(base))
+		what += " and origin/" + base
+	}
+	// The MR ref comes first so FETCH_HEAD points at it
+	if err := fetchRefs(ctx, path, s, "origin", what, refspecs...); err != nil {
+		if prefetched == "" || ctx.Err() != nil {
+			return "", err
+		}
+		s.done("fetch", "Fetch failed, using the prefetched "+head.Ref)
+		result.Prefetched = true
+		return prefetched, nil
+	}
+	fetched := revParse(ctx, path, "FETCH_HEAD")
+	result.Prefetched = fetched != "" && fetched == prefetched
+	return fetched, nil
+}
 
-	return nil
+// LocalBranchName returns the local branch name for an MR head: the head
+// branch prefixed with the fork owner, made safe for git
+func LocalBranchName(head MRHead) string {
+	name := head.Branch
+	if name == "" {
+		name = fmt.Sprintf("pr-%d", head.Number)
+	}
+	if head.Owner != "" {
+		name = head.Owner + "/" + name
+	}
+	return sanitizeBranchName(name)
+}
+
+// sanitizeBranchName replaces characters git doesn't allow in branch names
+// and removes forbidden sequences
+func sanitizeBranchName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == '/':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+
+	var parts []string
+	for _, part := range strings.Split(b.String(), "/") {
+		for strings.Contains(part, "..") {
+			part = strings.ReplaceAll(part, "..", ".")
+		}
+		part = strings.TrimLeft(part, ".-")
+		part = strings.TrimSuffix(part, ".lock")
+		part = strings.TrimRight(part, ".")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "mr"
+	}
+	return strings.Join(parts, "/")
+}
+
+// maxBranchSuffix bounds the search for a free local branch name
+const maxBranchSuffix = 100
+
+// pickLocalBranch finds the local branch to use for ref: base itself, or
+// base-2, base-3, ... when the name is taken by an unrelated branch. A branch
+// that already tracks ref is reused, reported by existing.
+func pickLocalBranch(ctx context.Context, path, base, ref string) (name string, existing bool, err error) {
+	for i := 1; i <= maxBranchSuffix; i++ {
+		name = base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		if _, err := cmd.Run(ctx, path, "git", "rev-parse", "--verify", "--quiet", "refs/heads/"+name); err != nil {
+			return name, false, nil
+		}
+		merge, _ := cmd.Run(ctx, path, "git", "config", "--get", "branch."+name+".merge")
+		if strings.TrimSpace(string(merge)) == ref {
+			return name, true, nil
+		}
+	}
+	return "", false, fmt.Errorf("no free branch name for %s", base)
 }
@@ -1,8 +1,12 @@
 package git
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,12 +27,21 @@ func TestIsGitRepo(t *testing.T) {
 		t.Error("expected false for non-git directory")
 	}
 
-	// Create .git directory
+	// An empty .git directory isn't a repository
 	gitDir := filepath.Join(tmpDir, ".git")
 	if err := os.Mkdir(gitDir, 0755); err != nil {
 		t.Fatal(err)
 	}
 
+	if IsGitRepo(tmpDir) {
+		t.Error("expected false for an empty .git directory")
+	}
+
+	if err := os.Remove(gitDir); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, tmpDir, "init", "-q")
+
 	if !IsGitRepo(tmpDir) {
 		t.Error("expected true for git directory")
 	}
@@ -48,7 +61,7 @@ func TestGetRemoteURL(t *testing.T) {
 		}
 	}(tmpDir)
 
-	_, err = GetRemoteURL(tmpDir)
+	_, err = GetRemoteURL(context.Background(), tmpDir)
 	if err == nil {
 		t.Error("expected error for non-git directory")
 	}
@@ -66,8 +79,708 @@ func TestCheckout_NotGitRepo(t *testing.T) {
 		}
 	}(tmpDir)
 
-	err = Checkout(tmpDir, "main")
+	err = Checkout(context.Background(), tmpDir, "main")
 	if err == nil {
 		t.Error("expected error for non-git directory")
 	}
 }
+
+func TestLocalBranchName(t *testing.T) {
+	tests := []struct {
+		head     MRHead
+		expected string
+	}{
+		{MRHead{Number: 1, Branch: "fix-typo", Owner: "alice"}, "alice/fix-typo"},
+		{MRHead{Number: 2, Branch: "main", Owner: "bob"}, "bob/main"},
+		{MRHead{Number: 3, Branch: "feature/x"}, "feature/x"},
+		{MRHead{Number: 4, Owner: "carol"}, "carol/pr-4"},
+		{MRHead{Number: 5, Branch: "weird name~^:?*[..lock", Owner: "dave"}, "dave/weird-name------"},
+		{MRHead{Number: 6, Branch: ".hidden/..x.lock", Owner: "-eve"}, "eve/hidden/x"},
+	}
+
+	for _, tt := range tests {
+		if got := LocalBranchName(tt.head); got != tt.expected {
+			t.Errorf("LocalBranchName(%+v) = %q, want %q", tt.head, got, tt.expected)
+		}
+	}
+}
+
+// runGit runs git in dir with a fixed identity, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCheckoutMRHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+
+	// An origin whose MR head only exists as refs/pull/7/head, like a fork's PR
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "initial")
+	runGit(t, origin, "checkout", "-q", "-b", "contrib")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "contribution")
+	runGit(t, origin, "update-ref", "refs/pull/7/head", "contrib")
+	runGit(t, origin, "checkout", "-q", "main")
+	runGit(t, origin, "branch", "-q", "-D", "contrib")
+
+	runGit(t, root, "clone", "-q", origin, clone)
+	// An unrelated local branch already uses the natural name
+	runGit(t, clone, "branch", "alice/fix")
+
+	head := MRHead{Number: 7, Ref: "refs/pull/7/head", Branch: "fix", Owner: "alice"}
+	var steps []string
+	result, err := CheckoutMRHead(context.Background(), clone, head, CheckoutOptions{Strategy: PullMerge}, func(p Progress) {
+		if p.State == StepDone {
+			steps = append(steps, p.Step)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name := result.Branch; name != "alice/fix-2" {
+		t.Errorf("got branch %q, want alice/fix-2", name)
+	}
+	if strings.Join(steps, ",") != "fetch,checkout,track" {
+		t.Errorf("got steps %v, want fetch, checkout, track", steps)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "contribution" {
+		t.Errorf("HEAD is %q, want the MR head commit", got)
+	}
+	if got := runGit(t, clone, "config", "branch.alice/fix-2.merge"); got != head.Ref {
+		t.Errorf("tracking ref is %q, want %q", got, head.Ref)
+	}
+
+	// New commits on the MR are picked up by a second checkout, reusing the branch
+	runGit(t, origin, "checkout", "-q", "refs/pull/7/head")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "follow-up")
+	runGit(t, origin, "update-ref", "refs/pull/7/head", "HEAD")
+	runGit(t, origin, "checkout", "-q", "main")
+	runGit(t, clone, "checkout", "-q", "main")
+
+	result, err = CheckoutMRHead(context.Background(), clone, head, CheckoutOptions{Strategy: PullMerge}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on second checkout: %v", err)
+	}
+	if result.Branch != "alice/fix-2" {
+		t.Errorf("second checkout used %q, want alice/fix-2 again", result.Branch)
+	}
+	if result.Pulled != 1 || result.PreviousHead == "" || result.Head == result.PreviousHead {
+		t.Errorf("unexpected result %+v, want 1 commit pulled and a new HEAD", result)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "follow-up" {
+		t.Errorf("HEAD is %q after update, want follow-up", got)
+	}
+
+	// The tracking config makes a plain pull work
+	runGit(t, clone, "pull", "-q")
+}
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line    string
+		phase   string
+		percent int
+		ok      bool
+	}{
+		{"Receiving objects:  45% (450/1000), 1.20 MiB | 800.00 KiB/s", "Receiving objects", 45, true},
+		{"remote: Counting objects: 100% (12/12), done.", "Counting objects", 100, true},
+		{"Resolving deltas:   3% (1/30)", "Resolving deltas", 3, true},
+		{"From github.com:org/repo", "", 0, false},
+		{" * branch            main       -> FETCH_HEAD", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		phase, percent, ok := parseProgress(tt.line)
+		if phase != tt.phase || percent != tt.percent || ok != tt.ok {
+			t.Errorf("parseProgress(%q) = %q, %d, %v; want %q, %d, %v", tt.line, phase, percent, ok, tt.phase, tt.percent, tt.ok)
+		}
+	}
+}
+
+func TestDescribeState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q", "-b", "main")
+	runGit(t, repo, "commit", "-q", "--allow-empty", "-m", "initial")
+	head := runGit(t, repo, "rev-parse", "--short", "HEAD")
+
+	state, err := DescribeState(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "On main at " + head + ", clean"; state.Summary() != expected {
+		t.Errorf("got %q, want %q", state.Summary(), expected)
+	}
+
+	// What an interrupted git command leaves behind
+	if err := os.WriteFile(filepath.Join(repo, ".git", "index.lock"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "MERGE_HEAD"), []byte(head+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err = DescribeState(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !state.IndexLock || state.Operation != "merge" {
+		t.Errorf("expected a stale lock and a merge in progress, got %+v", state)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	out := " M main.go\x00R  new.go\x00old.go\x00?? notes.txt\x00"
+	files := parseStatus(out)
+	if len(files) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(files), files)
+	}
+	if files[0].Path != "main.go" || files[0].Code() != " M" {
+		t.Errorf("unexpected first entry %+v", files[0])
+	}
+	if files[1].Path != "new.go" || files[1].OrigPath != "old.go" {
+		t.Errorf("rename not parsed: %+v", files[1])
+	}
+	if !files[2].Untracked() || files[2].Path != "notes.txt" {
+		t.Errorf("expected untracked notes.txt, got %+v", files[2])
+	}
+	if OnlyUntracked(files) || !OnlyUntracked(files[2:]) {
+		t.Error("OnlyUntracked misclassified the changes")
+	}
+}
+
+func TestAutoStashRestore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(kv, "test")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(kv, "test@example.com")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "a.txt")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	runGit(t, repo, "branch", "review")
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	branch, err := AutoStash(ctx, repo, false, nil)
+	if err != nil || branch != "main" {
+		t.Fatalf("AutoStash = %q, %v", branch, err)
+	}
+	if dirty, _ := IsDirty(ctx, repo); dirty {
+		t.Fatal("working tree should be clean after stashing")
+	}
+
+	// Switching to another branch leaves the stash alone
+	runGit(t, repo, "checkout", "-q", "review")
+	if restored, err := RestoreAutoStash(ctx, repo, "review", nil); restored || err != nil {
+		t.Fatalf("restored a stash for the wrong branch: %v, %v", restored, err)
+	}
+
+	runGit(t, repo, "checkout", "-q", "main")
+	restored, err := RestoreAutoStash(ctx, repo, "main", nil)
+	if !restored || err != nil {
+		t.Fatalf("RestoreAutoStash = %v, %v", restored, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(repo, "a.txt")); string(got) != "two\n" {
+		t.Errorf("change not restored, a.txt is %q", got)
+	}
+	if list := runGit(t, repo, "stash", "list"); list != "" {
+		t.Errorf("stash should be dropped after restoring, got %q", list)
+	}
+}
+
+func TestCheckoutWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+	dir := filepath.Join(root, "worktrees")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "initial")
+	runGit(t, origin, "branch", "feature")
+	runGit(t, root, "clone", "-q", origin, clone)
+
+	head := MRHead{Number: 3, Ref: "refs/heads/feature", Branch: "feature"}
+	result, err := CheckoutWorktree(ctx, clone, dir, head, CheckoutOptions{Strategy: PullMerge}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wtPath := filepath.Join(dir, "mr-3")
+	if result.Worktree != wtPath || result.Branch != "feature" {
+		t.Errorf("got worktree %q on %q, want %q on feature", result.Worktree, result.Branch, wtPath)
+	}
+	if got := runGit(t, clone, "rev-parse", "--abbrev-ref", "HEAD"); got != "main" {
+		t.Errorf("main working tree moved to %q", got)
+	}
+
+	// New commits on the MR fast-forward the existing worktree
+	runGit(t, origin, "checkout", "-q", "feature")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "more")
+	result, err = CheckoutWorktree(ctx, clone, dir, head, CheckoutOptions{Strategy: PullMerge}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on reuse: %v", err)
+	}
+	if result.Worktree != wtPath || result.Pulled != 1 {
+		t.Errorf("got %+v, want the same worktree with 1 commit pulled", result)
+	}
+
+	worktrees, err := ListWorktrees(ctx, clone)
+	if err != nil || len(worktrees) != 2 {
+		t.Fatalf("ListWorktrees = %+v, %v", worktrees, err)
+	}
+	if !worktrees[0].Main || WorktreeMR(worktrees[0]) != 0 || WorktreeMR(worktrees[1]) != 3 {
+		t.Errorf("unexpected worktrees %+v", worktrees)
+	}
+
+	if err := RemoveWorktree(ctx, clone, wtPath); err != nil {
+		t.Fatalf("RemoveWorktree: %v", err)
+	}
+	if worktrees, _ := ListWorktrees(ctx, clone); len(worktrees) != 1 {
+		t.Errorf("worktree not removed: %+v", worktrees)
+	}
+
+	// The MR's branch checked out in the main working tree isn't touched
+	runGit(t, clone, "checkout", "-q", "feature")
+	before := runGit(t, clone, "rev-parse", "HEAD")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "even more")
+	_, err = CheckoutWorktree(ctx, clone, dir, head, CheckoutOptions{Strategy: PullMerge}, nil)
+	if err == nil || !strings.Contains(err.Error(), "checked out in the main working tree") {
+		t.Errorf("got %v, want the main working tree refused", err)
+	}
+	if after := runGit(t, clone, "rev-parse", "HEAD"); after != before {
+		t.Errorf("main working tree moved from %s to %s", before, after)
+	}
+	if worktrees, _ := ListWorktrees(ctx, clone); len(worktrees) != 1 {
+		t.Errorf("a worktree was created: %+v", worktrees)
+	}
+}
+
+func TestCheckoutStrategies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(kv, "test")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(kv, "test@example.com")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "initial")
+	runGit(t, origin, "checkout", "-q", "-b", "feature")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "first")
+	runGit(t, root, "clone", "-q", "-b", "feature", origin, clone)
+
+	// The MR author rewrites the branch
+	runGit(t, origin, "commit", "-q", "--amend", "--allow-empty", "-m", "first, reworded")
+
+	result, err := CheckoutWithProgress(ctx, clone, "feature", CheckoutOptions{Strategy: PullReset}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.ForcePushed || !result.NeedsReset || result.LocalOnly != 1 || result.Upstream != "origin/feature" {
+		t.Fatalf("got %+v, want a force-push waiting for a reset that drops 1 commit", result)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "first" {
+		t.Fatalf("branch changed before the reset was confirmed: %q", got)
+	}
+
+	result, err = ResetToUpstream(ctx, clone, result, nil)
+	if err != nil || !result.Reset {
+		t.Fatalf("ResetToUpstream = %+v, %v", result, err)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "first, reworded" {
+		t.Errorf("HEAD is %q after reset, want the rewritten commit", got)
+	}
+
+	runGit(t, origin, "commit", "-q", "--amend", "--allow-empty", "-m", "first, reworded again")
+	if _, err := CheckoutWithProgress(ctx, clone, "feature", CheckoutOptions{Strategy: PullFFOnly}, nil); err == nil || !strings.Contains(err.Error(), "force-pushed") {
+		t.Fatalf("ff-only on a force-pushed branch should fail and say why, got %v", err)
+	}
+	runGit(t, clone, "reset", "-q", "--hard", "origin/feature")
+
+	// A plain extension fast-forwards under any strategy
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "second")
+	result, err = CheckoutWithProgress(ctx, clone, "feature", CheckoutOptions{Strategy: PullRebase}, nil)
+	if err != nil || result.ForcePushed || result.Pulled != 1 {
+		t.Errorf("got %+v, %v, want 1 commit pulled without a force-push", result, err)
+	}
+}
+
+func TestParsePullStrategy(t *testing.T) {
+	if s, err := ParsePullStrategy(""); err != nil || s != PullMerge {
+		t.Errorf("empty strategy = %q, %v, want merge", s, err)
+	}
+	if s, err := ParsePullStrategy("rebase"); err != nil || s != PullRebase {
+		t.Errorf("rebase = %q, %v", s, err)
+	}
+	if _, err := ParsePullStrategy("squash"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestCheckoutFetchesOnlyTarget(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "initial")
+	runGit(t, origin, "branch", "feature")
+	runGit(t, origin, "branch", "other")
+	runGit(t, root, "clone", "-q", origin, clone)
+
+	// Everything moves on origin, only the target and base should be fetched
+	for _, branch := range []string{"main", "feature", "other"} {
+		runGit(t, origin, "checkout", "-q", branch)
+		runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "new on "+branch)
+	}
+	otherBefore := runGit(t, clone, "rev-parse", "origin/other")
+
+	var fetchLabel string
+	_, err := CheckoutWithProgress(ctx, clone, "feature", CheckoutOptions{Strategy: PullMerge, BaseBranch: "main"}, func(p Progress) {
+		if p.Step == "fetch" && p.State == StepDone {
+			fetchLabel = p.Label
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(fetchLabel, "Fetched origin/feature and origin/main in ") {
+		t.Errorf("got fetch label %q, want the fetched refs and timing", fetchLabel)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s", "origin/main"); got != "new on main" {
+		t.Errorf("base branch not fetched, origin/main is at %q", got)
+	}
+	if got := runGit(t, clone, "rev-parse", "origin/other"); got != otherBefore {
+		t.Error("an unrelated branch was fetched")
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "new on feature" {
+		t.Errorf("HEAD is %q, want the latest feature commit", got)
+	}
+}
+
+func TestParsePrefetchConfig(t *testing.T) {
+	tests := []struct {
+		value string
+		want  PrefetchConfig
+	}{
+		{"", PrefetchConfig{}},
+		{"0", PrefetchConfig{}},
+		{"5", PrefetchConfig{Top: 5}},
+		{"review", PrefetchConfig{Review: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrefetchConfig(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePrefetchConfig(%q) = %+v, %v; want %+v", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"-1", "all"} {
+		if _, err := ParsePrefetchConfig(value); err == nil {
+			t.Errorf("ParsePrefetchConfig(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "initial")
+	for _, n := range []string{"1", "2", "3"} {
+		runGit(t, origin, "checkout", "-q", "-b", "mr"+n, "main")
+		runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "mr "+n)
+		runGit(t, origin, "update-ref", "refs/pull/"+n+"/head", "HEAD")
+	}
+	runGit(t, origin, "checkout", "-q", "main")
+	runGit(t, root, "clone", "-q", origin, clone)
+	headBefore := runGit(t, clone, "rev-parse", "HEAD")
+
+	heads := []MRHead{
+		{Number: 1, Ref: "refs/pull/1/head"},
+		{Number: 2, Ref: "refs/pull/2/head"},
+		{Number: 3, Ref: "refs/pull/3/head"},
+		{Number: 4, Ref: "refs/pull/4/head"}, // gone from origin
+	}
+	fetched, err := Prefetch(ctx, clone, heads, 2)
+	if fetched != 3 || err == nil || !strings.Contains(err.Error(), "refs/pull/4/head") {
+		t.Errorf("got %d fetched, error %v; want 3 and an error for the missing head", fetched, err)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s", PrefetchRef(2)); got != "mr 2" {
+		t.Errorf("prefetched ref 2 is at %q, want mr 2", got)
+	}
+	if got := runGit(t, clone, "rev-parse", "HEAD"); got != headBefore {
+		t.Error("prefetching moved HEAD")
+	}
+	if got := runGit(t, clone, "status", "--porcelain"); got != "" {
+		t.Errorf("prefetching touched the working tree:\n%s", got)
+	}
+
+	// MRs that dropped out of the list lose their prefetched refs
+	if _, err := Prefetch(ctx, clone, heads[:1], 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := runGit(t, clone, "for-each-ref", "--format=%(refname)", prefetchNamespace); got != PrefetchRef(1) {
+		t.Errorf("prefetched refs are %q, want only %s", got, PrefetchRef(1))
+	}
+
+	// A checkout uses the prefetched head, even when origin is unreachable
+	runGit(t, clone, "remote", "set-url", "origin", filepath.Join(root, "missing"))
+	var fetchLabel string
+	result, err := CheckoutMRHead(ctx, clone, heads[0], CheckoutOptions{Strategy: PullMerge}, func(p Progress) {
+		if p.Step == "fetch" && p.State == StepDone {
+			fetchLabel = p.Label
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Prefetched || !strings.Contains(fetchLabel, "prefetched") {
+		t.Errorf("got result %+v and fetch label %q, want the prefetched head used", result, fetchLabel)
+	}
+	if got := runGit(t, clone, "log", "-1", "--format=%s"); got != "mr 1" {
+		t.Errorf("HEAD is %q, want the prefetched MR head", got)
+	}
+}
+
+func TestCheckoutHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "file.txt")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	runGit(t, repo, "branch", "feature")
+
+	// Nothing recorded yet, git's own previous branch is used
+	runGit(t, repo, "checkout", "-q", "feature")
+	if got, err := PreviousBranch(ctx, repo, "feature"); err != nil || got != "main" {
+		t.Errorf("PreviousBranch = %q, %v; want main from @{-1}", got, err)
+	}
+	runGit(t, repo, "checkout", "-q", "main")
+
+	// Leave main with stashed changes for the MR branch
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AutoStash(ctx, repo, false, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runGit(t, repo, "checkout", "-q", "feature")
+	if err := RecordCheckout(ctx, repo, "main", HistoryEntry{Branch: "feature", MR: 12}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err := LoadHistory(ctx, repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].Branch != "feature" || history[0].MR != 12 || history[1].Branch != "main" {
+		t.Fatalf("got history %+v, want feature (#12) then main", history)
+	}
+	if history[1].Stash == "" || history[1].Stash != runGit(t, repo, "rev-parse", "stash@{0}") {
+		t.Errorf("main's entry has stash %q, want the autostash", history[1].Stash)
+	}
+	if got, _ := PreviousBranch(ctx, repo, "feature"); got != "main" {
+		t.Errorf("PreviousBranch = %q, want main", got)
+	}
+
+	// Going back restores the stash, which is then forgotten, and the MR
+	// branch keeps its number when checked out again without one
+	runGit(t, repo, "checkout", "-q", "main")
+	if _, err := RestoreAutoStash(ctx, repo, "main", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RecordCheckout(ctx, repo, "feature", HistoryEntry{Branch: "main"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runGit(t, repo, "stash", "-q")
+	runGit(t, repo, "checkout", "-q", "feature")
+	if err := RecordCheckout(ctx, repo, "main", HistoryEntry{Branch: "feature"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history, _ = LoadHistory(ctx, repo)
+	recent := RecentBranches(history, "feature")
+	if len(recent) != 1 || recent[0].Branch != "main" || recent[0].Stash != "" {
+		t.Errorf("got recent branches %+v, want main without a stash", recent)
+	}
+	if history[0].MR != 12 {
+		t.Errorf("feature checked out again has MR %d, want 12", history[0].MR)
+	}
+}
+
+func TestUpdateFromBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	write(origin, "shared.txt", "base\n")
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-q", "-m", "initial")
+	runGit(t, origin, "branch", "feature")
+	runGit(t, root, "clone", "-q", origin, clone)
+	// The rebase and merge commits are made by gq, outside runGit's environment
+	runGit(t, clone, "config", "user.name", "test")
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "checkout", "-q", "feature")
+	write(clone, "feature.txt", "feature\n")
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-q", "-m", "feature work")
+	runGit(t, clone, "push", "-q", "origin", "feature")
+
+	// main moves on without touching the feature's files: a clean rebase
+	write(origin, "main.txt", "main\n")
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-q", "-m", "main work")
+
+	result, err := UpdateFromBase(ctx, clone, "main", UpdateRebase, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Updated || result.Behind != 1 || result.InProgress || result.Remote != "origin" || result.RemoteBranch != "feature" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if got := runGit(t, clone, "log", "--format=%s", "-3"); got != "feature work\nmain work\ninitial" {
+		t.Errorf("history after rebase:\n%s", got)
+	}
+	if err := PushWithLease(ctx, clone, result, nil); err != nil {
+		t.Fatalf("force-push with lease failed: %v", err)
+	}
+	if got, want := runGit(t, origin, "rev-parse", "feature"), runGit(t, clone, "rev-parse", "HEAD"); got != want {
+		t.Errorf("origin/feature is %s after the push, want %s", got, want)
+	}
+
+	// A conflicting change on main stops the merge for the user
+	write(origin, "shared.txt", "main's version\n")
+	runGit(t, origin, "commit", "-q", "-am", "main edits shared")
+	write(clone, "shared.txt", "feature's version\n")
+	runGit(t, clone, "commit", "-q", "-am", "feature edits shared")
+
+	result, err = UpdateFromBase(ctx, clone, "main", UpdateMerge, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.InProgress || strings.Join(result.Conflicts, ",") != "shared.txt" {
+		t.Fatalf("got %+v, want the merge in progress with shared.txt conflicted", result)
+	}
+
+	// Aborting brings the branch back
+	head := runGit(t, clone, "rev-parse", "HEAD")
+	if err := AbortUpdate(ctx, clone); err != nil {
+		t.Fatalf("abort failed: %v", err)
+	}
+	if result, err = CheckUpdate(ctx, clone, result); err != nil || result.InProgress || result.Updated {
+		t.Errorf("after abort got %+v, %v; want neither in progress nor updated", result, err)
+	}
+	if got := runGit(t, clone, "rev-parse", "HEAD"); got != head {
+		t.Error("abort didn't restore HEAD")
+	}
+
+	// Resolving and continuing completes the merge
+	if result, err = UpdateFromBase(ctx, clone, "main", UpdateMerge, nil); err != nil || !result.InProgress {
+		t.Fatalf("got %+v, %v; want the merge in progress again", result, err)
+	}
+	write(clone, "shared.txt", "both versions\n")
+	runGit(t, clone, "add", "shared.txt")
+	result, err = ContinueUpdate(ctx, clone, result)
+	if err != nil || result.InProgress || !result.Updated {
+		t.Errorf("after continue got %+v, %v; want the update completed", result, err)
+	}
+}
+
+func TestParseUpdateStrategy(t *testing.T) {
+	if s, err := ParseUpdateStrategy(""); err != nil || s != UpdateMerge {
+		t.Errorf("default strategy = %q, %v; want merge", s, err)
+	}
+	if s, err := ParseUpdateStrategy("rebase"); err != nil || s != UpdateRebase {
+		t.Errorf("got %q, %v; want rebase", s, err)
+	}
+	if _, err := ParseUpdateStrategy("squash"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	// Only the repo's own config counts
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "config", "gq.pullStrategy", "rebase")
+
+	got := Settings(context.Background(), repo)
+	want := []Setting{
+		{Key: "gq.pullStrategy", Value: "rebase", Origin: "file:.git/config", Default: "merge"},
+		{Key: "gq.updateStrategy", Value: "merge", Default: "merge"},
+		{Key: "gq.prefetch", Value: "off", Default: "off"},
+		{Key: "gq.fetchFilter"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
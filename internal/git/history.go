@@ -0,0 +1,161 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// historyFile is the checkout history's file name in the git directory,
+// shared by all worktrees of the repository
+const historyFile = "gq-history.json"
+
+// maxHistory bounds the number of checkouts kept
+const maxHistory = 50
+
+// HistoryEntry is a branch that was checked out, or left, with gq
+type HistoryEntry struct {
+	Branch string    `json:"branch"`
+	MR     int       `json:"mr,omitempty"` // MR the branch was checked out for, 0 if none
+	Time   time.Time `json:"time"`
+	Stash  string    `json:"stash,omitempty"` // SHA of the changes stashed when the branch was left
+}
+
+// historyPath returns where the checkout history of the repo at path is kept
+func historyPath(ctx context.Context, path string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return filepath.Join(dir, historyFile), nil
+}
+
+// LoadHistory returns the checkout history of the repo, newest first. A repo
+// gq never checked anything out in has an empty history.
+func LoadHistory(ctx context.Context, path string) ([]HistoryEntry, error) {
+	file, err := historyPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []HistoryEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// RecordCheckout adds a checkout of to.Branch, coming from branch from, to
+// the history. The branch left is noted too, with the stash gq took of its
+// changes. A branch checked out without an MR keeps the MR it was first
+// checked out for.
+func RecordCheckout(ctx context.Context, path, from string, to HistoryEntry) error {
+	history, err := LoadHistory(ctx, path)
+	if err != nil {
+		return err
+	}
+	if to.Time.IsZero() {
+		to.Time = time.Now()
+	}
+
+	if from != "" && from != to.Branch {
+		if len(history) == 0 || history[0].Branch != from {
+			left := HistoryEntry{Branch: from, MR: historyMR(history, from), Time: to.Time}
+			history = append([]HistoryEntry{left}, history...)
+		}
+		if ref, err := findAutoStash(ctx, path, from); err == nil && ref != "" {
+			history[0].Stash = revParse(ctx, path, ref)
+		}
+	}
+	// The stash taken when to.Branch was left is gone once it was restored
+	if ref, err := findAutoStash(ctx, path, to.Branch); err == nil && ref == "" {
+		for i := range history {
+			if history[i].Branch == to.Branch {
+				history[i].Stash = ""
+			}
+		}
+	}
+	if to.MR == 0 {
+		to.MR = historyMR(history, to.Branch)
+	}
+	history = append([]HistoryEntry{to}, history...)
+	if len(history) > maxHistory {
+		history = history[:maxHistory]
+	}
+
+	file, err := historyPath(ctx, path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// historyMR returns the MR branch was last checked out for, 0 if none
+func historyMR(history []HistoryEntry, branch string) int {
+	for _, e := range history {
+		if e.Branch == branch && e.MR != 0 {
+			return e.MR
+		}
+	}
+	return 0
+}
+
+// RecentBranches returns the latest entry of each branch in history, newest
+// first, leaving out current
+func RecentBranches(history []HistoryEntry, current string) []HistoryEntry {
+	seen := map[string]bool{current: true}
+	var recent []HistoryEntry
+	for _, e := range history {
+		if seen[e.Branch] {
+			continue
+		}
+		seen[e.Branch] = true
+		recent = append(recent, e)
+	}
+	return recent
+}
+
+// PreviousBranch returns the branch checked out before current: the latest
+// other branch in gq's history, or else git's own @{-1}. Returns "" if there
+// is none.
+func PreviousBranch(ctx context.Context, path, current string) (string, error) {
+	history, err := LoadHistory(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if recent := RecentBranches(history, current); len(recent) > 0 {
+		return recent[0].Branch, nil
+	}
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--symbolic-full-name", "@{-1}")
+	if err != nil {
+		// Nothing was checked out before
+		return "", nil
+	}
+	// A detached HEAD before has no branch to return to
+	ref := strings.TrimSpace(string(out))
+	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && branch != current {
+		return branch, nil
+	}
+	return "", nil
+}
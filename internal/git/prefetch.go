@@ -0,0 +1,131 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// prefetchNamespace holds prefetched MR heads, out of the way of branches
+// and remote-tracking refs
+const prefetchNamespace = "refs/gq/prefetch/"
+
+// prefetchKey is the git config key that turns background prefetching on
+const prefetchKey = "gq.prefetch"
+
+// PrefetchJobs is how many prefetch fetches run at once
+const PrefetchJobs = 3
+
+// PrefetchConfig says which MR heads to prefetch in the background
+type PrefetchConfig struct {
+	Top    int  // the first Top MRs in the list
+	Review bool // MRs whose review is requested from you
+}
+
+// Enabled reports whether anything should be prefetched
+func (c PrefetchConfig) Enabled() bool {
+	return c.Top > 0 || c.Review
+}
+
+// GetPrefetchConfig returns the prefetch setting of the repo, from git
+// config gq.prefetch. Prefetching is off when it isn't set.
+func GetPrefetchConfig(ctx context.Context, path string) (PrefetchConfig, error) {
+	return ParsePrefetchConfig(configValue(ctx, path, prefetchKey))
+}
+
+// ParsePrefetchConfig parses a gq.prefetch value: a number N for the top N
+// MRs in the list, "review" for MRs awaiting your review, or "" or 0 for off
+func ParsePrefetchConfig(value string) (PrefetchConfig, error) {
+	switch value {
+	case "", "false", "off":
+		return PrefetchConfig{}, nil
+	case "review":
+		return PrefetchConfig{Review: true}, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return PrefetchConfig{}, fmt.Errorf("%s: unknown value %q (use a number of MRs or review)", prefetchKey, value)
+	}
+	return PrefetchConfig{Top: n}, nil
+}
+
+// PrefetchRef returns the ref an MR head is prefetched into
+func PrefetchRef(number int) string {
+	return prefetchNamespace + strconv.Itoa(number)
+}
+
+// Prefetch fetches MR heads into the prefetch namespace, at most jobs at a
+// time, and deletes prefetched refs of MRs no longer among heads. Only refs
+// and objects are written: the working tree, index, HEAD and FETCH_HEAD are
+// left alone. A later checkout of one of the MRs then has nothing left to
+// download. Returns the number of heads fetched and the first failure.
+func Prefetch(ctx context.Context, path string, heads []MRHead, jobs int) (int, error) {
+	if err := prunePrefetched(ctx, path, heads); err != nil {
+		return 0, err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		fetched int
+		first   error
+	)
+	sem := make(chan struct{}, max(1, jobs))
+	for _, head := range heads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := prefetchHead(ctx, path, head)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				fetched++
+			} else if first == nil {
+				first = fmt.Errorf("prefetching %s: %w", head.Ref, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return fetched, first
+}
+
+// prefetchHead fetches one MR head into its prefetch ref
+func prefetchHead(ctx context.Context, path string, head MRHead) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	args := []string{"fetch", "--quiet", "--no-tags", "--no-write-fetch-head", "--no-auto-gc"}
+	if filter := configValue(ctx, path, fetchFilterKey); filter != "" {
+		args = append(args, "--filter="+filter)
+	}
+	args = append(args, "origin", "+"+head.Ref+":"+PrefetchRef(head.Number))
+	return cmd.RunSimple(ctx, path, "git", args...)
+}
+
+// prunePrefetched deletes prefetched refs of MRs that aren't among heads
+func prunePrefetched(ctx context.Context, path string, heads []MRHead) error {
+	keep := make(map[string]bool, len(heads))
+	for _, head := range heads {
+		keep[PrefetchRef(head.Number)] = true
+	}
+	out, err := cmd.Run(ctx, path, "git", "for-each-ref", "--format=%(refname)", prefetchNamespace)
+	if err != nil {
+		return err
+	}
+	for _, ref := range strings.Fields(string(out)) {
+		if keep[ref] {
+			continue
+		}
+		if err := cmd.RunSimple(ctx, path, "git", "update-ref", "-d", ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
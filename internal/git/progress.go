@@ -0,0 +1,133 @@
+package git
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// StepState is the state of a checkout step
+type StepState int
+
+const (
+	StepRunning StepState = iota
+	StepDone
+	StepFailed
+)
+
+// Progress reports a change in one step of a checkout
+type Progress struct {
+	Step    string // "fetch", "checkout", "pull" or "track", as in CheckoutError
+	State   StepState
+	Label   string // what the step is doing or did, e.g. "Fetching origin"
+	Phase   string // git's current progress phase, e.g. "Receiving objects"
+	Percent int    // progress of the phase, -1 when unknown
+}
+
+// CheckoutResult summarises a completed checkout
+type CheckoutResult struct {
+	Branch       string // local branch that is now checked out
+	PreviousHead string // short SHA of HEAD before the checkout, empty if unknown
+	Head         string // short SHA of HEAD after the checkout
+	Pulled       int    // commits the pull brought into the branch
+	StashedFrom  string // branch whose changes were stashed before the checkout
+	Restored     bool   // changes stashed when leaving the branch were restored
+	Worktree     string // worktree the branch was checked out in, empty for the main one
+	Strategy     PullStrategy
+	Upstream     string // what the branch was updated from, e.g. origin/main
+	RemoteBefore string // full SHA of the upstream before fetching, empty if unknown
+	RemoteAfter  string // full SHA of the upstream after fetching
+	ForcePushed  bool   // the upstream was rewritten rather than extended
+	NeedsReset   bool   // the reset strategy is waiting for confirmation
+	LocalOnly    int    // local commits a reset would drop
+	Reset        bool   // the branch was hard-reset to the upstream
+	Prefetched   bool   // the MR head had already been prefetched in the background
+}
+
+// stepper sends progress for checkout steps to an optional callback
+type stepper struct {
+	report func(Progress)
+}
+
+func (s stepper) send(p Progress) {
+	if s.report != nil {
+		s.report(p)
+	}
+}
+
+func (s stepper) start(step, label string) {
+	s.send(Progress{Step: step, State: StepRunning, Label: label, Percent: -1})
+}
+
+func (s stepper) done(step, label string) {
+	s.send(Progress{Step: step, State: StepDone, Label: label, Percent: -1})
+}
+
+// fail marks a step as failed and wraps err as a CheckoutError
+func (s stepper) fail(step string, err error) error {
+	s.send(Progress{Step: step, State: StepFailed, Percent: -1})
+	return &CheckoutError{Step: step, Err: err}
+}
+
+// runWithProgress runs git with --progress output forwarded as phase updates of step
+func (s stepper) runWithProgress(ctx context.Context, path, step, label string, args ...string) error {
+	return cmd.RunStreaming(ctx, path, func(line string) {
+		if phase, percent, ok := parseProgress(line); ok {
+			s.send(Progress{Step: step, State: StepRunning, Label: label, Phase: phase, Percent: percent})
+		}
+	}, "git", args...)
+}
+
+// progressLine matches git's progress output, e.g.
+// "Receiving objects:  45% (450/1000), 1.2 MiB | 800 KiB/s"
+var progressLine = regexp.MustCompile(`^(?:remote: )?([A-Za-z][A-Za-z ]*):\s+(\d{1,3})%`)
+
+// parseProgress extracts the phase and percentage from a git progress line
+func parseProgress(line string) (phase string, percent int, ok bool) {
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return "", 0, false
+	}
+	percent, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], percent, true
+}
+
+// shortHead returns the short SHA of HEAD, or "" if there is none yet
+func shortHead(ctx context.Context, path string) string {
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// countCommits returns the number of commits in from..to
+func countCommits(ctx context.Context, path, from, to string) int {
+	if from == "" || to == "" || from == to {
+		return 0
+	}
+	out, err := cmd.Run(ctx, path, "git", "rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n
+}
+
+// pulledLabel describes the outcome of a pull
+func pulledLabel(commits int) string {
+	switch commits {
+	case 0:
+		return "Already up to date"
+	case 1:
+		return "Pulled (1 commit)"
+	default:
+		return "Pulled (" + strconv.Itoa(commits) + " commits)"
+	}
+}
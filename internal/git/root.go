@@ -0,0 +1,49 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+var (
+	// ErrNotARepo is returned for paths outside any git repository
+	ErrNotARepo = errors.New("not a git repository")
+	// ErrBareRepo is returned for bare repositories, which have no work tree
+	// to check branches out in
+	ErrBareRepo = errors.New("bare repository")
+	// ErrNoWorkTree is returned for paths inside a repository's git directory
+	ErrNoWorkTree = errors.New("not in a work tree")
+)
+
+// RepoRoot returns the top of the work tree containing path, the way git
+// finds it: from a subdirectory, a linked worktree or a submodule, whose
+// .git is a file rather than a directory
+func RepoRoot(ctx context.Context, path string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--is-bare-repository", "--is-inside-work-tree")
+	if err != nil {
+		var cmdErr *cmd.Error
+		if errors.As(err, &cmdErr) && strings.Contains(cmdErr.Stderr, "not a git repository") {
+			return "", fmt.Errorf("%s: %w", path, ErrNotARepo)
+		}
+		return "", err
+	}
+
+	bare, inside, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	switch {
+	case bare == "true":
+		return "", fmt.Errorf("%s: %w", path, ErrBareRepo)
+	case strings.TrimSpace(inside) != "true":
+		return "", fmt.Errorf("%s: %w", path, ErrNoWorkTree)
+	}
+
+	out, err = cmd.Run(ctx, path, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	ctx := context.Background()
+
+	// Resolve symlinks such as macOS's /var -> /private/var, which git
+	// reports resolved
+	tmp, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(tmp, "repo")
+	runGit(t, tmp, "init", "-q", "-b", "main", "repo")
+	if err := os.MkdirAll(filepath.Join(repo, "src", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	worktree := filepath.Join(tmp, "worktree")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "feature", worktree)
+
+	runGit(t, tmp, "clone", "-q", "--bare", "repo", "bare.git")
+	runGit(t, repo, "-c", "protocol.file.allow=always", "submodule", "add", "-q", filepath.Join(tmp, "bare.git"), "sub")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+		err  error
+	}{
+		{"top", repo, repo, nil},
+		{"subdirectory", filepath.Join(repo, "src", "pkg"), repo, nil},
+		{"linked worktree", worktree, worktree, nil},
+		{"submodule", filepath.Join(repo, "sub"), filepath.Join(repo, "sub"), nil},
+		{"bare", filepath.Join(tmp, "bare.git"), "", ErrBareRepo},
+		{"git directory", filepath.Join(repo, ".git"), "", ErrNoWorkTree},
+		{"not a repository", t.TempDir(), "", ErrNotARepo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepoRoot(ctx, tt.path)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("root = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
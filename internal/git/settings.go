@@ -0,0 +1,42 @@
+package git
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// Setting is one of gq's settings kept in git config rather than gq's
+// config file
+type Setting struct {
+	Key     string // e.g. gq.pullStrategy
+	Value   string // what applies, the default if it isn't set
+	Origin  string // where git found it, e.g. file:.git/config; empty if it isn't set
+	Default string
+}
+
+// Settings returns gq's git config settings for the repo at path, or for the
+// user's git config outside a repository
+func Settings(ctx context.Context, path string) []Setting {
+	settings := []Setting{
+		{Key: pullStrategyKey, Default: string(PullMerge)},
+		{Key: updateStrategyKey, Default: string(UpdateMerge)},
+		{Key: prefetchKey, Default: "off"},
+		{Key: fetchFilterKey},
+	}
+	for i, s := range settings {
+		settings[i].Value = s.Default
+		// Lines look like "file:.git/config\trebase"
+		out, err := cmd.Run(ctx, path, "git", "config", "--show-origin", "--get", s.Key)
+		if err != nil {
+			continue
+		}
+		origin, value, ok := strings.Cut(strings.TrimSpace(string(out)), "\t")
+		if !ok {
+			continue
+		}
+		settings[i].Value, settings[i].Origin = value, origin
+	}
+	return settings
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// FileStatus is one entry of git status
+type FileStatus struct {
+	Path     string
+	OrigPath string // source path of a rename or copy
+	Index    byte   // status in the index, as in git status --porcelain
+	Worktree byte   // status in the working tree
+}
+
+// Untracked reports whether the file isn't known to git
+func (f FileStatus) Untracked() bool {
+	return f.Index == '?'
+}
+
+// Code returns the two-letter porcelain status, e.g. " M" or "??"
+func (f FileStatus) Code() string {
+	return string([]byte{f.Index, f.Worktree})
+}
+
+// Status returns the uncommitted changes in the working tree
+func Status(ctx context.Context, path string) ([]FileStatus, error) {
+	out, err := cmd.Run(ctx, path, "git", "status", "--porcelain", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(string(out)), nil
+}
+
+// parseStatus parses the output of git status --porcelain -z
+func parseStatus(out string) []FileStatus {
+	var files []FileStatus
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		f := FileStatus{Index: entry[0], Worktree: entry[1], Path: entry[3:]}
+		// Renames and copies are followed by their source path
+		if (f.Index == 'R' || f.Index == 'C') && i+1 < len(entries) {
+			i++
+			f.OrigPath = entries[i]
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// OnlyUntracked reports whether none of files is tracked by git
+func OnlyUntracked(files []FileStatus) bool {
+	for _, f := range files {
+		if !f.Untracked() {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkingDiff returns the patch of tracked changes against HEAD
+func WorkingDiff(ctx context.Context, path string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "diff", "HEAD", "--no-color", "--no-ext-diff")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// autoStashPrefix names the stashes gq creates, followed by the branch they
+// were taken on, so they can be found again when returning to that branch
+const autoStashPrefix = "gq autostash from "
+
+// AutoStash stashes uncommitted changes under a name recording the current
+// branch. Untracked files are left in place unless untracked is set. It
+// returns the branch the changes were stashed on.
+func AutoStash(ctx context.Context, path string, untracked bool, report func(Progress)) (string, error) {
+	s := stepper{report: report}
+	s.start("stash", "Stashing changes")
+
+	branch, err := GetCurrentBranch(ctx, path)
+	if err != nil {
+		return "", s.fail("stash", err)
+	}
+	args := []string{"stash", "push", "-m", autoStashPrefix + branch}
+	if untracked {
+		args = append(args, "--include-untracked")
+	}
+	if err := cmd.RunSimple(ctx, path, "git", args...); err != nil {
+		return "", s.fail("stash", err)
+	}
+	s.done("stash", "Stashed changes from "+branch)
+	return branch, nil
+}
+
+// findAutoStash returns the stash gq took when leaving branch, e.g.
+// "stash@{1}", or "" if there is none
+func findAutoStash(ctx context.Context, path, branch string) (string, error) {
+	out, err := cmd.Run(ctx, path, "git", "stash", "list", "--format=%gd%x00%gs")
+	if err != nil {
+		return "", err
+	}
+	// Subjects look like "On main: gq autostash from main"
+	suffix := ": " + autoStashPrefix + branch
+	for _, line := range strings.Split(string(out), "\n") {
+		ref, subject, ok := strings.Cut(line, "\x00")
+		if ok && strings.HasSuffix(subject, suffix) {
+			return ref, nil
+		}
+	}
+	return "", nil
+}
+
+// RestoreAutoStash pops the stash gq took when leaving branch, if there is
+// one. A stash that doesn't apply cleanly is kept for the user to resolve.
+func RestoreAutoStash(ctx context.Context, path, branch string, report func(Progress)) (bool, error) {
+	s := stepper{report: report}
+	ref, err := findAutoStash(ctx, path, branch)
+	if err != nil || ref == "" {
+		return false, err
+	}
+
+	s.start("restore", "Restoring changes stashed on "+branch)
+	if err := cmd.RunSimple(ctx, path, "git", "stash", "pop", ref); err != nil {
+		return false, s.fail("restore", err)
+	}
+	s.done("restore", "Restored changes stashed on "+branch)
+	return true, nil
+}
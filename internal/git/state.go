@@ -0,0 +1,84 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// RepoState describes the state a repository is in, e.g. after an
+// interrupted checkout
+type RepoState struct {
+	Branch    string // current branch, "HEAD" when detached
+	Head      string // short SHA of HEAD
+	Operation string // "merge", "rebase" or "cherry-pick" left in progress, empty if none
+	Dirty     bool   // uncommitted changes in the working tree
+	IndexLock bool   // index.lock left behind by an interrupted git command
+}
+
+// DescribeState inspects the repository at path
+func DescribeState(ctx context.Context, path string) (RepoState, error) {
+	var state RepoState
+
+	branch, err := GetCurrentBranch(ctx, path)
+	if err != nil {
+		return state, err
+	}
+	state.Branch = branch
+	state.Head = shortHead(ctx, path)
+
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--git-dir")
+	if err != nil {
+		return state, err
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+	switch {
+	case exists("rebase-merge"), exists("rebase-apply"):
+		state.Operation = "rebase"
+	case exists("MERGE_HEAD"):
+		state.Operation = "merge"
+	case exists("CHERRY_PICK_HEAD"):
+		state.Operation = "cherry-pick"
+	}
+	state.IndexLock = exists("index.lock")
+
+	// git status would fail on a stale lock, so only check when there is none
+	if !state.IndexLock {
+		dirty, err := IsDirty(ctx, path)
+		if err != nil {
+			return state, err
+		}
+		state.Dirty = dirty
+	}
+	return state, nil
+}
+
+// Summary describes the state in one line, with what to do about leftovers
+func (s RepoState) Summary() string {
+	summary := "On " + s.Branch
+	if s.Head != "" {
+		summary += " at " + s.Head
+	}
+	if s.Dirty {
+		summary += ", with uncommitted changes"
+	} else if !s.IndexLock {
+		summary += ", clean"
+	}
+	if s.Operation != "" {
+		summary += "; " + s.Operation + " in progress (git " + s.Operation + " --abort to undo)"
+	}
+	if s.IndexLock {
+		summary += "; stale index.lock left behind (remove it if no git command is running)"
+	}
+	return summary
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// PullStrategy is how a checkout brings a branch up to date with its upstream
+type PullStrategy string
+
+const (
+	PullMerge  PullStrategy = "merge"   // merge the upstream, like git pull
+	PullFFOnly PullStrategy = "ff-only" // fast-forward only, fail if the branch diverged
+	PullRebase PullStrategy = "rebase"  // rebase local commits onto the upstream
+	PullReset  PullStrategy = "reset"   // hard-reset to the upstream once confirmed
+	PullNone   PullStrategy = "none"    // leave the branch as it is
+)
+
+// pullStrategyKey is the git config key holding a repo's pull strategy
+const pullStrategyKey = "gq.pullStrategy"
+
+// GetPullStrategy returns the pull strategy configured for the repo with
+// git config gq.pullStrategy, PullMerge if none is set
+func GetPullStrategy(ctx context.Context, path string) (PullStrategy, error) {
+	return ParsePullStrategy(configValue(ctx, path, pullStrategyKey))
+}
+
+// ParsePullStrategy validates a pull strategy name
+func ParsePullStrategy(name string) (PullStrategy, error) {
+	switch s := PullStrategy(name); s {
+	case PullMerge, PullFFOnly, PullRebase, PullReset, PullNone:
+		return s, nil
+	case "":
+		return PullMerge, nil
+	}
+	return PullMerge, fmt.Errorf("%s: unknown strategy %q (use merge, ff-only, rebase, reset or none)", pullStrategyKey, name)
+}
+
+// revParse returns the full SHA of rev, or "" if it doesn't exist
+func revParse(ctx context.Context, path, rev string) string {
+	out, err := cmd.Run(ctx, path, "git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// isAncestor reports whether commit a is an ancestor of (or equal to) b
+func isAncestor(ctx context.Context, path, a, b string) bool {
+	return cmd.RunSimple(ctx, path, "git", "merge-base", "--is-ancestor", a, b) == nil
+}
+
+// ShortSHA abbreviates a full SHA for display
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// noteRemote records the upstream's SHA before and after fetching and
+// whether it was rewritten rather than extended
+func noteRemote(ctx context.Context, path string, result *CheckoutResult, before, after string) {
+	result.RemoteBefore = before
+	result.RemoteAfter = after
+	result.ForcePushed = before != "" && after != "" && before != after && !isAncestor(ctx, path, before, after)
+}
+
+// updateBranch brings the checked out branch up to date with upstream (a
+// commit SHA) as the strategy says. upstreamName is shown in messages.
+func updateBranch(ctx context.Context, path string, s stepper, strategy PullStrategy, upstream, upstreamName string, result *CheckoutResult) error {
+	result.Strategy = strategy
+	result.Upstream = upstreamName
+	if strategy == PullNone {
+		s.done("pull", "Not updated (pull strategy none)")
+		return nil
+	}
+
+	s.start("pull", "Updating from "+upstreamName)
+	before := revParse(ctx, path, "HEAD")
+	var err error
+	switch {
+	case before == upstream || isAncestor(ctx, path, upstream, before):
+		// Nothing to bring in
+	case isAncestor(ctx, path, before, upstream):
+		// Every strategy agrees on a fast-forward
+		err = cmd.RunSimple(ctx, path, "git", "merge", "--ff-only", upstream)
+	case strategy == PullReset:
+		// Diverged: resetting drops local commits, so ask first
+		result.NeedsReset = true
+		result.LocalOnly = countCommits(ctx, path, upstream, before)
+		s.done("pull", "Waiting to reset to "+upstreamName)
+		return nil
+	case strategy == PullFFOnly:
+		err = fmt.Errorf("%s has diverged from %s, can't fast-forward", result.Branch, upstreamName)
+	case strategy == PullRebase:
+		if err = cmd.RunSimple(ctx, path, "git", "rebase", upstream); err != nil {
+			// Don't leave a half-done rebase behind
+			_ = cmd.RunSimple(context.Background(), path, "git", "rebase", "--abort")
+			err = fmt.Errorf("rebase onto %s failed and was aborted: %w", upstreamName, err)
+		}
+	default:
+		err = cmd.RunSimple(ctx, path, "git", "merge", "--no-edit", upstream)
+	}
+	if err != nil {
+		if result.ForcePushed {
+			err = fmt.Errorf("%w (%s was force-pushed)", err, upstreamName)
+		}
+		return s.fail("pull", err)
+	}
+
+	result.Head = shortHead(ctx, path)
+	result.Pulled = countCommits(ctx, path, before, "HEAD")
+	s.done("pull", pulledLabel(result.Pulled))
+	return nil
+}
+
+// ResetToUpstream hard-resets the checked out branch to the upstream a
+// checkout with the reset strategy stopped at, once the user confirmed
+func ResetToUpstream(ctx context.Context, path string, result CheckoutResult, report func(Progress)) (CheckoutResult, error) {
+	s := stepper{report: report}
+	s.start("reset", "Resetting to "+result.Upstream)
+	if err := cmd.RunSimple(ctx, path, "git", "reset", "--hard", result.RemoteAfter); err != nil {
+		return result, s.fail("reset", err)
+	}
+	result.NeedsReset = false
+	result.Reset = true
+	result.Head = shortHead(ctx, path)
+	s.done("reset", "Reset to "+result.Upstream)
+	return result, nil
+}
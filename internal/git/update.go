@@ -0,0 +1,200 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// UpdateStrategy is how the current branch takes in the default branch
+type UpdateStrategy string
+
+const (
+	UpdateMerge  UpdateStrategy = "merge"  // merge the default branch into the branch
+	UpdateRebase UpdateStrategy = "rebase" // rebase the branch onto the default branch
+)
+
+// updateStrategyKey is the git config key holding a repo's update strategy
+const updateStrategyKey = "gq.updateStrategy"
+
+// GetUpdateStrategy returns the update strategy configured for the repo with
+// git config gq.updateStrategy, UpdateMerge if none is set
+func GetUpdateStrategy(ctx context.Context, path string) (UpdateStrategy, error) {
+	return ParseUpdateStrategy(configValue(ctx, path, updateStrategyKey))
+}
+
+// ParseUpdateStrategy validates an update strategy name
+func ParseUpdateStrategy(name string) (UpdateStrategy, error) {
+	switch s := UpdateStrategy(name); s {
+	case UpdateMerge, UpdateRebase:
+		return s, nil
+	case "":
+		return UpdateMerge, nil
+	}
+	return UpdateMerge, fmt.Errorf("%s: unknown strategy %q (use merge or rebase)", updateStrategyKey, name)
+}
+
+// UpdateResult summarises an update of the current branch from the default branch
+type UpdateResult struct {
+	Branch       string
+	Base         string // what the branch was updated from, e.g. origin/main
+	Strategy     UpdateStrategy
+	Behind       int      // commits of the base the branch didn't have
+	InProgress   bool     // the merge or rebase stopped on conflicts and is left in progress
+	Conflicts    []string // files still conflicted while in progress
+	Updated      bool     // the branch contains the base
+	Remote       string   // remote the branch is pushed to, empty if it has no branch upstream
+	RemoteBranch string
+	Lease        string // full SHA of the remote branch when the update started, empty if it doesn't exist
+}
+
+// UpdateFromBase fetches base from origin and rebases the current branch onto
+// it or merges it in, as strategy says. Uncommitted changes are stashed
+// around the update by git. On conflicts the merge or rebase is left in
+// progress for the user to resolve or abort, and the conflicted files are
+// returned in the result.
+func UpdateFromBase(ctx context.Context, path, base string, strategy UpdateStrategy, report func(Progress)) (UpdateResult, error) {
+	s := stepper{report: report}
+	result := UpdateResult{Base: "origin/" + base, Strategy: strategy}
+
+	branch, err := GetCurrentBranch(ctx, path)
+	if err != nil {
+		return result, s.fail("update", err)
+	}
+	if branch == "HEAD" || branch == base {
+		return result, s.fail("update", fmt.Errorf("check out a branch other than %s to update it", base))
+	}
+	result.Branch = branch
+	if remote, remoteBranch, ok := branchUpstream(ctx, path, branch); ok && revParse(ctx, path, "refs/heads/"+branch) != "" {
+		result.Remote, result.RemoteBranch = remote, remoteBranch
+		result.Lease = revParse(ctx, path, "refs/remotes/"+remote+"/"+remoteBranch)
+	}
+
+	if err := fetchRefs(ctx, path, s, "origin", result.Base, trackingRefspec(base)); err != nil {
+		return result, err
+	}
+	target := "refs/remotes/" + result.Base
+	result.Behind = countCommits(ctx, path, "HEAD", target)
+	if result.Behind == 0 {
+		result.Updated = true
+		s.done("update", "Already up to date with "+result.Base)
+		return result, nil
+	}
+
+	args := []string{"merge", "--autostash", "--no-edit", target}
+	label, doneLabel := "Merging "+result.Base, "Merged "+result.Base
+	if strategy == UpdateRebase {
+		args = []string{"rebase", "--autostash", target}
+		label, doneLabel = "Rebasing onto "+result.Base, "Rebased onto "+result.Base
+	}
+	s.start("update", label)
+	if err := cmd.RunSimple(ctx, path, "git", args...); err != nil {
+		conflicts, cerr := ConflictedFiles(ctx, path)
+		if cerr != nil || len(conflicts) == 0 {
+			return result, s.fail("update", err)
+		}
+		result.InProgress = true
+		result.Conflicts = conflicts
+		s.send(Progress{Step: "update", State: StepFailed, Label: "Conflicts in " + countLabel(len(conflicts), "file"), Percent: -1})
+		return result, nil
+	}
+	result.Updated = true
+	s.done("update", doneLabel+" ("+countLabel(result.Behind, "new commit")+")")
+	return result, nil
+}
+
+// CheckUpdate looks at an update that stopped on conflicts again, after the
+// user worked on it outside gq: whether it is still in progress and with
+// which conflicts, or else whether it was completed rather than aborted
+func CheckUpdate(ctx context.Context, path string, result UpdateResult) (UpdateResult, error) {
+	state, err := DescribeState(ctx, path)
+	if err != nil {
+		return result, err
+	}
+	result.InProgress = state.Operation == "merge" || state.Operation == "rebase"
+	result.Conflicts = nil
+	if result.InProgress {
+		if result.Conflicts, err = ConflictedFiles(ctx, path); err != nil {
+			return result, err
+		}
+	}
+	result.Updated = !result.InProgress && isAncestor(ctx, path, "refs/remotes/"+result.Base, "HEAD")
+	return result, nil
+}
+
+// ContinueUpdate continues a merge or rebase whose conflicts were resolved
+// and staged. A rebase can stop on conflicts in a later commit again.
+func ContinueUpdate(ctx context.Context, path string, result UpdateResult) (UpdateResult, error) {
+	// Keep git from opening an editor for the commit message
+	args := []string{"-c", "core.editor=true", "rebase", "--continue"}
+	if result.Strategy != UpdateRebase {
+		args = []string{"commit", "--no-edit"}
+	}
+	err := cmd.RunSimple(ctx, path, "git", args...)
+	result, cerr := CheckUpdate(ctx, path, result)
+	if cerr != nil {
+		return result, cerr
+	}
+	if err != nil && !(result.InProgress && len(result.Conflicts) > 0) {
+		return result, err
+	}
+	return result, nil
+}
+
+// ConflictedFiles returns the paths with unresolved conflicts
+func ConflictedFiles(ctx context.Context, path string) ([]string, error) {
+	out, err := cmd.Run(ctx, path, "git", "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// AbortUpdate aborts the merge or rebase in progress, bringing the branch
+// and any autostashed changes back to where they were
+func AbortUpdate(ctx context.Context, path string) error {
+	state, err := DescribeState(ctx, path)
+	if err != nil {
+		return err
+	}
+	switch state.Operation {
+	case "merge", "rebase":
+		return cmd.RunSimple(ctx, path, "git", state.Operation, "--abort")
+	}
+	return fmt.Errorf("no merge or rebase in progress")
+}
+
+// PushWithLease pushes the updated branch to its remote branch, overwriting
+// it only if it is still where it was when the update started
+func PushWithLease(ctx context.Context, path string, result UpdateResult, report func(Progress)) error {
+	s := stepper{report: report}
+	if result.Remote == "" {
+		return s.fail("push", fmt.Errorf("%s has no upstream branch to push to", result.Branch))
+	}
+	ref := "refs/heads/" + result.RemoteBranch
+	label := "Pushing to " + result.Remote + "/" + result.RemoteBranch
+	s.start("push", label)
+	err := s.runWithProgress(ctx, path, "push", label, "push", "--progress",
+		"--force-with-lease="+ref+":"+result.Lease, result.Remote, "HEAD:"+ref)
+	if err != nil {
+		return s.fail("push", err)
+	}
+	s.done("push", "Pushed to "+result.Remote+"/"+result.RemoteBranch)
+	return nil
+}
+
+// countLabel formats a count of noun, e.g. "1 file" or "3 files"
+func countLabel(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,204 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// Worktree is a working tree attached to the repository
+type Worktree struct {
+	Path     string
+	Branch   string // checked out branch, empty when detached
+	Head     string // full SHA of HEAD
+	Main     bool   // the repository's main working tree
+	Prunable bool   // its directory is gone
+}
+
+// ListWorktrees returns the working trees of the repository at path, the
+// main one first
+func ListWorktrees(ctx context.Context, path string) ([]Worktree, error) {
+	out, err := cmd.Run(ctx, path, "git", "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktrees(string(out)), nil
+}
+
+// parseWorktrees parses the output of git worktree list --porcelain
+func parseWorktrees(out string) []Worktree {
+	var worktrees []Worktree
+	for _, block := range strings.Split(strings.TrimSpace(out), "\n\n") {
+		var wt Worktree
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				wt.Path = value
+			case "HEAD":
+				wt.Head = value
+			case "branch":
+				wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "prunable":
+				wt.Prunable = true
+			}
+		}
+		if wt.Path == "" {
+			continue
+		}
+		wt.Main = len(worktrees) == 0
+		worktrees = append(worktrees, wt)
+	}
+	return worktrees
+}
+
+// DefaultWorktreeDir returns where MR worktrees go when no directory is
+// configured: a "<repo>.worktrees" directory next to the repository
+func DefaultWorktreeDir(repoPath string) string {
+	repoPath = filepath.Clean(repoPath)
+	return filepath.Join(filepath.Dir(repoPath), filepath.Base(repoPath)+".worktrees")
+}
+
+// WorktreePath returns the worktree directory for MR number under dir
+func WorktreePath(dir string, number int) string {
+	return filepath.Join(dir, fmt.Sprintf("mr-%d", number))
+}
+
+// worktreeName matches the directory names WorktreePath creates
+var worktreeName = regexp.MustCompile(`^mr-(\d+)$`)
+
+// WorktreeMR returns the MR number a worktree was created for, or 0 if it
+// isn't one of gq's
+func WorktreeMR(wt Worktree) int {
+	m := worktreeName.FindStringSubmatch(filepath.Base(wt.Path))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// CheckoutWorktree checks out an MR head in its own worktree under dir, so
+// the main working tree is left alone. An existing worktree for the MR, or
+// another linked worktree that already has its branch checked out, is
+// reused and updated as strategy says, fast-forwarding for a merge as
+// CheckoutMRHead does. A branch checked out in the main working tree is
+// refused rather than updated there.
+// Same-repo MRs pass refs/heads/<branch> as the head ref.
+func CheckoutWorktree(ctx context.Context, path, dir string, head MRHead, opts CheckoutOptions, report func(Progress)) (CheckoutResult, error) {
+	s := stepper{report: report}
+	result := CheckoutResult{}
+
+	name, existing, err := pickLocalBranch(ctx, path, LocalBranchName(head), head.Ref)
+	if err != nil {
+		return result, s.fail("worktree", err)
+	}
+	result.Branch = name
+	strategy := opts.Strategy
+	if strategy == PullMerge {
+		strategy = PullFFOnly
+	}
+	var remoteBefore string
+	if existing {
+		remoteBefore = revParse(ctx, path, "refs/heads/"+name)
+	}
+
+	// Fetch, resolving FETCH_HEAD right away since every worktree has its own
+	fetched, err := fetchMRHead(ctx, path, s, head, opts.BaseBranch, &result)
+	if err != nil {
+		return result, err
+	}
+	if fetched == "" {
+		return result, s.fail("fetch", fmt.Errorf("nothing fetched for %s", head.Ref))
+	}
+
+	// Forget worktrees whose directories were deleted so their paths can be reused
+	if err := cmd.RunSimple(ctx, path, "git", "worktree", "prune"); err != nil {
+		return result, s.fail("worktree", err)
+	}
+	worktrees, err := ListWorktrees(ctx, path)
+	if err != nil {
+		return result, s.fail("worktree", err)
+	}
+	wtPath := WorktreePath(dir, head.Number)
+	reuse := ""
+	for _, wt := range worktrees {
+		if samePath(wt.Path, wtPath) {
+			reuse = wt.Path
+			break
+		}
+	}
+	for _, wt := range worktrees {
+		if reuse != "" || !existing || wt.Branch != name {
+			continue
+		}
+		if wt.Main {
+			// Checking the MR out there is what a worktree is meant to avoid
+			return result, s.fail("worktree", fmt.Errorf("%s is checked out in the main working tree, switch it to another branch first", name))
+		}
+		reuse = wt.Path
+	}
+
+	if reuse != "" {
+		result.Worktree = reuse
+		result.PreviousHead = shortHead(ctx, reuse)
+		s.done("worktree", "Reusing "+reuse)
+	} else {
+		result.Worktree = wtPath
+		s.start("worktree", "Creating worktree "+wtPath)
+		args := []string{"worktree", "add", wtPath, name}
+		if !existing {
+			args = []string{"worktree", "add", "-b", name, wtPath, fetched}
+		}
+		if err := cmd.RunSimple(ctx, path, "git", args...); err != nil {
+			return result, s.fail("worktree", err)
+		}
+		s.done("worktree", "Created worktree "+wtPath)
+		result.PreviousHead = shortHead(ctx, wtPath)
+	}
+
+	if !existing {
+		// Track the MR ref so "git pull" works in the worktree
+		s.start("track", "Setting upstream to "+head.Ref)
+		if err := cmd.RunSimple(ctx, path, "git", "config", "branch."+name+".remote", "origin"); err != nil {
+			return result, s.fail("track", err)
+		}
+		if err := cmd.RunSimple(ctx, path, "git", "config", "branch."+name+".merge", head.Ref); err != nil {
+			return result, s.fail("track", err)
+		}
+		s.done("track", "Tracking "+head.Ref)
+	}
+
+	noteRemote(ctx, path, &result, remoteBefore, fetched)
+	if err := updateBranch(ctx, result.Worktree, s, strategy, fetched, head.Ref, &result); err != nil {
+		return result, err
+	}
+	result.Head = shortHead(ctx, result.Worktree)
+	return result, nil
+}
+
+// samePath reports whether a and b name the same directory. git reports
+// worktree paths with symlinks resolved.
+func samePath(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// RemoveWorktree removes a worktree and prunes its administrative files.
+// Worktrees with uncommitted changes are refused by git and kept.
+func RemoveWorktree(ctx context.Context, path, wtPath string) error {
+	if err := cmd.RunSimple(ctx, path, "git", "worktree", "remove", wtPath); err != nil {
+		return err
+	}
+	return cmd.RunSimple(ctx, path, "git", "worktree", "prune")
+}
@@ -0,0 +1,218 @@
+package highlight
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a piece of source text
+type TokenKind int
+
+const (
+	Plain TokenKind = iota
+	Keyword
+	Type
+	String
+	Number
+	Comment
+	Function
+)
+
+// Token is a run of text of a single kind
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// Language describes the lexical rules needed to highlight a language
+type Language struct {
+	Name          string
+	LineComments  []string    // e.g. "//", "#"
+	BlockComments [][2]string // start/end pairs, e.g. {"/*", "*/"}
+	Quotes        string      // single-line string delimiters, e.g. `"'`
+	MultiStrings  [][2]string // strings that may span lines, e.g. {"`", "`"}
+	Keywords      map[string]bool
+	Types         map[string]bool
+}
+
+// State carries multi-line constructs (block comments, raw strings) from one
+// line to the next. The zero value is the start of a file.
+type State struct {
+	end  string    // closing delimiter of the open construct, "" if none
+	kind TokenKind // Comment or String
+}
+
+// Detect returns the language for a file path based on its extension
+// or well-known file name, or nil if unknown
+func Detect(path string) *Language {
+	base := strings.ToLower(filepath.Base(path))
+	if lang, ok := byName[base]; ok {
+		return lang
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	return byExt[ext]
+}
+
+// Line splits a line of source into tokens, updating state for the next line.
+// A nil language yields a single plain token.
+func Line(lang *Language, text string, state *State) []Token {
+	if lang == nil || text == "" {
+		if text == "" {
+			return nil
+		}
+		return []Token{{Kind: Plain, Text: text}}
+	}
+	if state == nil {
+		state = &State{}
+	}
+
+	var tokens []Token
+	emit := func(kind TokenKind, s string) {
+		if s == "" {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind {
+			tokens[n-1].Text += s
+			return
+		}
+		tokens = append(tokens, Token{Kind: kind, Text: s})
+	}
+
+	i := 0
+	// Continue a construct opened on a previous line
+	if state.end != "" {
+		end := strings.Index(text, state.end)
+		if end < 0 {
+			emit(state.kind, text)
+			return tokens
+		}
+		i = end + len(state.end)
+		emit(state.kind, text[:i])
+		state.end = ""
+	}
+
+	for i < len(text) {
+		rest := text[i:]
+
+		if prefix := matchAny(rest, lang.LineComments); prefix != "" {
+			emit(Comment, rest)
+			break
+		}
+		if pair, ok := matchPair(rest, lang.BlockComments); ok {
+			if n := consumeUntil(rest, pair, state, Comment); n > 0 {
+				emit(Comment, rest[:n])
+				i += n
+				continue
+			}
+		}
+		if pair, ok := matchPair(rest, lang.MultiStrings); ok {
+			if n := consumeUntil(rest, pair, state, String); n > 0 {
+				emit(String, rest[:n])
+				i += n
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case strings.ContainsRune(lang.Quotes, r):
+			n := scanString(rest, r)
+			emit(String, rest[:n])
+			i += n
+		case unicode.IsDigit(r):
+			n := scanWhile(rest, func(r rune) bool {
+				return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '.' || r == '_'
+			})
+			emit(Number, rest[:n])
+			i += n
+		case isIdentStart(r):
+			n := scanWhile(rest, isIdentPart)
+			word := rest[:n]
+			switch {
+			case lang.Keywords[word]:
+				emit(Keyword, word)
+			case lang.Types[word]:
+				emit(Type, word)
+			case strings.HasPrefix(strings.TrimLeft(rest[n:], " "), "("):
+				emit(Function, word)
+			default:
+				emit(Plain, word)
+			}
+			i += n
+		default:
+			emit(Plain, rest[:size])
+			i += size
+		}
+	}
+	return tokens
+}
+
+// consumeUntil consumes an opening delimiter up to and including its closing
+// delimiter on the same line; if it doesn't close, the rest of the line is
+// consumed and state remembers the open construct
+func consumeUntil(s string, pair [2]string, state *State, kind TokenKind) int {
+	start := len(pair[0])
+	if end := strings.Index(s[start:], pair[1]); end >= 0 {
+		return start + end + len(pair[1])
+	}
+	state.end = pair[1]
+	state.kind = kind
+	return len(s)
+}
+
+// scanString returns the length of a quoted string starting at s[0],
+// honouring backslash escapes; unterminated strings run to end of line
+func scanString(s string, quote rune) int {
+	escaped := false
+	for i, r := range s {
+		if i == 0 {
+			continue
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == quote:
+			return i + utf8.RuneLen(r)
+		}
+	}
+	return len(s)
+}
+
+func scanWhile(s string, ok func(rune) bool) int {
+	for i, r := range s {
+		if !ok(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || r == '@' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchAny(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func matchPair(s string, pairs [][2]string) ([2]string, bool) {
+	for _, p := range pairs {
+		if strings.HasPrefix(s, p[0]) {
+			return p, true
+		}
+	}
+	return [2]string{}, false
+}
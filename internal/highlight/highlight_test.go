@@ -0,0 +1,84 @@
+package highlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"internal/ui/dashboard.go", "go"},
+		{"web/App.TSX", "javascript"},
+		{"scripts/build.sh", "shell"},
+		{"Makefile", "shell"},
+		{".github/workflows/go.yml", "yaml"},
+		{"README.md", ""},
+		{"LICENSE", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			name := ""
+			if lang := Detect(tt.path); lang != nil {
+				name = lang.Name
+			}
+			if name != tt.expected {
+				t.Errorf("got %q, want %q", name, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLine_Go(t *testing.T) {
+	var state State
+	got := Line(Detect("x.go"), `func add(a int) string { return "x\"y" // done`, &state)
+
+	expected := []Token{
+		{Kind: Keyword, Text: "func"},
+		{Kind: Plain, Text: " "},
+		{Kind: Function, Text: "add"},
+		{Kind: Plain, Text: "(a "},
+		{Kind: Type, Text: "int"},
+		{Kind: Plain, Text: ") "},
+		{Kind: Type, Text: "string"},
+		{Kind: Plain, Text: " { "},
+		{Kind: Keyword, Text: "return"},
+		{Kind: Plain, Text: " "},
+		{Kind: String, Text: `"x\"y"`},
+		{Kind: Plain, Text: " "},
+		{Kind: Comment, Text: "// done"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestLine_BlockCommentAcrossLines(t *testing.T) {
+	lang := Detect("x.go")
+	var state State
+
+	first := Line(lang, "x := 1 /* start", &state)
+	if last := first[len(first)-1]; last.Kind != Comment || last.Text != "/* start" {
+		t.Errorf("first line ends with %+v, want open comment", last)
+	}
+
+	second := Line(lang, "still comment */ y := 2", &state)
+	if second[0].Kind != Comment || second[0].Text != "still comment */" {
+		t.Errorf("second line starts with %+v, want closed comment", second[0])
+	}
+	if second[len(second)-1].Kind != Number {
+		t.Errorf("expected highlighting to resume after the comment, got %+v", second)
+	}
+}
+
+func TestLine_UnknownLanguage(t *testing.T) {
+	got := Line(nil, "plain text", nil)
+	expected := []Token{{Kind: Plain, Text: "plain text"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+}
@@ -0,0 +1,203 @@
+package highlight
+
+import "strings"
+
+// words builds a lookup set from a space-separated list
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+var cStyleComments = [][2]string{{"/*", "*/"}}
+
+var (
+	langGo = &Language{
+		Name:          "go",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		MultiStrings:  [][2]string{{"`", "`"}},
+		Keywords: words(`break case chan const continue default defer else fallthrough for func go goto
+			if import interface map package range return select struct switch type var nil true false iota`),
+		Types: words(`bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64
+			rune string uint uint8 uint16 uint32 uint64 uintptr any comparable`),
+	}
+
+	langPython = &Language{
+		Name:         "python",
+		LineComments: []string{"#"},
+		Quotes:       `"'`,
+		MultiStrings: [][2]string{{`"""`, `"""`}, {"'''", "'''"}},
+		Keywords: words(`and as assert async await break class continue def del elif else except finally
+			for from global if import in is lambda nonlocal not or pass raise return try while with yield
+			None True False self match case`),
+		Types: words(`int float str bytes bool list dict set tuple object type`),
+	}
+
+	langJS = &Language{
+		Name:          "javascript",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		MultiStrings:  [][2]string{{"`", "`"}},
+		Keywords: words(`async await break case catch class const continue debugger default delete do else
+			export extends finally for from function if import in instanceof let new of return static super
+			switch this throw try typeof var void while with yield null undefined true false
+			as enum implements interface keyof private protected public readonly type declare namespace`),
+		Types: words(`string number boolean any unknown never object void Array Promise Record Map Set`),
+	}
+
+	langRust = &Language{
+		Name:          "rust",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"`,
+		Keywords: words(`as async await break const continue crate dyn else enum extern false fn for if impl
+			in let loop match mod move mut pub ref return self Self static struct super trait true type
+			unsafe use where while`),
+		Types: words(`i8 i16 i32 i64 i128 isize u8 u16 u32 u64 u128 usize f32 f64 bool char str String
+			Vec Option Result Box`),
+	}
+
+	langJava = &Language{
+		Name:          "java",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		MultiStrings:  [][2]string{{`"""`, `"""`}},
+		Keywords: words(`abstract assert break case catch class const continue default do else enum extends
+			final finally for goto if implements import instanceof interface native new package private
+			protected public return static strictfp super switch synchronized this throw throws transient
+			try volatile while null true false var fun val when object companion data sealed override`),
+		Types: words(`boolean byte char double float int long short void String Integer Long Boolean List Map`),
+	}
+
+	langC = &Language{
+		Name:          "c",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		Keywords: words(`auto break case const continue default do else enum extern for goto if inline
+			register restrict return sizeof static struct switch typedef union volatile while
+			class namespace template typename public private protected virtual override new delete
+			this using nullptr true false NULL`),
+		Types: words(`char double float int long short signed unsigned void bool size_t int8_t int16_t int32_t
+			int64_t uint8_t uint16_t uint32_t uint64_t std string vector`),
+	}
+
+	langCSharp = &Language{
+		Name:          "csharp",
+		LineComments:  []string{"//"},
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		Keywords: words(`abstract as async await base break case catch class const continue default delegate
+			do else enum event explicit extern false finally fixed for foreach goto if implicit in interface
+			internal is lock namespace new null operator out override params private protected public
+			readonly ref return sealed sizeof static struct switch this throw true try typeof using var
+			virtual void volatile while`),
+		Types: words(`bool byte char decimal double float int long object sbyte short string uint ulong ushort`),
+	}
+
+	langRuby = &Language{
+		Name:         "ruby",
+		LineComments: []string{"#"},
+		Quotes:       `"'`,
+		Keywords: words(`alias and begin break case class def defined? do else elsif end ensure false for if
+			in module next nil not or redo rescue retry return self super then true undef unless until when
+			while yield require attr_reader attr_accessor`),
+	}
+
+	langShell = &Language{
+		Name:         "shell",
+		LineComments: []string{"#"},
+		Quotes:       `"'`,
+		Keywords: words(`if then else elif fi case esac for while until do done in function return local
+			export readonly set unset exit echo source`),
+	}
+
+	langYAML = &Language{
+		Name:         "yaml",
+		LineComments: []string{"#"},
+		Quotes:       `"'`,
+		Keywords:     words(`true false null yes no on off`),
+	}
+
+	langJSON = &Language{
+		Name:     "json",
+		Quotes:   `"`,
+		Keywords: words(`true false null`),
+	}
+
+	langTOML = &Language{
+		Name:         "toml",
+		LineComments: []string{"#"},
+		Quotes:       `"'`,
+		MultiStrings: [][2]string{{`"""`, `"""`}, {"'''", "'''"}},
+		Keywords:     words(`true false`),
+	}
+
+	langSQL = &Language{
+		Name:          "sql",
+		LineComments:  []string{"--"},
+		BlockComments: cStyleComments,
+		Quotes:        `'"`,
+		Keywords: words(`select from where insert into values update set delete create table alter drop index
+			join left right inner outer on and or not null is in as group by order having limit offset union
+			primary key foreign references default distinct case when then else end
+			SELECT FROM WHERE INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE ALTER DROP INDEX JOIN LEFT
+			RIGHT INNER OUTER ON AND OR NOT NULL IS IN AS GROUP BY ORDER HAVING LIMIT OFFSET UNION PRIMARY
+			KEY FOREIGN REFERENCES DEFAULT DISTINCT CASE WHEN THEN ELSE END`),
+		Types: words(`int integer bigint text varchar boolean timestamp date numeric serial
+			INT INTEGER BIGINT TEXT VARCHAR BOOLEAN TIMESTAMP DATE NUMERIC SERIAL`),
+	}
+
+	langCSS = &Language{
+		Name:          "css",
+		BlockComments: cStyleComments,
+		Quotes:        `"'`,
+		Keywords:      words(`important inherit initial none auto`),
+	}
+)
+
+var byExt = map[string]*Language{
+	"go":   langGo,
+	"py":   langPython,
+	"js":   langJS,
+	"jsx":  langJS,
+	"mjs":  langJS,
+	"cjs":  langJS,
+	"ts":   langJS,
+	"tsx":  langJS,
+	"rs":   langRust,
+	"java": langJava,
+	"kt":   langJava,
+	"kts":  langJava,
+	"c":    langC,
+	"h":    langC,
+	"cc":   langC,
+	"cpp":  langC,
+	"hpp":  langC,
+	"cs":   langCSharp,
+	"rb":   langRuby,
+	"sh":   langShell,
+	"bash": langShell,
+	"zsh":  langShell,
+	"yml":  langYAML,
+	"yaml": langYAML,
+	"json": langJSON,
+	"toml": langTOML,
+	"sql":  langSQL,
+	"css":  langCSS,
+	"scss": langCSS,
+}
+
+var byName = map[string]*Language{
+	"makefile":   langShell,
+	"dockerfile": langShell,
+	"gemfile":    langRuby,
+	"rakefile":   langRuby,
+	"go.mod":     langGo,
+}
@@ -0,0 +1,343 @@
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Action is something a key does, named "<context>.<name>", e.g.
+// "dashboard.refresh". Keys only have to be unique within a context.
+type Action string
+
+// Context returns the part of the screen the action belongs to
+func (a Action) Context() string {
+	ctx, _, _ := strings.Cut(string(a), ".")
+	return ctx
+}
+
+// Name returns the action's name within its context
+func (a Action) Name() string {
+	_, name, _ := strings.Cut(string(a), ".")
+	return name
+}
+
+// Contexts, in the order the help lists them
+const (
+	Dashboard = "dashboard" // the MR list
+	Detail    = "detail"    // the MR detail modal
+	Picker    = "picker"    // author, history, reviewer, assignee and label pickers
+	Diff      = "diff"      // the diff viewer
+	Comment   = "comment"   // the review comment composer in the diff viewer
+	Checkout  = "checkout"  // the checkout modal
+	Dirty     = "dirty"     // the uncommitted changes prompt before a checkout
+	Updating  = "update"    // the update from the default branch
+	Worktree  = "worktrees" // the MR worktree list
+)
+
+// Contexts lists every context
+var Contexts = []string{Dashboard, Detail, Picker, Diff, Comment, Checkout, Dirty, Updating, Worktree}
+
+const (
+	Quit      Action = "dashboard.quit"
+	Up        Action = "dashboard.up"
+	Down      Action = "dashboard.down"
+	Details   Action = "dashboard.details"
+	Open      Action = "dashboard.open"
+	Ticket    Action = "dashboard.ticket"
+	Find      Action = "dashboard.find"
+	Refresh   Action = "dashboard.refresh"
+	Author    Action = "dashboard.author"
+	Main      Action = "dashboard.main"
+	Update    Action = "dashboard.update"
+	Back      Action = "dashboard.back"
+	History   Action = "dashboard.history"
+	Worktrees Action = "dashboard.worktrees"
+	NextTab   Action = "dashboard.next_tab"
+	Help      Action = "dashboard.help"
+
+	DetailUp          Action = "detail.up"
+	DetailDown        Action = "detail.down"
+	DetailCheckout    Action = "detail.checkout"
+	DetailWorktree    Action = "detail.worktree"
+	DetailDiff        Action = "detail.diff"
+	DetailDescription Action = "detail.description"
+	DetailCommits     Action = "detail.commits"
+	DetailReviewers   Action = "detail.reviewers"
+	DetailAssignees   Action = "detail.assignees"
+	DetailLabels      Action = "detail.labels"
+	DetailClose       Action = "detail.close"
+	DetailHelp        Action = "detail.help"
+
+	PickerUp     Action = "picker.up"
+	PickerDown   Action = "picker.down"
+	PickerToggle Action = "picker.toggle"
+	PickerFind   Action = "picker.find"
+	PickerSelect Action = "picker.select"
+	PickerClose  Action = "picker.close"
+
+	DiffUp       Action = "diff.up"
+	DiffDown     Action = "diff.down"
+	DiffPageUp   Action = "diff.page_up"
+	DiffPageDown Action = "diff.page_down"
+	DiffTop      Action = "diff.top"
+	DiffBottom   Action = "diff.bottom"
+	DiffNextHunk Action = "diff.next_hunk"
+	DiffPrevHunk Action = "diff.prev_hunk"
+	DiffNextFile Action = "diff.next_file"
+	DiffPrevFile Action = "diff.prev_file"
+	DiffOldSide  Action = "diff.old_side"
+	DiffNewSide  Action = "diff.new_side"
+	DiffSplit    Action = "diff.split"
+	DiffWrap     Action = "diff.wrap"
+	DiffFullFile Action = "diff.full_file"
+	DiffSelect   Action = "diff.select"
+	DiffComment  Action = "diff.comment"
+	DiffDiscard  Action = "diff.discard"
+	DiffSubmit   Action = "diff.submit"
+	DiffClose    Action = "diff.close"
+
+	CommentSave   Action = "comment.save"
+	CommentCancel Action = "comment.cancel"
+
+	CheckoutReset  Action = "checkout.reset"
+	CheckoutKeep   Action = "checkout.keep"
+	CheckoutShell  Action = "checkout.shell"
+	CheckoutEditor Action = "checkout.editor"
+	CheckoutCancel Action = "checkout.cancel"
+
+	DirtyStash  Action = "dirty.stash"
+	DirtyCarry  Action = "dirty.carry"
+	DirtyDiff   Action = "dirty.diff"
+	DirtyCancel Action = "dirty.cancel"
+
+	UpdateContinue Action = "update.continue"
+	UpdateShell    Action = "update.shell"
+	UpdateAbort    Action = "update.abort"
+	UpdatePush     Action = "update.push"
+	UpdateCancel   Action = "update.cancel"
+
+	WorktreeUp     Action = "worktrees.up"
+	WorktreeDown   Action = "worktrees.down"
+	WorktreeShell  Action = "worktrees.shell"
+	WorktreeEditor Action = "worktrees.editor"
+	WorktreeRemove Action = "worktrees.remove"
+	WorktreeClean  Action = "worktrees.clean"
+	WorktreeClose  Action = "worktrees.close"
+)
+
+// Binding is the keys an action is bound to
+type Binding struct {
+	Action Action
+	Keys   []string
+	Help   string
+	Footer bool // listed in the context's footer, not only in the help
+}
+
+// defaults are the built-in bindings, in the order the footers and the help
+// list them
+var defaults = []Binding{
+	{Up, []string{"up", "k"}, "move up", false},
+	{Down, []string{"down", "j"}, "move down", false},
+	{Details, []string{"enter"}, "details", true},
+	{Open, []string{"w"}, "open", true},
+	{Ticket, []string{"t"}, "ticket", true},
+	{Find, []string{"f", "/"}, "find", true},
+	{Refresh, []string{"r", "R"}, "refresh", true},
+	{Author, []string{"a"}, "author", true},
+	{Main, []string{"m"}, "main", true},
+	{Update, []string{"U"}, "update", true},
+	{Back, []string{"b"}, "back", true},
+	{History, []string{"H"}, "history", true},
+	{Worktrees, []string{"W"}, "worktrees", true},
+	{NextTab, []string{"tab"}, "next tab", false},
+	{Help, []string{"?"}, "help", true},
+	{Quit, []string{"q"}, "quit", true},
+
+	{DetailUp, []string{"up", "k"}, "scroll up", false},
+	{DetailDown, []string{"down", "j"}, "scroll down", false},
+	{DetailDiff, []string{"v"}, "diff", true},
+	{DetailDescription, []string{"d", "D"}, "description", true},
+	{DetailCommits, []string{"c", "C"}, "commits", true},
+	{DetailReviewers, []string{"r"}, "reviewers", true},
+	{DetailAssignees, []string{"a"}, "assignees", true},
+	{DetailLabels, []string{"l"}, "labels", true},
+	{DetailCheckout, []string{"enter"}, "check out", true},
+	{DetailWorktree, []string{"W"}, "worktree", true},
+	{DetailHelp, []string{"?"}, "help", true},
+	{DetailClose, []string{"esc"}, "close", true},
+
+	{PickerUp, []string{"up", "k"}, "move up", false},
+	{PickerDown, []string{"down", "j"}, "move down", false},
+	{PickerToggle, []string{"space"}, "toggle", true},
+	{PickerFind, []string{"f", "/"}, "find", true},
+	{PickerSelect, []string{"enter"}, "select", true},
+	{PickerClose, []string{"esc"}, "close", true},
+
+	{DiffUp, []string{"up", "k"}, "move up", false},
+	{DiffDown, []string{"down", "j"}, "move down", false},
+	{DiffPageUp, []string{"pgup", "ctrl+u"}, "half page up", false},
+	{DiffPageDown, []string{"pgdown", "ctrl+d", "space"}, "half page down", false},
+	{DiffTop, []string{"home", "g"}, "top", false},
+	{DiffBottom, []string{"end", "G"}, "bottom", false},
+	{DiffNextHunk, []string{"n"}, "next hunk", true},
+	{DiffPrevHunk, []string{"N", "p"}, "previous hunk", false},
+	{DiffNextFile, []string{"tab", "]"}, "next file", true},
+	{DiffPrevFile, []string{"shift+tab", "["}, "previous file", false},
+	{DiffOldSide, []string{"left", "h"}, "comment on old side", false},
+	{DiffNewSide, []string{"right", "l"}, "comment on new side", false},
+	{DiffSplit, []string{"s"}, "split", true},
+	{DiffWrap, []string{"w"}, "wrap", true},
+	{DiffFullFile, []string{"o"}, "full file", true},
+	{DiffSelect, []string{"V"}, "select", true},
+	{DiffComment, []string{"c"}, "comment", true},
+	{DiffDiscard, []string{"x"}, "discard pending comment", false},
+	{DiffSubmit, []string{"S"}, "submit", true},
+	{DiffClose, []string{"esc"}, "close", true},
+
+	{CommentSave, []string{"ctrl+s"}, "add to review", true},
+	{CommentCancel, []string{"esc"}, "cancel", true},
+
+	{CheckoutReset, []string{"y", "Y"}, "reset", true},
+	{CheckoutKeep, []string{"n", "N"}, "keep the local branch", true},
+	{CheckoutShell, []string{"s"}, "open shell in the worktree", true},
+	{CheckoutEditor, []string{"e"}, "open editor in the worktree", true},
+	{CheckoutCancel, []string{"esc"}, "cancel", true},
+
+	{DirtyStash, []string{"s"}, "stash & checkout", true},
+	{DirtyCarry, []string{"enter", "c", "y", "Y"}, "carry over", true},
+	{DirtyDiff, []string{"d"}, "diff", true},
+	{DirtyCancel, []string{"esc", "n", "N"}, "cancel", true},
+
+	{UpdateContinue, []string{"c"}, "continue", true},
+	{UpdateShell, []string{"s"}, "open shell", true},
+	{UpdateAbort, []string{"a"}, "abort", true},
+	{UpdatePush, []string{"p"}, "force-push with lease", true},
+	{UpdateCancel, []string{"esc"}, "cancel, or leave it in progress", true},
+
+	{WorktreeUp, []string{"up", "k"}, "move up", false},
+	{WorktreeDown, []string{"down", "j"}, "move down", false},
+	{WorktreeShell, []string{"enter", "s"}, "shell", true},
+	{WorktreeEditor, []string{"e"}, "editor", true},
+	{WorktreeRemove, []string{"d"}, "remove", true},
+	{WorktreeClean, []string{"C"}, "clean up merged/closed", true},
+	{WorktreeClose, []string{"esc"}, "close", true},
+}
+
+// reserved keys can't be bound: ctrl+c always quits
+var reserved = []string{"ctrl+c"}
+
+// Keymap is the active bindings: the defaults, with the configured keys of
+// some actions replacing theirs. The zero Keymap is the defaults.
+type Keymap struct {
+	overrides map[Action][]string
+}
+
+// Known reports whether action exists
+func Known(action Action) bool {
+	return slices.ContainsFunc(defaults, func(b Binding) bool { return b.Action == action })
+}
+
+// New returns the keymap with overrides, action -> keys, on top of the
+// defaults. It reports unknown actions, reserved keys and keys bound to two
+// actions of the same context, but always returns the keymap.
+func New(overrides map[string][]string) (Keymap, error) {
+	k := Keymap{overrides: make(map[Action][]string, len(overrides))}
+	var errs []error
+	for name, keys := range overrides {
+		action := Action(name)
+		if !Known(action) {
+			errs = append(errs, fmt.Errorf("keys.%s: unknown action", name))
+			continue
+		}
+		if len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("keys.%s: needs at least one key", name))
+			continue
+		}
+		for _, key := range keys {
+			if slices.Contains(reserved, key) {
+				errs = append(errs, fmt.Errorf("keys.%s: %s always quits and can't be bound", name, key))
+			}
+		}
+		k.overrides[action] = keys
+	}
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return k, errors.Join(append(errs, k.conflicts()...)...)
+}
+
+// conflicts reports keys bound to more than one action of a context
+func (k Keymap) conflicts() []error {
+	var errs []error
+	for _, ctx := range Contexts {
+		bound := make(map[string]Action)
+		for _, b := range k.Bindings(ctx) {
+			for _, key := range b.Keys {
+				if other, ok := bound[key]; ok && other != b.Action {
+					errs = append(errs, fmt.Errorf("keys.%s: %q is bound to both %s and %s", ctx, key, other.Name(), b.Action.Name()))
+					continue
+				}
+				bound[key] = b.Action
+			}
+		}
+	}
+	return errs
+}
+
+// Keys returns the keys bound to action
+func (k Keymap) Keys(action Action) []string {
+	if keys, ok := k.overrides[action]; ok {
+		return keys
+	}
+	for _, b := range defaults {
+		if b.Action == action {
+			return b.Keys
+		}
+	}
+	return nil
+}
+
+// Matches reports whether key, as tea.KeyPressMsg.String() spells it, is
+// bound to action
+func (k Keymap) Matches(key string, action Action) bool {
+	return slices.Contains(k.Keys(action), key)
+}
+
+// Bindings returns the active bindings of a context
+func (k Keymap) Bindings(ctx string) []Binding {
+	var bindings []Binding
+	for _, b := range defaults {
+		if b.Action.Context() != ctx {
+			continue
+		}
+		b.Keys = k.Keys(b.Action)
+		bindings = append(bindings, b)
+	}
+	return bindings
+}
+
+// Footer returns the bindings of a context listed in its footer
+func (k Keymap) Footer(ctx string) []Binding {
+	var bindings []Binding
+	for _, b := range k.Bindings(ctx) {
+		if b.Footer {
+			bindings = append(bindings, b)
+		}
+	}
+	return bindings
+}
+
+// KeyName returns how a key is shown, with arrows for the arrow keys
+func KeyName(key string) string {
+	switch key {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	}
+	return key
+}
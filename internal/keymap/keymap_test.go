@@ -0,0 +1,87 @@
+package keymap
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	k, err := New(nil)
+	if err != nil {
+		t.Fatalf("the defaults conflict: %v", err)
+	}
+	for _, b := range defaults {
+		if !slices.Contains(Contexts, b.Action.Context()) {
+			t.Errorf("%s is in no known context", b.Action)
+		}
+		if len(b.Keys) == 0 || b.Help == "" {
+			t.Errorf("%s needs keys and help", b.Action)
+		}
+	}
+	if !k.Matches("R", Refresh) || k.Matches("x", Refresh) {
+		t.Error("refresh isn't bound to r and R")
+	}
+	// The zero keymap is the defaults
+	var zero Keymap
+	if !reflect.DeepEqual(zero.Keys(DetailCommits), []string{"c", "C"}) {
+		t.Errorf("zero keymap commits = %v", zero.Keys(DetailCommits))
+	}
+}
+
+func TestNew_Overrides(t *testing.T) {
+	k, err := New(map[string][]string{"dashboard.refresh": {"ctrl+r"}, "detail.diff": {"x"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !k.Matches("ctrl+r", Refresh) || k.Matches("r", Refresh) {
+		t.Errorf("refresh = %v, want only ctrl+r", k.Keys(Refresh))
+	}
+	if !k.Matches("x", DetailDiff) {
+		t.Errorf("diff = %v, want x", k.Keys(DetailDiff))
+	}
+
+	// Rebound actions show their new key in the footer
+	var footer []string
+	for _, b := range k.Footer(Detail) {
+		footer = append(footer, b.Keys[0]+" "+b.Help)
+	}
+	if footer[0] != "x diff" {
+		t.Errorf("footer = %v", footer)
+	}
+	for _, b := range k.Footer(Dashboard) {
+		if b.Action == Up || b.Action == NextTab {
+			t.Errorf("%s shouldn't be in the footer", b.Action)
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	// r is refresh's by default, r in the detail modal is a different context
+	k, err := New(map[string][]string{
+		"dashboard.open":  {"r"},
+		"dashboard.nope":  {"n"},
+		"detail.close":    {"ctrl+c"},
+		"picker.select":   {},
+		"detail.checkout": {"r"},
+	})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`keys.dashboard: "r" is bound to both open and refresh`,
+		`keys.detail: "r" is bound to both reviewers and checkout`,
+		"keys.dashboard.nope: unknown action",
+		"keys.detail.close: ctrl+c always quits and can't be bound",
+		"keys.picker.select: needs at least one key",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("errors don't include %q:\n%v", want, err)
+		}
+	}
+	// The keymap still has the valid overrides
+	if !k.Matches("r", Open) {
+		t.Errorf("open = %v", k.Keys(Open))
+	}
+}
@@ -0,0 +1,179 @@
+package platform
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cache"
+)
+
+// TTLs are how long cached results are used without asking the forge again
+type TTLs struct {
+	MRs      time.Duration // MR lists
+	RepoInfo time.Duration
+	Authors  time.Duration
+	Details  time.Duration // MR details
+}
+
+// Cache keys
+const (
+	repoInfoKey = "repo"
+	authorsKey  = "authors"
+	mrsPrefix   = "mrs/"    // + author
+	detailKey   = "detail/" // + MR number
+)
+
+// Cached is a Platform that keeps MR lists, repository info, authors and MR
+// details on disk. Reads younger than their TTL come from disk, older ones
+// go to the forge and refresh the cache; writes drop what they change. The
+// Cached* methods return whatever is on disk, however old, so there's
+// something to show before a load finishes. A nil *Cached caches nothing.
+type Cached struct {
+	Platform
+	store *cache.Store
+	ttl   TTLs
+}
+
+// NewCached caches p's results in store
+func NewCached(p Platform, store *cache.Store, ttl TTLs) *Cached {
+	return &Cached{Platform: p, store: store, ttl: ttl}
+}
+
+// load returns key's cached value if it's younger than ttl, otherwise
+// fetches and caches it
+func load[T any](c *Cached, key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	var v T
+	if saved, ok := c.store.Get(key, &v); ok && time.Since(saved) < ttl {
+		return v, nil
+	}
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	_ = c.store.Put(key, v)
+	return v, nil
+}
+
+// peek returns key's cached value and when it was saved, however old
+func peek[T any](c *Cached, key string) (T, time.Time, bool) {
+	var v T
+	if c == nil {
+		return v, time.Time{}, false
+	}
+	saved, ok := c.store.Get(key, &v)
+	return v, saved, ok
+}
+
+// ListMRs returns the author's MRs, from disk while they are fresh
+func (c *Cached) ListMRs(ctx context.Context, author string) ([]MR, error) {
+	return load(c, mrsPrefix+author, c.ttl.MRs, func() ([]MR, error) {
+		return c.Platform.ListMRs(ctx, author)
+	})
+}
+
+// RefreshMRs returns the author's MRs from the forge however fresh the
+// cached ones are, and caches them
+func (c *Cached) RefreshMRs(ctx context.Context, author string) ([]MR, error) {
+	return load(c, mrsPrefix+author, 0, func() ([]MR, error) {
+		return c.Platform.ListMRs(ctx, author)
+	})
+}
+
+// GetRepoInfo returns repository information, from disk while it is fresh
+func (c *Cached) GetRepoInfo(ctx context.Context) (RepoInfo, error) {
+	return load(c, repoInfoKey, c.ttl.RepoInfo, func() (RepoInfo, error) {
+		return c.Platform.GetRepoInfo(ctx)
+	})
+}
+
+// ListAuthors returns repository contributors, from disk while they are fresh
+func (c *Cached) ListAuthors(ctx context.Context) ([]Author, error) {
+	return load(c, authorsKey, c.ttl.Authors, func() ([]Author, error) {
+		return c.Platform.ListAuthors(ctx)
+	})
+}
+
+// GetMRDetail returns an MR's detail, from disk while it is fresh
+func (c *Cached) GetMRDetail(ctx context.Context, number int) (MRDetail, error) {
+	return load(c, detailKey+strconv.Itoa(number), c.ttl.Details, func() (MRDetail, error) {
+		return c.Platform.GetMRDetail(ctx, number)
+	})
+}
+
+// RefreshMRDetail returns an MR's detail from the forge however fresh the
+// cached one is, and caches it
+func (c *Cached) RefreshMRDetail(ctx context.Context, number int) (MRDetail, error) {
+	return load(c, detailKey+strconv.Itoa(number), 0, func() (MRDetail, error) {
+		return c.Platform.GetMRDetail(ctx, number)
+	})
+}
+
+// EditReviewers edits the reviewers and drops the MR's cached data
+func (c *Cached) EditReviewers(ctx context.Context, number int, add, remove []string) error {
+	defer c.InvalidateMR(number)
+	return c.Platform.EditReviewers(ctx, number, add, remove)
+}
+
+// EditAssignees edits the assignees and drops the MR's cached data
+func (c *Cached) EditAssignees(ctx context.Context, number int, add, remove []string) error {
+	defer c.InvalidateMR(number)
+	return c.Platform.EditAssignees(ctx, number, add, remove)
+}
+
+// EditLabels edits the labels and drops the MR's cached data
+func (c *Cached) EditLabels(ctx context.Context, number int, add, remove []string) error {
+	defer c.InvalidateMR(number)
+	return c.Platform.EditLabels(ctx, number, add, remove)
+}
+
+// SubmitReview publishes the comments and drops the MR's cached data
+func (c *Cached) SubmitReview(ctx context.Context, number int, comments []ReviewComment) error {
+	defer c.InvalidateMR(number)
+	return c.Platform.SubmitReview(ctx, number, comments)
+}
+
+// CachedMRs returns the cached MRs of author and when they were cached
+func (c *Cached) CachedMRs(author string) ([]MR, time.Time, bool) {
+	return peek[[]MR](c, mrsPrefix+author)
+}
+
+// CachedRepoInfo returns the cached repository info
+func (c *Cached) CachedRepoInfo() (RepoInfo, bool) {
+	info, _, ok := peek[RepoInfo](c, repoInfoKey)
+	return info, ok
+}
+
+// CachedAuthors returns the cached authors
+func (c *Cached) CachedAuthors() ([]Author, bool) {
+	authors, _, ok := peek[[]Author](c, authorsKey)
+	return authors, ok
+}
+
+// CachedMRDetail returns the cached detail of an MR
+func (c *Cached) CachedMRDetail(number int) (MRDetail, bool) {
+	detail, _, ok := peek[MRDetail](c, detailKey+strconv.Itoa(number))
+	return detail, ok
+}
+
+// InvalidateMRs drops every cached MR list, so the next one comes from the forge
+func (c *Cached) InvalidateMRs() {
+	if c != nil {
+		c.store.DeletePrefix(mrsPrefix)
+	}
+}
+
+// InvalidateMR drops an MR's cached detail and the lists it may show in
+func (c *Cached) InvalidateMR(number int) {
+	if c != nil {
+		c.InvalidateMRDetail(number)
+		c.store.DeletePrefix(mrsPrefix)
+	}
+}
+
+// InvalidateMRDetail drops an MR's cached detail, keeping the lists
+func (c *Cached) InvalidateMRDetail(number int) {
+	if c != nil {
+		c.store.Delete(detailKey + strconv.Itoa(number))
+	}
+}
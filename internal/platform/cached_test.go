@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cache"
+)
+
+// countingPlatform counts the calls that reach the forge
+type countingPlatform struct {
+	Platform
+	calls int
+}
+
+func (p *countingPlatform) ListMRs(ctx context.Context, author string) ([]MR, error) {
+	p.calls++
+	return []MR{{Number: p.calls, Title: author}}, nil
+}
+
+func (p *countingPlatform) GetMRDetail(ctx context.Context, number int) (MRDetail, error) {
+	p.calls++
+	return MRDetail{Number: number, Body: "call " + strconv.Itoa(p.calls)}, nil
+}
+
+func (p *countingPlatform) EditLabels(ctx context.Context, number int, add, remove []string) error {
+	return nil
+}
+
+func TestCached_TTL(t *testing.T) {
+	ctx := context.Background()
+	forge := &countingPlatform{}
+	c := NewCached(forge, cache.Open(t.TempDir()), TTLs{MRs: time.Hour})
+
+	if _, _, ok := c.CachedMRs("@me"); ok {
+		t.Fatal("CachedMRs hit before any load")
+	}
+	first, _ := c.ListMRs(ctx, "@me")
+	second, _ := c.ListMRs(ctx, "@me")
+	if forge.calls != 1 || second[0].Number != first[0].Number {
+		t.Errorf("a fresh list went to the forge, %d calls", forge.calls)
+	}
+	if mrs, saved, ok := c.CachedMRs("@me"); !ok || saved.IsZero() || mrs[0].Number != 1 {
+		t.Errorf("CachedMRs = %v, %v, %v", mrs, saved, ok)
+	}
+
+	// A zero TTL makes every cached detail stale
+	c.GetMRDetail(ctx, 7)
+	detail, _ := c.GetMRDetail(ctx, 7)
+	if forge.calls != 3 || detail.Body != "call 3" {
+		t.Errorf("a stale detail came from the cache: %q, %d calls", detail.Body, forge.calls)
+	}
+	if cached, ok := c.CachedMRDetail(7); !ok || cached.Body != "call 3" {
+		t.Errorf("CachedMRDetail = %q, %v, want the latest load", cached.Body, ok)
+	}
+}
+
+func TestCached_RefreshMRDetail(t *testing.T) {
+	ctx := context.Background()
+	forge := &countingPlatform{}
+	c := NewCached(forge, cache.Open(t.TempDir()), TTLs{Details: time.Hour})
+
+	c.GetMRDetail(ctx, 7)
+	detail, _ := c.RefreshMRDetail(ctx, 7)
+	if forge.calls != 2 || detail.Body != "call 2" {
+		t.Errorf("a fresh detail wasn't refreshed: %q, %d calls", detail.Body, forge.calls)
+	}
+	if cached, ok := c.CachedMRDetail(7); !ok || cached.Body != "call 2" {
+		t.Errorf("CachedMRDetail = %q, %v, want the refreshed one", cached.Body, ok)
+	}
+}
+
+func TestCached_RefreshMRs(t *testing.T) {
+	ctx := context.Background()
+	forge := &countingPlatform{}
+	c := NewCached(forge, cache.Open(t.TempDir()), TTLs{MRs: time.Hour})
+
+	c.ListMRs(ctx, "@me")
+	mrs, _ := c.RefreshMRs(ctx, "@me")
+	if forge.calls != 2 || len(mrs) != 1 || mrs[0].Number != 2 {
+		t.Errorf("fresh MRs weren't refreshed: %+v, %d calls", mrs, forge.calls)
+	}
+	if cached, _, ok := c.CachedMRs("@me"); !ok || cached[0].Number != 2 {
+		t.Errorf("CachedMRs = %+v, %v, want the refreshed ones", cached, ok)
+	}
+}
+
+func TestCached_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	c := NewCached(&countingPlatform{}, cache.Open(t.TempDir()), TTLs{MRs: time.Hour, Details: time.Hour})
+	c.ListMRs(ctx, "@me")
+	c.GetMRDetail(ctx, 7)
+	c.GetMRDetail(ctx, 8)
+
+	c.EditLabels(ctx, 7, []string{"bug"}, nil)
+	if _, ok := c.CachedMRDetail(7); ok {
+		t.Error("editing #7 kept its cached detail")
+	}
+	if _, ok := c.CachedMRDetail(8); !ok {
+		t.Error("editing #7 dropped #8's cached detail")
+	}
+	if _, _, ok := c.CachedMRs("@me"); ok {
+		t.Error("editing #7 kept the cached MR lists")
+	}
+
+	c.ListMRs(ctx, "@me")
+	c.InvalidateMRDetail(8)
+	if _, ok := c.CachedMRDetail(8); ok {
+		t.Error("InvalidateMRDetail kept #8's cached detail")
+	}
+	if _, _, ok := c.CachedMRs("@me"); !ok {
+		t.Error("InvalidateMRDetail dropped the cached MR lists")
+	}
+
+	var none *Cached
+	none.InvalidateMRs()
+	none.InvalidateMRDetail(8)
+	if _, ok := none.CachedRepoInfo(); ok {
+		t.Error("a nil cache hit")
+	}
+}
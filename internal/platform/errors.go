@@ -0,0 +1,107 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+// Kinds of gh and glab failures, matched with errors.Is
+var (
+	ErrNotInstalled     = errors.New("CLI not installed")
+	ErrNotAuthenticated = errors.New("not authenticated")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrNetwork          = errors.New("network unreachable")
+	ErrNotFound         = errors.New("not found")
+)
+
+// Error is a failed gh or glab command, classified by what it wrote to stderr
+type Error struct {
+	CLI  string // "gh" or "glab"
+	Kind error  // one of the Err kinds above, nil if it couldn't be classified
+	Err  *cmd.Error
+}
+
+// Error returns the command's own message
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the command error, which carries the exit code and stderr
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error's kind
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// errorPatterns maps lowercased stderr fragments of gh, glab and the git
+// they run to a kind of failure. Earlier kinds win: an unauthenticated
+// request for an MR also reports it as not found.
+var errorPatterns = []struct {
+	kind     error
+	patterns []string
+}{
+	{ErrNotAuthenticated, []string{
+		"gh auth login", "glab auth login", "not logged in", "not logged into",
+		"authentication required", "bad credentials", "http 401", "401 unauthorized",
+		"requires authentication", "token is expired", "invalid token",
+	}},
+	{ErrRateLimited, []string{
+		"rate limit", "http 429", "429 too many requests", "too many requests",
+	}},
+	{ErrNetwork, []string{
+		"could not resolve host", "no such host", "dial tcp", "connection refused",
+		"network is unreachable", "connection reset", "i/o timeout",
+		"tls handshake timeout", "could not connect",
+	}},
+	{ErrNotFound, []string{
+		"could not resolve to a pullrequest", "no pull requests found", "http 404",
+		"404 not found", "not found",
+	}},
+}
+
+// classify turns a failed command of cli into an *Error with its kind.
+// Cancellations and other errors are returned unchanged.
+func classify(cli string, err error) error {
+	var cmdErr *cmd.Error
+	if err == nil || errors.Is(err, context.Canceled) || !errors.As(err, &cmdErr) {
+		return err
+	}
+	classified := &Error{CLI: cli, Err: cmdErr}
+	if errors.Is(cmdErr, cmd.ErrNotInstalled) {
+		classified.Kind = ErrNotInstalled
+		return classified
+	}
+	stderr := strings.ToLower(cmdErr.Stderr)
+	for _, p := range errorPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(stderr, pattern) {
+				classified.Kind = p.kind
+				return classified
+			}
+		}
+	}
+	return classified
+}
+
+// run runs a forge CLI and returns its output, classifying failures
+func run(ctx context.Context, dir, cli string, args ...string) ([]byte, error) {
+	out, err := cmd.Run(ctx, dir, cli, args...)
+	return out, classify(cli, err)
+}
+
+// runSimple runs a forge CLI without capturing output, classifying failures
+func runSimple(ctx context.Context, dir, cli string, args ...string) error {
+	return classify(cli, cmd.RunSimple(ctx, dir, cli, args...))
+}
+
+// runWithInput runs a forge CLI with stdin fed from input, classifying failures
+func runWithInput(ctx context.Context, dir string, input []byte, cli string, args ...string) ([]byte, error) {
+	out, err := cmd.RunWithInput(ctx, dir, input, cli, args...)
+	return out, classify(cli, err)
+}
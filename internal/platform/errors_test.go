@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"To get started with GitHub CLI, please run:  gh auth login", ErrNotAuthenticated},
+		{"HTTP 401: Bad credentials (https://api.github.com/graphql)", ErrNotAuthenticated},
+		{"GraphQL: API rate limit exceeded for user ID 1.", ErrRateLimited},
+		{"error connecting to api.github.com\ndial tcp: lookup api.github.com: no such host", ErrNetwork},
+		{"GraphQL: Could not resolve to a PullRequest with the number of 999.", ErrNotFound},
+		{"404 Not Found", ErrNotFound},
+		{"something else went wrong", nil},
+	}
+	kinds := []error{ErrNotInstalled, ErrNotAuthenticated, ErrRateLimited, ErrNetwork, ErrNotFound}
+	for _, tt := range tests {
+		err := classify("gh", &cmd.Error{Name: "gh", ExitCode: 1, Stderr: tt.stderr, Err: errors.New("exit status 1")})
+		var platformErr *Error
+		if !errors.As(err, &platformErr) || platformErr.CLI != "gh" {
+			t.Fatalf("classify(%q) = %v, want a *platform.Error", tt.stderr, err)
+		}
+		for _, kind := range kinds {
+			if got := errors.Is(err, kind); got != (kind == tt.want) {
+				t.Errorf("classify(%q): errors.Is(%v) = %v", tt.stderr, kind, got)
+			}
+		}
+		var cmdErr *cmd.Error
+		if !errors.As(err, &cmdErr) || cmdErr.ExitCode != 1 {
+			t.Errorf("classify(%q) lost the command error", tt.stderr)
+		}
+	}
+}
+
+func TestClassify_NotInstalled(t *testing.T) {
+	err := classify("glab", &cmd.Error{Name: "glab", ExitCode: -1, Err: &exec.Error{Name: "glab", Err: exec.ErrNotFound}})
+	if !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("got %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestClassify_Passthrough(t *testing.T) {
+	cancelled := fmt.Errorf("gh cancelled: %w", context.Canceled)
+	if got := classify("gh", cancelled); got != cancelled {
+		t.Errorf("a cancellation was changed to %v", got)
+	}
+	if classify("gh", nil) != nil {
+		t.Error("nil should stay nil")
+	}
+}
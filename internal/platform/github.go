@@ -1,11 +1,12 @@
 package platform
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
-
-	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
 )
 
 // GitHub implements Platform for GitHub repositories
@@ -20,11 +21,17 @@ func NewGitHub(repoPath string) *GitHub {
 
 // ghPR represents the JSON structure from gh pr list
 type ghPR struct {
-	Number      int    `json:"number"`
-	Title       string `json:"title"`
-	HeadRefName string `json:"headRefName"`
-	State       string `json:"state"`
-	URL         string `json:"url"`
+	Number              int    `json:"number"`
+	Title               string `json:"title"`
+	HeadRefName         string `json:"headRefName"`
+	HeadRefOid          string `json:"headRefOid"`
+	State               string `json:"state"`
+	IsDraft             bool   `json:"isDraft"`
+	URL                 string `json:"url"`
+	IsCrossRepository   bool   `json:"isCrossRepository"`
+	HeadRepositoryOwner struct {
+		Login string `json:"login"`
+	} `json:"headRepositoryOwner"`
 }
 
 // ghPRDetail represents the JSON structure from gh pr view
@@ -38,6 +45,15 @@ type ghPRDetail struct {
 		Additions int    `json:"additions"`
 		Deletions int    `json:"deletions"`
 	} `json:"files"`
+	ReviewRequests []struct {
+		Login string `json:"login"` // empty for team review requests
+	} `json:"reviewRequests"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
 }
 
 func parseGitHubMRs(data []byte) ([]MR, error) {
@@ -48,17 +64,40 @@ func parseGitHubMRs(data []byte) ([]MR, error) {
 
 	mrs := make([]MR, len(prs))
 	for i, pr := range prs {
-		mrs[i] = MR{
-			Number: pr.Number,
-			Title:  pr.Title,
-			Branch: pr.HeadRefName,
-			Status: strings.ToLower(pr.State),
-			URL:    pr.URL,
-		}
+		mrs[i] = pr.toMR()
 	}
 	return mrs, nil
 }
 
+func parseGitHubMR(data []byte) (MR, error) {
+	var pr ghPR
+	if err := json.Unmarshal(data, &pr); err != nil {
+		return MR{}, err
+	}
+	return pr.toMR(), nil
+}
+
+func (pr ghPR) toMR() MR {
+	status := strings.ToLower(pr.State)
+	if status == "open" && pr.IsDraft {
+		status = "draft"
+	}
+	mr := MR{
+		Number:  pr.Number,
+		Title:   pr.Title,
+		Branch:  pr.HeadRefName,
+		Status:  status,
+		URL:     pr.URL,
+		HeadRef: fmt.Sprintf("refs/pull/%d/head", pr.Number),
+		HeadSHA: pr.HeadRefOid,
+	}
+	if pr.IsCrossRepository {
+		mr.CrossRepo = true
+		mr.HeadOwner = pr.HeadRepositoryOwner.Login
+	}
+	return mr
+}
+
 func parseGitHubMRDetail(number int, data []byte) (MRDetail, error) {
 	var pr ghPRDetail
 	if err := json.Unmarshal(data, &pr); err != nil {
@@ -74,6 +113,19 @@ func parseGitHubMRDetail(number int, data []byte) (MRDetail, error) {
 		}
 	}
 
+	var reviewers, assignees, labels []string
+	for _, r := range pr.ReviewRequests {
+		if r.Login != "" {
+			reviewers = append(reviewers, r.Login)
+		}
+	}
+	for _, a := range pr.Assignees {
+		assignees = append(assignees, a.Login)
+	}
+	for _, l := range pr.Labels {
+		labels = append(labels, l.Name)
+	}
+
 	return MRDetail{
 		Number:    number,
 		Title:     pr.Title,
@@ -81,14 +133,84 @@ func parseGitHubMRDetail(number int, data []byte) (MRDetail, error) {
 		Files:     files,
 		Additions: pr.Additions,
 		Deletions: pr.Deletions,
+		Reviewers: reviewers,
+		Assignees: assignees,
+		Labels:    labels,
 	}, nil
 }
 
+func parseGitHubLabels(data []byte) ([]Label, error) {
+	var ghLabels []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &ghLabels); err != nil {
+		return nil, err
+	}
+
+	labels := make([]Label, len(ghLabels))
+	for i, l := range ghLabels {
+		labels[i] = Label{
+			Name:        l.Name,
+			Description: l.Description,
+		}
+	}
+	return labels, nil
+}
+
 // ListMRs returns pull requests for the given author
-func (g *GitHub) ListMRs(author string) ([]MR, error) {
-	out, err := cmd.Run(g.repoPath, "gh", "pr", "list",
-		"--author", author,
-		"--json", "number,title,headRefName,state,url",
+func (g *GitHub) ListMRs(ctx context.Context, author string) ([]MR, error) {
+	return g.SearchMRs(ctx, MRQuery{Author: author})
+}
+
+// SearchMRs returns pull requests matching q
+func (g *GitHub) SearchMRs(ctx context.Context, q MRQuery) ([]MR, error) {
+	out, err := run(ctx, g.repoPath, "gh", githubListArgs(q)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitHubMRs(out)
+}
+
+// githubListArgs builds the gh pr list arguments for q
+func githubListArgs(q MRQuery) []string {
+	args := []string{"pr", "list"}
+	if q.Author != "" {
+		args = append(args, "--author", q.Author)
+	}
+	switch q.State {
+	case "":
+	case "draft":
+		args = append(args, "--draft")
+	default:
+		args = append(args, "--state", q.State)
+	}
+	for _, label := range q.Labels {
+		args = append(args, "--label", label)
+	}
+	if q.Limit > 0 {
+		args = append(args, "--limit", strconv.Itoa(q.Limit))
+	}
+	return append(args, "--json", "number,title,headRefName,headRefOid,state,isDraft,url,isCrossRepository,headRepositoryOwner")
+}
+
+// GetMR returns a single pull request
+func (g *GitHub) GetMR(ctx context.Context, number int) (MR, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "view",
+		fmt.Sprintf("%d", number),
+		"--json", "number,title,headRefName,headRefOid,state,isDraft,url,isCrossRepository,headRepositoryOwner",
+	)
+	if err != nil {
+		return MR{}, err
+	}
+	return parseGitHubMR(out)
+}
+
+// ListReviewRequested returns open pull requests whose review is requested from you
+func (g *GitHub) ListReviewRequested(ctx context.Context) ([]MR, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "list",
+		"--search", "review-requested:@me",
+		"--json", "number,title,headRefName,headRefOid,state,isDraft,url,isCrossRepository,headRepositoryOwner",
 	)
 	if err != nil {
 		return nil, err
@@ -97,8 +219,8 @@ func (g *GitHub) ListMRs(author string) ([]MR, error) {
 }
 
 // GetRepoInfo returns repository information
-func (g *GitHub) GetRepoInfo() (RepoInfo, error) {
-	out, err := cmd.Run(g.repoPath, "gh", "repo", "view", "--json", "name,description,defaultBranchRef")
+func (g *GitHub) GetRepoInfo(ctx context.Context) (RepoInfo, error) {
+	out, err := run(ctx, g.repoPath, "gh", "repo", "view", "--json", "name,description,defaultBranchRef")
 	if err != nil {
 		return RepoInfo{}, err
 	}
@@ -123,8 +245,8 @@ func (g *GitHub) GetRepoInfo() (RepoInfo, error) {
 }
 
 // ListAuthors returns repository contributors
-func (g *GitHub) ListAuthors() ([]Author, error) {
-	out, err := cmd.Run(g.repoPath, "gh", "api", "repos/{owner}/{repo}/contributors", "--paginate", "-q", ".[].login")
+func (g *GitHub) ListAuthors(ctx context.Context) ([]Author, error) {
+	out, err := run(ctx, g.repoPath, "gh", "api", "repos/{owner}/{repo}/contributors", "--paginate", "-q", ".[].login")
 	if err != nil {
 		return nil, err
 	}
@@ -143,10 +265,10 @@ func (g *GitHub) ListAuthors() ([]Author, error) {
 }
 
 // GetMRDetail returns detailed information about a pull request
-func (g *GitHub) GetMRDetail(number int) (MRDetail, error) {
-	out, err := cmd.Run(g.repoPath, "gh", "pr", "view",
+func (g *GitHub) GetMRDetail(ctx context.Context, number int) (MRDetail, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "view",
 		fmt.Sprintf("%d", number),
-		"--json", "title,body,files,additions,deletions",
+		"--json", "title,body,files,additions,deletions,reviewRequests,assignees,labels",
 	)
 	if err != nil {
 		return MRDetail{}, err
@@ -154,6 +276,204 @@ func (g *GitHub) GetMRDetail(number int) (MRDetail, error) {
 	return parseGitHubMRDetail(number, out)
 }
 
+// ListLabels returns the labels defined in the repository
+func (g *GitHub) ListLabels(ctx context.Context) ([]Label, error) {
+	out, err := run(ctx, g.repoPath, "gh", "label", "list",
+		"--limit", "500",
+		"--json", "name,description",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitHubLabels(out)
+}
+
+// EditReviewers requests and removes reviewers on a pull request
+func (g *GitHub) EditReviewers(ctx context.Context, number int, add, remove []string) error {
+	return g.editPR(ctx, number, "reviewer", add, remove)
+}
+
+// EditAssignees adds and removes assignees on a pull request
+func (g *GitHub) EditAssignees(ctx context.Context, number int, add, remove []string) error {
+	return g.editPR(ctx, number, "assignee", add, remove)
+}
+
+// EditLabels adds and removes labels on a pull request
+func (g *GitHub) EditLabels(ctx context.Context, number int, add, remove []string) error {
+	return g.editPR(ctx, number, "label", add, remove)
+}
+
+// editPR runs gh pr edit with --add-<field>/--remove-<field> flags
+func (g *GitHub) editPR(ctx context.Context, number int, field string, add, remove []string) error {
+	args := ghEditArgs(number, field, add, remove)
+	if args == nil {
+		return nil
+	}
+	return runSimple(ctx, g.repoPath, "gh", args...)
+}
+
+// ghEditArgs builds the gh pr edit arguments, or nil if there is nothing to change
+func ghEditArgs(number int, field string, add, remove []string) []string {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	args := []string{"pr", "edit", fmt.Sprintf("%d", number)}
+	if len(add) > 0 {
+		args = append(args, "--add-"+field, strings.Join(add, ","))
+	}
+	if len(remove) > 0 {
+		args = append(args, "--remove-"+field, strings.Join(remove, ","))
+	}
+	return args
+}
+
+// GetMRDiff returns the unified diff of a pull request
+func (g *GitHub) GetMRDiff(ctx context.Context, number int) (string, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "diff",
+		fmt.Sprintf("%d", number),
+		"--color", "never",
+	)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// GetMRFileContent returns the content of a file at the head commit of a pull request
+func (g *GitHub) GetMRFileContent(ctx context.Context, number int, path string) (string, error) {
+	// The head commit is reachable from the base repo even for forks
+	out, err := run(ctx, g.repoPath, "gh", "pr", "view",
+		fmt.Sprintf("%d", number),
+		"--json", "headRefOid",
+		"-q", ".headRefOid",
+	)
+	if err != nil {
+		return "", err
+	}
+	sha := strings.TrimSpace(string(out))
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	out, err = run(ctx, g.repoPath, "gh", "api",
+		"-H", "Accept: application/vnd.github.raw",
+		fmt.Sprintf("repos/{owner}/{repo}/contents/%s?ref=%s", strings.Join(segments, "/"), sha),
+	)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ghReviewComment represents a pull request review comment from the REST API
+type ghReviewComment struct {
+	Path      string `json:"path"`
+	Line      *int   `json:"line"` // null once the comment is outdated
+	StartLine *int   `json:"start_line"`
+	Side      string `json:"side"`
+	Body      string `json:"body"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+func parseGitHubReviewComments(data []byte) ([]ReviewComment, error) {
+	ghComments, err := unmarshalPages[ghReviewComment](data)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []ReviewComment
+	for _, c := range ghComments {
+		if c.Line == nil {
+			continue
+		}
+		side := SideNew
+		if c.Side == SideOld {
+			side = SideOld
+		}
+		comment := ReviewComment{
+			Path:    c.Path,
+			OldPath: c.Path,
+			Side:    side,
+			End:     ghLineRef(side, *c.Line),
+			Body:    c.Body,
+			Author:  c.User.Login,
+		}
+		if c.StartLine != nil {
+			comment.Start = ghLineRef(side, *c.StartLine)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// ghLineRef builds a LineRef from a GitHub side and line; GitHub only
+// reports the number on the commented side
+func ghLineRef(side string, line int) LineRef {
+	if side == SideOld {
+		return LineRef{Old: line}
+	}
+	return LineRef{New: line}
+}
+
+// ghReviewPayload builds the request body for creating a review with line comments
+func ghReviewPayload(comments []ReviewComment) ([]byte, error) {
+	type ghNewComment struct {
+		Path      string `json:"path"`
+		Body      string `json:"body"`
+		Side      string `json:"side"`
+		Line      int    `json:"line"`
+		StartSide string `json:"start_side,omitempty"`
+		StartLine int    `json:"start_line,omitempty"`
+	}
+	payload := struct {
+		Event    string         `json:"event"`
+		Comments []ghNewComment `json:"comments"`
+	}{Event: "COMMENT"}
+
+	for _, c := range comments {
+		nc := ghNewComment{
+			Path: c.Path,
+			Body: c.Body,
+			Side: c.Side,
+			Line: c.Line(),
+		}
+		if start := c.StartLine(); start != 0 {
+			nc.StartSide = c.Side
+			nc.StartLine = start
+		}
+		payload.Comments = append(payload.Comments, nc)
+	}
+	return json.Marshal(payload)
+}
+
+// ListReviewComments returns the line comments on a pull request
+func (g *GitHub) ListReviewComments(ctx context.Context, number int) ([]ReviewComment, error) {
+	out, err := run(ctx, g.repoPath, "gh", "api",
+		fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/comments?per_page=100", number), "--paginate",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitHubReviewComments(out)
+}
+
+// SubmitReview publishes the comments as a single review on a pull request
+func (g *GitHub) SubmitReview(ctx context.Context, number int, comments []ReviewComment) error {
+	payload, err := ghReviewPayload(comments)
+	if err != nil {
+		return err
+	}
+	_, err = runWithInput(ctx, g.repoPath, payload, "gh", "api",
+		"-X", "POST",
+		fmt.Sprintf("repos/{owner}/{repo}/pulls/%d/reviews", number),
+		"--input", "-",
+	)
+	return err
+}
+
 // ghCommit represents the JSON structure for commits from gh pr view
 type ghCommit struct {
 	OID             string `json:"oid"`
@@ -164,9 +484,36 @@ type ghCommit struct {
 	CommittedDate string `json:"committedDate"`
 }
 
+// GetMRStatus returns the state of a pull request
+func (g *GitHub) GetMRStatus(ctx context.Context, number int) (string, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "view",
+		fmt.Sprintf("%d", number),
+		"--json", "state,isDraft",
+	)
+	if err != nil {
+		return "", err
+	}
+	return parseGitHubMRStatus(out)
+}
+
+func parseGitHubMRStatus(data []byte) (string, error) {
+	var pr struct {
+		State   string `json:"state"`
+		IsDraft bool   `json:"isDraft"`
+	}
+	if err := json.Unmarshal(data, &pr); err != nil {
+		return "", err
+	}
+	status := strings.ToLower(pr.State)
+	if status == "open" && pr.IsDraft {
+		status = "draft"
+	}
+	return status, nil
+}
+
 // GetMRCommits returns commits for a pull request
-func (g *GitHub) GetMRCommits(number int) ([]Commit, error) {
-	out, err := cmd.Run(g.repoPath, "gh", "pr", "view",
+func (g *GitHub) GetMRCommits(ctx context.Context, number int) ([]Commit, error) {
+	out, err := run(ctx, g.repoPath, "gh", "pr", "view",
 		fmt.Sprintf("%d", number),
 		"--json", "commits",
 	)
@@ -8,7 +8,9 @@ import (
 func TestGitHub_ParseMRList(t *testing.T) {
 	jsonOutput := `[
 		{"number": 142, "title": "Fix login timeout", "headRefName": "feature/login", "state": "OPEN", "url": "https://github.com/org/repo/pull/142"},
-		{"number": 138, "title": "Add user preferences", "headRefName": "user-prefs", "state": "DRAFT", "url": "https://github.com/org/repo/pull/138"}
+		{"number": 138, "title": "Add user preferences", "headRefName": "user-prefs", "state": "OPEN", "isDraft": true, "url": "https://github.com/org/repo/pull/138"},
+		{"number": 137, "title": "Fix typo", "headRefName": "main", "state": "OPEN", "url": "https://github.com/org/repo/pull/137",
+		 "isCrossRepository": true, "headRepositoryOwner": {"login": "contributor"}}
 	]`
 
 	mrs, err := parseGitHubMRs([]byte(jsonOutput))
@@ -17,8 +19,9 @@ func TestGitHub_ParseMRList(t *testing.T) {
 	}
 
 	expected := []MR{
-		{Number: 142, Title: "Fix login timeout", Branch: "feature/login", Status: "open", URL: "https://github.com/org/repo/pull/142"},
-		{Number: 138, Title: "Add user preferences", Branch: "user-prefs", Status: "draft", URL: "https://github.com/org/repo/pull/138"},
+		{Number: 142, Title: "Fix login timeout", Branch: "feature/login", Status: "open", URL: "https://github.com/org/repo/pull/142", HeadRef: "refs/pull/142/head"},
+		{Number: 138, Title: "Add user preferences", Branch: "user-prefs", Status: "draft", URL: "https://github.com/org/repo/pull/138", HeadRef: "refs/pull/138/head"},
+		{Number: 137, Title: "Fix typo", Branch: "main", Status: "open", URL: "https://github.com/org/repo/pull/137", HeadRef: "refs/pull/137/head", CrossRepo: true, HeadOwner: "contributor"},
 	}
 
 	if !reflect.DeepEqual(mrs, expected) {
@@ -84,3 +87,162 @@ func TestGitHub_ParseMRDetail_EmptyFiles(t *testing.T) {
 		t.Errorf("Files: got %d files, want 0", len(detail.Files))
 	}
 }
+
+func TestGitHub_ParseMRDetail_ReviewersAssigneesLabels(t *testing.T) {
+	jsonOutput := `{
+		"title": "Add feature Y",
+		"body": "",
+		"additions": 1,
+		"deletions": 0,
+		"files": [],
+		"reviewRequests": [{"login": "alice"}, {"slug": "core-team"}],
+		"assignees": [{"login": "bob"}],
+		"labels": [{"name": "bug"}, {"name": "needs-review"}]
+	}`
+
+	detail, err := parseGitHubMRDetail(7, []byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(detail.Reviewers, []string{"alice"}) {
+		t.Errorf("Reviewers: got %v, want [alice]", detail.Reviewers)
+	}
+	if !reflect.DeepEqual(detail.Assignees, []string{"bob"}) {
+		t.Errorf("Assignees: got %v, want [bob]", detail.Assignees)
+	}
+	if !reflect.DeepEqual(detail.Labels, []string{"bug", "needs-review"}) {
+		t.Errorf("Labels: got %v, want [bug needs-review]", detail.Labels)
+	}
+}
+
+func TestGitHub_ParseLabels(t *testing.T) {
+	jsonOutput := `[
+		{"name": "bug", "description": "Something isn't working"},
+		{"name": "enhancement", "description": ""}
+	]`
+
+	labels, err := parseGitHubLabels([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Label{
+		{Name: "bug", Description: "Something isn't working"},
+		{Name: "enhancement", Description: ""},
+	}
+
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("got %+v, want %+v", labels, expected)
+	}
+}
+
+func TestGhEditArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		add      []string
+		remove   []string
+		expected []string
+	}{
+		{"nothing", nil, nil, nil},
+		{"add only", []string{"alice", "bob"}, nil, []string{"pr", "edit", "5", "--add-reviewer", "alice,bob"}},
+		{"remove only", nil, []string{"carol"}, []string{"pr", "edit", "5", "--remove-reviewer", "carol"}},
+		{"both", []string{"alice"}, []string{"carol"}, []string{"pr", "edit", "5", "--add-reviewer", "alice", "--remove-reviewer", "carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ghEditArgs(5, "reviewer", tt.add, tt.remove)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitHub_ParseReviewComments(t *testing.T) {
+	// Two pages, as gh api --paginate prints them
+	jsonOutput := `[
+		{"path": "main.go", "line": 12, "start_line": null, "side": "RIGHT", "body": "Nit", "user": {"login": "alice"}},
+		{"path": "main.go", "line": 4, "start_line": 2, "side": "LEFT", "body": "Why remove?", "user": {"login": "bob"}}
+	]
+	[
+		{"path": "old.go", "line": null, "start_line": null, "side": "RIGHT", "body": "Outdated", "user": {"login": "carol"}}
+	]`
+
+	comments, err := parseGitHubReviewComments([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ReviewComment{
+		{Path: "main.go", OldPath: "main.go", Side: SideNew, End: LineRef{New: 12}, Body: "Nit", Author: "alice"},
+		{Path: "main.go", OldPath: "main.go", Side: SideOld, Start: LineRef{Old: 2}, End: LineRef{Old: 4}, Body: "Why remove?", Author: "bob"},
+	}
+
+	if !reflect.DeepEqual(comments, expected) {
+		t.Errorf("got %+v, want %+v", comments, expected)
+	}
+}
+
+func TestGhReviewPayload(t *testing.T) {
+	payload, err := ghReviewPayload([]ReviewComment{
+		{Path: "a.go", Side: SideNew, End: LineRef{Old: 3, New: 5}, Body: "single"},
+		{Path: "a.go", Side: SideNew, Start: LineRef{New: 7}, End: LineRef{New: 9}, Body: "range"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"event":"COMMENT","comments":[` +
+		`{"path":"a.go","body":"single","side":"RIGHT","line":5},` +
+		`{"path":"a.go","body":"range","side":"RIGHT","line":9,"start_side":"RIGHT","start_line":7}]}`
+	if string(payload) != expected {
+		t.Errorf("got %s, want %s", payload, expected)
+	}
+}
+
+func TestGitHub_ParseMRStatus(t *testing.T) {
+	cases := map[string]string{
+		`{"state":"OPEN","isDraft":false}`:   "open",
+		`{"state":"OPEN","isDraft":true}`:    "draft",
+		`{"state":"MERGED","isDraft":false}`: "merged",
+	}
+	for input, want := range cases {
+		got, err := parseGitHubMRStatus([]byte(input))
+		if err != nil || got != want {
+			t.Errorf("parseGitHubMRStatus(%s) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+}
+
+func TestGithubListArgs(t *testing.T) {
+	const fields = "number,title,headRefName,headRefOid,state,isDraft,url,isCrossRepository,headRepositoryOwner"
+	tests := []struct {
+		q    MRQuery
+		want []string
+	}{
+		{MRQuery{Author: "@me"}, []string{"pr", "list", "--author", "@me", "--json", fields}},
+		{MRQuery{State: "merged", Labels: []string{"bug", "ui"}, Limit: 5},
+			[]string{"pr", "list", "--state", "merged", "--label", "bug", "--label", "ui", "--limit", "5", "--json", fields}},
+		{MRQuery{Author: "alice", State: "draft"}, []string{"pr", "list", "--author", "alice", "--draft", "--json", fields}},
+	}
+	for _, tt := range tests {
+		if got := githubListArgs(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("githubListArgs(%+v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestGitHub_ParseMR(t *testing.T) {
+	mr, err := parseGitHubMR([]byte(`{"number": 7, "title": "Fix", "headRefName": "fix", "headRefOid": "3f2a9c1", "state": "MERGED",
+		"url": "https://github.com/org/repo/pull/7", "isCrossRepository": true, "headRepositoryOwner": {"login": "bob"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := MR{Number: 7, Title: "Fix", Branch: "fix", Status: "merged", URL: "https://github.com/org/repo/pull/7",
+		HeadRef: "refs/pull/7/head", CrossRepo: true, HeadOwner: "bob", HeadSHA: "3f2a9c1"}
+	if mr != want {
+		t.Errorf("got %+v, want %+v", mr, want)
+	}
+}
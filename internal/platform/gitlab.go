@@ -1,10 +1,13 @@
 package platform
 
 import (
+	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"fmt"
-
-	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // GitLab implements Platform for GitLab repositories
@@ -19,11 +22,18 @@ func NewGitLab(repoPath string) *GitLab {
 
 // glabMR represents the JSON structure from glab mr list
 type glabMR struct {
-	IID          int    `json:"iid"`
-	Title        string `json:"title"`
-	SourceBranch string `json:"source_branch"`
-	State        string `json:"state"`
-	WebURL       string `json:"web_url"`
+	IID             int    `json:"iid"`
+	Title           string `json:"title"`
+	SourceBranch    string `json:"source_branch"`
+	SHA             string `json:"sha"`
+	State           string `json:"state"`
+	Draft           bool   `json:"draft"`
+	WebURL          string `json:"web_url"`
+	SourceProjectID int    `json:"source_project_id"`
+	TargetProjectID int    `json:"target_project_id"`
+	Author          struct {
+		Username string `json:"username"`
+	} `json:"author"`
 }
 
 func parseGitLabMRs(data []byte) ([]MR, error) {
@@ -34,24 +44,92 @@ func parseGitLabMRs(data []byte) ([]MR, error) {
 
 	mrs := make([]MR, len(gMRs))
 	for i, mr := range gMRs {
-		status := mr.State
-		if status == "opened" {
-			status = "open"
-		}
-		mrs[i] = MR{
-			Number: mr.IID,
-			Title:  mr.Title,
-			Branch: mr.SourceBranch,
-			Status: status,
-			URL:    mr.WebURL,
-		}
+		mrs[i] = mr.toMR()
 	}
 	return mrs, nil
 }
 
+func parseGitLabMR(data []byte) (MR, error) {
+	var mr glabMR
+	if err := json.Unmarshal(data, &mr); err != nil {
+		return MR{}, err
+	}
+	return mr.toMR(), nil
+}
+
+func (mr glabMR) toMR() MR {
+	status := glabStatus(mr.State)
+	if status == "open" && mr.Draft {
+		status = "draft"
+	}
+	result := MR{
+		Number:  mr.IID,
+		Title:   mr.Title,
+		Branch:  mr.SourceBranch,
+		Status:  status,
+		URL:     mr.WebURL,
+		HeadRef: fmt.Sprintf("refs/merge-requests/%d/head", mr.IID),
+		HeadSHA: mr.SHA,
+	}
+	if mr.SourceProjectID != mr.TargetProjectID {
+		// The fork's namespace isn't in the list output, the author owns it
+		result.CrossRepo = true
+		result.HeadOwner = mr.Author.Username
+	}
+	return result
+}
+
 // ListMRs returns merge requests for the given author
-func (g *GitLab) ListMRs(author string) ([]MR, error) {
-	out, err := cmd.Run(g.repoPath, "glab", "mr", "list", "-F", "json", "--author", author)
+func (g *GitLab) ListMRs(ctx context.Context, author string) ([]MR, error) {
+	return g.SearchMRs(ctx, MRQuery{Author: author})
+}
+
+// SearchMRs returns merge requests matching q
+func (g *GitLab) SearchMRs(ctx context.Context, q MRQuery) ([]MR, error) {
+	out, err := run(ctx, g.repoPath, "glab", gitlabListArgs(q)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLabMRs(out)
+}
+
+// gitlabListArgs builds the glab mr list arguments for q
+func gitlabListArgs(q MRQuery) []string {
+	args := []string{"mr", "list", "-F", "json"}
+	if q.Author != "" {
+		args = append(args, "--author", q.Author)
+	}
+	switch q.State {
+	case "closed":
+		args = append(args, "--closed")
+	case "merged":
+		args = append(args, "--merged")
+	case "all":
+		args = append(args, "--all")
+	case "draft":
+		args = append(args, "--draft")
+	}
+	if len(q.Labels) > 0 {
+		args = append(args, "--label", strings.Join(q.Labels, ","))
+	}
+	if q.Limit > 0 {
+		args = append(args, "--per-page", strconv.Itoa(q.Limit))
+	}
+	return args
+}
+
+// GetMR returns a single merge request
+func (g *GitLab) GetMR(ctx context.Context, number int) (MR, error) {
+	out, err := run(ctx, g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
+	if err != nil {
+		return MR{}, err
+	}
+	return parseGitLabMR(out)
+}
+
+// ListReviewRequested returns open merge requests you are a reviewer of
+func (g *GitLab) ListReviewRequested(ctx context.Context) ([]MR, error) {
+	out, err := run(ctx, g.repoPath, "glab", "mr", "list", "-F", "json", "--reviewer", "@me")
 	if err != nil {
 		return nil, err
 	}
@@ -59,8 +137,8 @@ func (g *GitLab) ListMRs(author string) ([]MR, error) {
 }
 
 // GetRepoInfo returns repository information
-func (g *GitLab) GetRepoInfo() (RepoInfo, error) {
-	out, err := cmd.Run(g.repoPath, "glab", "repo", "view", "-F", "json")
+func (g *GitLab) GetRepoInfo(ctx context.Context) (RepoInfo, error) {
+	out, err := run(ctx, g.repoPath, "glab", "repo", "view", "-F", "json")
 	if err != nil {
 		return RepoInfo{}, err
 	}
@@ -83,17 +161,21 @@ func (g *GitLab) GetRepoInfo() (RepoInfo, error) {
 }
 
 // ListAuthors returns repository members (uses members API for proper usernames)
-func (g *GitLab) ListAuthors() ([]Author, error) {
-	out, err := cmd.Run(g.repoPath, "glab", "api", "projects/:id/members/all")
+func (g *GitLab) ListAuthors(ctx context.Context) ([]Author, error) {
+	out, err := run(ctx, g.repoPath, "glab", "api", "projects/:id/members/all?per_page=100", "--paginate")
 	if err != nil {
 		return nil, err
 	}
+	return parseGitLabMembers(out)
+}
 
-	var members []struct {
+// parseGitLabMembers parses the pages of project members glab api prints
+func parseGitLabMembers(data []byte) ([]Author, error) {
+	members, err := unmarshalPages[struct {
 		Username string `json:"username"`
 		Name     string `json:"name"`
-	}
-	if err := json.Unmarshal(out, &members); err != nil {
+	}](data)
+	if err != nil {
 		return nil, err
 	}
 
@@ -112,6 +194,13 @@ type glabMRDetail struct {
 	IID         int    `json:"iid"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	Reviewers   []struct {
+		Username string `json:"username"`
+	} `json:"reviewers"`
+	Assignees []struct {
+		Username string `json:"username"`
+	} `json:"assignees"`
+	Labels []string `json:"labels"`
 }
 
 // glabDiffStats represents a file's diff statistics from GitLab API
@@ -166,9 +255,41 @@ type glabCommit struct {
 	CommittedDate string `json:"committed_date"`
 }
 
+// glabStatus maps a GitLab MR state to MR.Status
+func glabStatus(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return state
+}
+
+// GetMRStatus returns the state of a merge request
+func (g *GitLab) GetMRStatus(ctx context.Context, number int) (string, error) {
+	out, err := run(ctx, g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
+	if err != nil {
+		return "", err
+	}
+	return parseGitLabMRStatus(out)
+}
+
+func parseGitLabMRStatus(data []byte) (string, error) {
+	var mr struct {
+		State string `json:"state"`
+		Draft bool   `json:"draft"`
+	}
+	if err := json.Unmarshal(data, &mr); err != nil {
+		return "", err
+	}
+	status := glabStatus(mr.State)
+	if status == "open" && mr.Draft {
+		status = "draft"
+	}
+	return status, nil
+}
+
 // GetMRCommits returns commits for a merge request
-func (g *GitLab) GetMRCommits(number int) ([]Commit, error) {
-	out, err := cmd.Run(g.repoPath, "glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/commits", number))
+func (g *GitLab) GetMRCommits(ctx context.Context, number int) ([]Commit, error) {
+	out, err := run(ctx, g.repoPath, "glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/commits", number))
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +311,216 @@ func (g *GitLab) GetMRCommits(number int) ([]Commit, error) {
 	return commits, nil
 }
 
+// GetMRDiff returns the unified diff of a merge request
+func (g *GitLab) GetMRDiff(ctx context.Context, number int) (string, error) {
+	out, err := run(ctx, g.repoPath, "glab", "mr", "diff", fmt.Sprintf("%d", number), "--raw")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// GetMRFileContent returns the content of a file at the head commit of a merge request
+func (g *GitLab) GetMRFileContent(ctx context.Context, number int, path string) (string, error) {
+	mrOut, err := run(ctx, g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
+	if err != nil {
+		return "", err
+	}
+	var mr struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.Unmarshal(mrOut, &mr); err != nil {
+		return "", err
+	}
+
+	// The files API wants the whole path URL-encoded, including slashes
+	out, err := run(ctx, g.repoPath, "glab", "api",
+		fmt.Sprintf("projects/:id/repository/files/%s/raw?ref=%s", url.PathEscape(path), mr.SHA),
+	)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// glabPosition is the position of a diff note
+type glabPosition struct {
+	OldPath   string `json:"old_path"`
+	NewPath   string `json:"new_path"`
+	OldLine   int    `json:"old_line"` // 0 (null) for added lines
+	NewLine   int    `json:"new_line"` // 0 (null) for removed lines
+	LineRange *struct {
+		Start struct {
+			OldLine int `json:"old_line"`
+			NewLine int `json:"new_line"`
+		} `json:"start"`
+	} `json:"line_range"`
+}
+
+// glabDiscussion represents a merge request discussion thread
+type glabDiscussion struct {
+	Notes []struct {
+		Type     string        `json:"type"` // "DiffNote" for line comments
+		Body     string        `json:"body"`
+		Position *glabPosition `json:"position"`
+		Author   struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	} `json:"notes"`
+}
+
+func parseGitLabReviewComments(data []byte) ([]ReviewComment, error) {
+	discussions, err := unmarshalPages[glabDiscussion](data)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []ReviewComment
+	for _, d := range discussions {
+		for _, n := range d.Notes {
+			if n.Type != "DiffNote" || n.Position == nil {
+				continue
+			}
+			p := n.Position
+			side := SideNew
+			if p.NewLine == 0 {
+				side = SideOld
+			}
+			comment := ReviewComment{
+				Path:    p.NewPath,
+				OldPath: p.OldPath,
+				Side:    side,
+				End:     LineRef{Old: p.OldLine, New: p.NewLine},
+				Body:    n.Body,
+				Author:  n.Author.Username,
+			}
+			if p.LineRange != nil {
+				comment.Start = LineRef{Old: p.LineRange.Start.OldLine, New: p.LineRange.Start.NewLine}
+			}
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// glabDiffRefs are the commits a diff note position is relative to
+type glabDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// glabDraftNotePayload builds the request body for a draft note anchored to lines
+func glabDraftNotePayload(refs glabDiffRefs, c ReviewComment) ([]byte, error) {
+	type linePoint struct {
+		LineCode string `json:"line_code"`
+		Type     string `json:"type,omitempty"`
+		OldLine  int    `json:"old_line,omitempty"`
+		NewLine  int    `json:"new_line,omitempty"`
+	}
+	type position struct {
+		PositionType string `json:"position_type"`
+		BaseSHA      string `json:"base_sha"`
+		HeadSHA      string `json:"head_sha"`
+		StartSHA     string `json:"start_sha"`
+		OldPath      string `json:"old_path"`
+		NewPath      string `json:"new_path"`
+		OldLine      int    `json:"old_line,omitempty"`
+		NewLine      int    `json:"new_line,omitempty"`
+		LineRange    *struct {
+			Start linePoint `json:"start"`
+			End   linePoint `json:"end"`
+		} `json:"line_range,omitempty"`
+	}
+
+	oldPath := c.OldPath
+	if oldPath == "" {
+		oldPath = c.Path
+	}
+	pos := position{
+		PositionType: "text",
+		BaseSHA:      refs.BaseSHA,
+		HeadSHA:      refs.HeadSHA,
+		StartSHA:     refs.StartSHA,
+		OldPath:      oldPath,
+		NewPath:      c.Path,
+		OldLine:      c.End.Old,
+		NewLine:      c.End.New,
+	}
+
+	// Unchanged lines carry both numbers, added and removed lines just one
+	point := func(r LineRef) linePoint {
+		lp := linePoint{
+			LineCode: fmt.Sprintf("%x_%d_%d", sha1.Sum([]byte(c.Path)), r.Old, r.New),
+			OldLine:  r.Old,
+			NewLine:  r.New,
+		}
+		switch {
+		case r.Old == 0:
+			lp.Type = "new"
+		case r.New == 0:
+			lp.Type = "old"
+		}
+		return lp
+	}
+	if c.StartLine() != 0 {
+		pos.LineRange = &struct {
+			Start linePoint `json:"start"`
+			End   linePoint `json:"end"`
+		}{Start: point(c.Start), End: point(c.End)}
+	}
+
+	return json.Marshal(struct {
+		Note     string   `json:"note"`
+		Position position `json:"position"`
+	}{Note: c.Body, Position: pos})
+}
+
+// ListReviewComments returns the line comments on a merge request
+func (g *GitLab) ListReviewComments(ctx context.Context, number int) ([]ReviewComment, error) {
+	out, err := run(ctx, g.repoPath, "glab", "api",
+		fmt.Sprintf("projects/:id/merge_requests/%d/discussions?per_page=100", number), "--paginate",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLabReviewComments(out)
+}
+
+// SubmitReview publishes the comments together: each becomes a draft note,
+// then all drafts are published at once
+func (g *GitLab) SubmitReview(ctx context.Context, number int, comments []ReviewComment) error {
+	mrOut, err := run(ctx, g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
+	if err != nil {
+		return err
+	}
+	var mr struct {
+		DiffRefs glabDiffRefs `json:"diff_refs"`
+	}
+	if err := json.Unmarshal(mrOut, &mr); err != nil {
+		return err
+	}
+
+	draftsPath := fmt.Sprintf("projects/:id/merge_requests/%d/draft_notes", number)
+	for _, c := range comments {
+		payload, err := glabDraftNotePayload(mr.DiffRefs, c)
+		if err != nil {
+			return err
+		}
+		_, err = runWithInput(ctx, g.repoPath, payload, "glab", "api",
+			"-X", "POST",
+			"-H", "Content-Type: application/json",
+			draftsPath,
+			"--input", "-",
+		)
+		if err != nil {
+			return fmt.Errorf("creating draft note on %s:%d: %w", c.Path, c.Line(), err)
+		}
+	}
+
+	return runSimple(ctx, g.repoPath, "glab", "api", "-X", "POST", draftsPath+"/bulk_publish")
+}
+
 // formatGitLabDate formats a GitLab date string to a shorter format
 func formatGitLabDate(date string) string {
 	// Input: "2024-01-15T10:30:00.000+00:00", Output: "2024-01-15"
@@ -200,9 +531,9 @@ func formatGitLabDate(date string) string {
 }
 
 // GetMRDetail returns detailed information about a merge request
-func (g *GitLab) GetMRDetail(number int) (MRDetail, error) {
+func (g *GitLab) GetMRDetail(ctx context.Context, number int) (MRDetail, error) {
 	// Get basic MR info using glab mr view
-	mrOut, err := cmd.Run(g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
+	mrOut, err := run(ctx, g.repoPath, "glab", "mr", "view", fmt.Sprintf("%d", number), "-F", "json")
 	if err != nil {
 		return MRDetail{}, err
 	}
@@ -216,11 +547,18 @@ func (g *GitLab) GetMRDetail(number int) (MRDetail, error) {
 		Number: detail.IID,
 		Title:  detail.Title,
 		Body:   detail.Description,
+		Labels: detail.Labels,
+	}
+	for _, r := range detail.Reviewers {
+		result.Reviewers = append(result.Reviewers, r.Username)
+	}
+	for _, a := range detail.Assignees {
+		result.Assignees = append(result.Assignees, a.Username)
 	}
 
 	// Get diff stats using GitLab API
 	// The endpoint /projects/:id/merge_requests/:iid/changes returns file-level changes
-	diffOut, err := cmd.Run(g.repoPath, "glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/changes", number))
+	diffOut, err := run(ctx, g.repoPath, "glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/changes", number))
 	if err != nil {
 		// If we can't get diff stats, return what we have
 		return result, nil
@@ -254,3 +592,78 @@ func (g *GitLab) GetMRDetail(number int) (MRDetail, error) {
 
 	return result, nil
 }
+
+func parseGitLabLabels(data []byte) ([]Label, error) {
+	glabLabels, err := unmarshalPages[struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}](data)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]Label, len(glabLabels))
+	for i, l := range glabLabels {
+		labels[i] = Label{
+			Name:        l.Name,
+			Description: l.Description,
+		}
+	}
+	return labels, nil
+}
+
+// ListLabels returns the labels available to the project
+func (g *GitLab) ListLabels(ctx context.Context) ([]Label, error) {
+	out, err := run(ctx, g.repoPath, "glab", "api", "projects/:id/labels?per_page=100", "--paginate")
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLabLabels(out)
+}
+
+// EditReviewers adds and removes reviewers on a merge request
+func (g *GitLab) EditReviewers(ctx context.Context, number int, add, remove []string) error {
+	return g.updateMR(ctx, number, glabPrefixedArgs("--reviewer", add, remove))
+}
+
+// EditAssignees adds and removes assignees on a merge request
+func (g *GitLab) EditAssignees(ctx context.Context, number int, add, remove []string) error {
+	return g.updateMR(ctx, number, glabPrefixedArgs("--assignee", add, remove))
+}
+
+// EditLabels adds and removes labels on a merge request
+func (g *GitLab) EditLabels(ctx context.Context, number int, add, remove []string) error {
+	var args []string
+	if len(add) > 0 {
+		args = append(args, "--label", strings.Join(add, ","))
+	}
+	if len(remove) > 0 {
+		args = append(args, "--unlabel", strings.Join(remove, ","))
+	}
+	return g.updateMR(ctx, number, args)
+}
+
+// updateMR runs glab mr update with the given flags, skipping empty updates
+func (g *GitLab) updateMR(ctx context.Context, number int, flags []string) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	args := append([]string{"mr", "update", fmt.Sprintf("%d", number)}, flags...)
+	return runSimple(ctx, g.repoPath, "glab", args...)
+}
+
+// glabPrefixedArgs builds a single user-list flag where "+" adds and "-" removes
+// (without a prefix glab would replace the whole list)
+func glabPrefixedArgs(flag string, add, remove []string) []string {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(add)+len(remove))
+	for _, u := range add {
+		values = append(values, "+"+u)
+	}
+	for _, u := range remove {
+		values = append(values, "-"+u)
+	}
+	return []string{flag, strings.Join(values, ",")}
+}
@@ -2,13 +2,16 @@ package platform
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestGitLab_ParseMRList(t *testing.T) {
 	jsonOutput := `[
-		{"iid": 42, "title": "Update API docs", "source_branch": "docs/api", "state": "opened", "web_url": "https://gitlab.com/org/repo/-/merge_requests/42"},
-		{"iid": 40, "title": "Fix CI pipeline", "source_branch": "fix/ci", "state": "merged", "web_url": "https://gitlab.com/org/repo/-/merge_requests/40"}
+		{"iid": 42, "title": "Update API docs", "source_branch": "docs/api", "state": "opened", "draft": true, "web_url": "https://gitlab.com/org/repo/-/merge_requests/42"},
+		{"iid": 40, "title": "Fix CI pipeline", "source_branch": "fix/ci", "state": "merged", "web_url": "https://gitlab.com/org/repo/-/merge_requests/40"},
+		{"iid": 39, "title": "Fix typo", "source_branch": "main", "state": "opened", "web_url": "https://gitlab.com/org/repo/-/merge_requests/39",
+		 "source_project_id": 7, "target_project_id": 3, "author": {"username": "contributor"}}
 	]`
 
 	mrs, err := parseGitLabMRs([]byte(jsonOutput))
@@ -17,8 +20,9 @@ func TestGitLab_ParseMRList(t *testing.T) {
 	}
 
 	expected := []MR{
-		{Number: 42, Title: "Update API docs", Branch: "docs/api", Status: "open", URL: "https://gitlab.com/org/repo/-/merge_requests/42"},
-		{Number: 40, Title: "Fix CI pipeline", Branch: "fix/ci", Status: "merged", URL: "https://gitlab.com/org/repo/-/merge_requests/40"},
+		{Number: 42, Title: "Update API docs", Branch: "docs/api", Status: "draft", URL: "https://gitlab.com/org/repo/-/merge_requests/42", HeadRef: "refs/merge-requests/42/head"},
+		{Number: 40, Title: "Fix CI pipeline", Branch: "fix/ci", Status: "merged", URL: "https://gitlab.com/org/repo/-/merge_requests/40", HeadRef: "refs/merge-requests/40/head"},
+		{Number: 39, Title: "Fix typo", Branch: "main", Status: "open", URL: "https://gitlab.com/org/repo/-/merge_requests/39", HeadRef: "refs/merge-requests/39/head", CrossRepo: true, HeadOwner: "contributor"},
 	}
 
 	if !reflect.DeepEqual(mrs, expected) {
@@ -148,3 +152,167 @@ func TestGitLab_ParseDiffStats_EmptyArray(t *testing.T) {
 		t.Errorf("got deletions %d, want 0", deletions)
 	}
 }
+
+func TestGitLab_ParseMembers(t *testing.T) {
+	// Two pages, as glab api --paginate prints them
+	jsonOutput := `[
+		{"id": 1, "username": "alice", "name": "Alice", "state": "active"}
+	]
+	[
+		{"id": 2, "username": "bob", "name": "Bob", "state": "active"}
+	]`
+
+	authors, err := parseGitLabMembers([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Author{
+		{Username: "alice", Name: "Alice"},
+		{Username: "bob", Name: "Bob"},
+	}
+
+	if !reflect.DeepEqual(authors, expected) {
+		t.Errorf("got %+v, want %+v", authors, expected)
+	}
+}
+
+func TestGitLab_ParseLabels(t *testing.T) {
+	// Two pages, as glab api --paginate prints them
+	jsonOutput := `[
+		{"id": 1, "name": "bug", "description": "Broken things", "color": "#d9534f"}
+	]
+	[
+		{"id": 2, "name": "docs", "description": null, "color": "#428bca"}
+	]`
+
+	labels, err := parseGitLabLabels([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Label{
+		{Name: "bug", Description: "Broken things"},
+		{Name: "docs", Description: ""},
+	}
+
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("got %+v, want %+v", labels, expected)
+	}
+}
+
+func TestGlabPrefixedArgs(t *testing.T) {
+	got := glabPrefixedArgs("--reviewer", []string{"alice", "bob"}, []string{"carol"})
+	expected := []string{"--reviewer", "+alice,+bob,-carol"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+
+	if got := glabPrefixedArgs("--assignee", nil, nil); got != nil {
+		t.Errorf("expected nil for empty update, got %v", got)
+	}
+}
+
+func TestGitLab_ParseReviewComments(t *testing.T) {
+	jsonOutput := `[
+		{"notes": [{"type": null, "body": "General remark", "position": null, "author": {"username": "alice"}}]},
+		{"notes": [
+			{"type": "DiffNote", "body": "Rename this", "author": {"username": "bob"},
+			 "position": {"old_path": "a.go", "new_path": "b.go", "old_line": null, "new_line": 8, "line_range": null}},
+			{"type": "DiffNote", "body": "Agreed", "author": {"username": "carol"},
+			 "position": {"old_path": "a.go", "new_path": "b.go", "old_line": null, "new_line": 8, "line_range": null}}
+		]},
+		{"notes": [{"type": "DiffNote", "body": "Keep this", "author": {"username": "dave"},
+			"position": {"old_path": "c.go", "new_path": "c.go", "old_line": 4, "new_line": null,
+				"line_range": {"start": {"old_line": 2, "new_line": null}, "end": {"old_line": 4, "new_line": null}}}}]}
+	]`
+
+	comments, err := parseGitLabReviewComments([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ReviewComment{
+		{Path: "b.go", OldPath: "a.go", Side: SideNew, End: LineRef{New: 8}, Body: "Rename this", Author: "bob"},
+		{Path: "b.go", OldPath: "a.go", Side: SideNew, End: LineRef{New: 8}, Body: "Agreed", Author: "carol"},
+		{Path: "c.go", OldPath: "c.go", Side: SideOld, Start: LineRef{Old: 2}, End: LineRef{Old: 4}, Body: "Keep this", Author: "dave"},
+	}
+
+	if !reflect.DeepEqual(comments, expected) {
+		t.Errorf("got %+v, want %+v", comments, expected)
+	}
+}
+
+func TestGlabDraftNotePayload(t *testing.T) {
+	refs := glabDiffRefs{BaseSHA: "base", HeadSHA: "head", StartSHA: "start"}
+
+	// An unchanged line needs both line numbers
+	payload, err := glabDraftNotePayload(refs, ReviewComment{
+		Path: "a.go", Side: SideNew, End: LineRef{Old: 3, New: 5}, Body: "context",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"note":"context","position":{"position_type":"text","base_sha":"base","head_sha":"head",` +
+		`"start_sha":"start","old_path":"a.go","new_path":"a.go","old_line":3,"new_line":5}}`
+	if string(payload) != expected {
+		t.Errorf("got %s, want %s", payload, expected)
+	}
+
+	// A range carries line codes for both ends
+	payload, err = glabDraftNotePayload(refs, ReviewComment{
+		Path: "a.go", Side: SideNew, Start: LineRef{New: 7}, End: LineRef{New: 9}, Body: "range",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(payload), `"line_range":{"start":{"line_code":"`) ||
+		!strings.Contains(string(payload), `_0_7","type":"new","new_line":7}`) {
+		t.Errorf("unexpected range payload: %s", payload)
+	}
+}
+
+func TestGitLab_ParseMRStatus(t *testing.T) {
+	cases := map[string]string{
+		`{"state":"opened","draft":false}`: "open",
+		`{"state":"opened","draft":true}`:  "draft",
+		`{"state":"closed","draft":false}`: "closed",
+	}
+	for input, want := range cases {
+		got, err := parseGitLabMRStatus([]byte(input))
+		if err != nil || got != want {
+			t.Errorf("parseGitLabMRStatus(%s) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+}
+
+func TestGitlabListArgs(t *testing.T) {
+	tests := []struct {
+		q    MRQuery
+		want []string
+	}{
+		{MRQuery{Author: "@me"}, []string{"mr", "list", "-F", "json", "--author", "@me"}},
+		{MRQuery{State: "open"}, []string{"mr", "list", "-F", "json"}},
+		{MRQuery{State: "all", Labels: []string{"bug", "ui"}, Limit: 50},
+			[]string{"mr", "list", "-F", "json", "--all", "--label", "bug,ui", "--per-page", "50"}},
+		{MRQuery{Author: "alice", State: "closed"}, []string{"mr", "list", "-F", "json", "--author", "alice", "--closed"}},
+	}
+	for _, tt := range tests {
+		if got := gitlabListArgs(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("gitlabListArgs(%+v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestGitLab_ParseMR(t *testing.T) {
+	mr, err := parseGitLabMR([]byte(`{"iid": 12, "title": "Fix", "source_branch": "fix", "sha": "3f2a9c1", "state": "opened",
+		"web_url": "https://gitlab.com/org/repo/-/merge_requests/12", "source_project_id": 1, "target_project_id": 1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := MR{Number: 12, Title: "Fix", Branch: "fix", Status: "open",
+		URL: "https://gitlab.com/org/repo/-/merge_requests/12", HeadRef: "refs/merge-requests/12/head", HeadSHA: "3f2a9c1"}
+	if mr != want {
+		t.Errorf("got %+v, want %+v", mr, want)
+	}
+}
@@ -1,12 +1,32 @@
 package platform
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+)
+
 // MR represents a merge/pull request
 type MR struct {
-	Number int
-	Title  string
-	Branch string
-	Status string // "open", "draft", "merged", "closed"
-	URL    string
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Branch string `json:"branch"`
+	Status string `json:"status"` // "open", "draft", "merged", "closed"
+	URL    string `json:"url"`
+
+	HeadRef   string `json:"headRef"`             // ref on the base repo that points at the MR head, e.g. refs/pull/42/head
+	CrossRepo bool   `json:"crossRepo"`           // the head branch lives in a fork
+	HeadOwner string `json:"headOwner,omitempty"` // owner of the fork, empty for same-repo MRs
+	HeadSHA   string `json:"headSha,omitempty"`   // commit the head points at, changes with every push
+}
+
+// MRQuery filters the MRs listed by SearchMRs
+type MRQuery struct {
+	Author string   // username or @me, empty for anyone
+	State  string   // "open", "draft", "merged", "closed" or "all"; empty for open
+	Labels []string // MRs must have all of them
+	Limit  int      // most MRs to return, 0 for the CLI's default
 }
 
 // Author represents a repository contributor
@@ -15,6 +35,12 @@ type Author struct {
 	Name     string
 }
 
+// Label represents a repository label that can be applied to an MR/PR
+type Label struct {
+	Name        string
+	Description string
+}
+
 // RepoInfo contains repository metadata
 type RepoInfo struct {
 	Name          string
@@ -25,34 +51,123 @@ type RepoInfo struct {
 
 // FileChange represents a file modification in an MR/PR
 type FileChange struct {
-	Path      string
-	Additions int
-	Deletions int
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
 }
 
 // MRDetail contains detailed information about an MR/PR
 type MRDetail struct {
-	Number    int
-	Title     string
-	Body      string
-	Files     []FileChange
-	Additions int // total additions across all files
-	Deletions int // total deletions across all files
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	Body      string       `json:"body"`
+	Files     []FileChange `json:"files"`
+	Additions int          `json:"additions"` // total additions across all files
+	Deletions int          `json:"deletions"` // total deletions across all files
+	Reviewers []string     `json:"reviewers"` // usernames of requested reviewers
+	Assignees []string     `json:"assignees"` // usernames of assignees
+	Labels    []string     `json:"labels"`    // label names
 }
 
 // Commit represents a commit in an MR/PR
 type Commit struct {
-	SHA     string
-	Message string
-	Author  string
-	Date    string
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+}
+
+// Review comment sides, using GitHub's naming
+const (
+	SideOld = "LEFT"  // the file before the change
+	SideNew = "RIGHT" // the file after the change
+)
+
+// LineRef locates a line of a file's diff by its old and new line numbers,
+// 0 on the side where the line doesn't exist
+type LineRef struct {
+	Old int
+	New int
+}
+
+// ReviewComment is a comment anchored to a line or range of lines of a changed file
+type ReviewComment struct {
+	Path    string
+	OldPath string  // path before a rename, same as Path otherwise
+	Side    string  // SideOld or SideNew
+	Start   LineRef // first line of a range, zero for a single line
+	End     LineRef // the line the comment is anchored to
+	Body    string
+	Author  string // set on existing comments
 }
 
-// Platform abstracts GitHub/GitLab operations
+// Line returns the anchor line number on the comment's side
+func (c ReviewComment) Line() int {
+	return c.End.on(c.Side)
+}
+
+// StartLine returns the first line of the range on the comment's side, 0 for a single line
+func (c ReviewComment) StartLine() int {
+	if c.Start == (LineRef{}) || c.Start == c.End {
+		return 0
+	}
+	return c.Start.on(c.Side)
+}
+
+func (r LineRef) on(side string) int {
+	if side == SideOld {
+		return r.Old
+	}
+	return r.New
+}
+
+// Platform abstracts GitHub/GitLab operations. Every method takes a context
+// that cancels the underlying CLI command.
 type Platform interface {
-	ListMRs(author string) ([]MR, error)
-	GetRepoInfo() (RepoInfo, error)
-	ListAuthors() ([]Author, error)
-	GetMRDetail(number int) (MRDetail, error)
-	GetMRCommits(number int) ([]Commit, error)
+	ListMRs(ctx context.Context, author string) ([]MR, error)
+	SearchMRs(ctx context.Context, q MRQuery) ([]MR, error)
+	ListReviewRequested(ctx context.Context) ([]MR, error) // open MRs whose review is requested from you
+	GetMR(ctx context.Context, number int) (MR, error)
+	GetRepoInfo(ctx context.Context) (RepoInfo, error)
+	ListAuthors(ctx context.Context) ([]Author, error)
+	GetMRDetail(ctx context.Context, number int) (MRDetail, error)
+	GetMRStatus(ctx context.Context, number int) (string, error) // "open", "draft", "merged" or "closed", as in MR.Status
+	GetMRCommits(ctx context.Context, number int) ([]Commit, error)
+	GetMRDiff(ctx context.Context, number int) (string, error)                     // whole-MR unified diff
+	GetMRFileContent(ctx context.Context, number int, path string) (string, error) // file content at the MR head
+	ListLabels(ctx context.Context) ([]Label, error)
+	EditReviewers(ctx context.Context, number int, add, remove []string) error
+	EditAssignees(ctx context.Context, number int, add, remove []string) error
+	EditLabels(ctx context.Context, number int, add, remove []string) error
+	ListReviewComments(ctx context.Context, number int) ([]ReviewComment, error)  // existing line comments
+	SubmitReview(ctx context.Context, number int, comments []ReviewComment) error // publishes comments as one review
+}
+
+// NewPlatformNamed creates the Platform for name, "github" or "gitlab", for
+// remotes on hosts mapped to a platform in the config
+func NewPlatformNamed(name, repoPath string) (Platform, error) {
+	switch name {
+	case "github":
+		return NewGitHub(repoPath), nil
+	case "gitlab":
+		return NewGitLab(repoPath), nil
+	default:
+		return nil, ErrUnknownPlatform
+	}
+}
+
+// unmarshalPages decodes the JSON arrays `gh api --paginate` and
+// `glab api --paginate` print one page after another into a single slice
+func unmarshalPages[T any](data []byte) ([]T, error) {
+	var all []T
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var page []T
+		if err := dec.Decode(&page); err == io.EOF {
+			return all, nil
+		} else if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+	}
 }
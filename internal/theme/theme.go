@@ -0,0 +1,171 @@
+package theme
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Palette is the colors of a theme, each an ANSI color number ("0" to "255")
+// or a hex color ("#0af", "#00aaff"). An empty color leaves the terminal's.
+type Palette struct {
+	Primary   string // header and active tab
+	Secondary string // inactive tabs, footer and drafts
+	Success   string // open MRs and done steps
+	Error     string // closed MRs and failures
+	Selected  string // the selected row
+	Accent    string // titles, borders and merged MRs
+	Text      string // body text
+	Bright    string // text being typed and commit messages
+	Dim       string // hints and secondary details
+	Faint     string // branch names
+	Backdrop  string // around modals
+	Warning   string
+	Comment   string // review comments in diffs
+
+	AddedBg           string // added diff lines
+	RemovedBg         string // removed diff lines
+	AddedEmphasisBg   string // changed words on added lines
+	RemovedEmphasisBg string // changed words on removed lines
+	SyntaxKeyword     string
+	SyntaxType        string
+	SyntaxString      string
+	SyntaxNumber      string
+	SyntaxComment     string
+	SyntaxFunction    string
+}
+
+// Names of the built-in themes. Auto picks dark or light from the
+// terminal's background.
+const (
+	Auto         = "auto"
+	Dark         = "dark"
+	Light        = "light"
+	HighContrast = "high-contrast"
+)
+
+// builtins are the themes gq ships with
+var builtins = map[string]Palette{
+	Dark: {
+		Primary: "36", Secondary: "242", Success: "35", Error: "167", Selected: "35", Accent: "72",
+		Text: "250", Bright: "255", Dim: "244", Faint: "240", Backdrop: "236", Warning: "214", Comment: "180",
+		AddedBg: "22", RemovedBg: "52", AddedEmphasisBg: "28", RemovedEmphasisBg: "88",
+		SyntaxKeyword: "110", SyntaxType: "73", SyntaxString: "144", SyntaxNumber: "173",
+		SyntaxComment: "243", SyntaxFunction: "180",
+	},
+	Light: {
+		Primary: "24", Secondary: "245", Success: "28", Error: "124", Selected: "28", Accent: "30",
+		Text: "237", Bright: "232", Dim: "242", Faint: "246", Backdrop: "253", Warning: "130", Comment: "94",
+		AddedBg: "194", RemovedBg: "224", AddedEmphasisBg: "157", RemovedEmphasisBg: "217",
+		SyntaxKeyword: "25", SyntaxType: "30", SyntaxString: "94", SyntaxNumber: "130",
+		SyntaxComment: "245", SyntaxFunction: "90",
+	},
+	HighContrast: {
+		Primary: "51", Secondary: "252", Success: "46", Error: "196", Selected: "226", Accent: "51",
+		Text: "255", Bright: "231", Dim: "250", Faint: "250", Backdrop: "238", Warning: "226", Comment: "219",
+		AddedBg: "22", RemovedBg: "52", AddedEmphasisBg: "34", RemovedEmphasisBg: "160",
+		SyntaxKeyword: "117", SyntaxType: "87", SyntaxString: "229", SyntaxNumber: "215",
+		SyntaxComment: "250", SyntaxFunction: "222",
+	},
+}
+
+// None has no colors, for NO_COLOR
+var None = Palette{}
+
+// Role is a color of a palette under the name themes use in the config
+type Role struct {
+	Name  string
+	Color func(p *Palette) *string
+}
+
+// Roles lists every color of a palette
+var Roles = []Role{
+	{"primary", func(p *Palette) *string { return &p.Primary }},
+	{"secondary", func(p *Palette) *string { return &p.Secondary }},
+	{"success", func(p *Palette) *string { return &p.Success }},
+	{"error", func(p *Palette) *string { return &p.Error }},
+	{"selected", func(p *Palette) *string { return &p.Selected }},
+	{"accent", func(p *Palette) *string { return &p.Accent }},
+	{"text", func(p *Palette) *string { return &p.Text }},
+	{"bright", func(p *Palette) *string { return &p.Bright }},
+	{"dim", func(p *Palette) *string { return &p.Dim }},
+	{"faint", func(p *Palette) *string { return &p.Faint }},
+	{"backdrop", func(p *Palette) *string { return &p.Backdrop }},
+	{"warning", func(p *Palette) *string { return &p.Warning }},
+	{"comment", func(p *Palette) *string { return &p.Comment }},
+	{"added_bg", func(p *Palette) *string { return &p.AddedBg }},
+	{"removed_bg", func(p *Palette) *string { return &p.RemovedBg }},
+	{"added_emphasis_bg", func(p *Palette) *string { return &p.AddedEmphasisBg }},
+	{"removed_emphasis_bg", func(p *Palette) *string { return &p.RemovedEmphasisBg }},
+	{"syntax_keyword", func(p *Palette) *string { return &p.SyntaxKeyword }},
+	{"syntax_type", func(p *Palette) *string { return &p.SyntaxType }},
+	{"syntax_string", func(p *Palette) *string { return &p.SyntaxString }},
+	{"syntax_number", func(p *Palette) *string { return &p.SyntaxNumber }},
+	{"syntax_comment", func(p *Palette) *string { return &p.SyntaxComment }},
+	{"syntax_function", func(p *Palette) *string { return &p.SyntaxFunction }},
+}
+
+// Base is the setting of a custom theme naming the built-in theme it starts
+// from, dark by default
+const Base = "base"
+
+// Builtin reports whether name is a built-in theme or auto
+func Builtin(name string) bool {
+	_, ok := builtins[name]
+	return ok || name == Auto
+}
+
+// BuiltinNames returns the built-in themes' names, auto first
+func BuiltinNames() []string {
+	return append([]string{Auto}, slices.Sorted(maps.Keys(builtins))...)
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidColor checks that s is an ANSI color number or a hex color
+func ValidColor(s string) error {
+	if hexColor.MatchString(s) {
+		return nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid color %q, use 0 to 255 or #rrggbb", s)
+}
+
+// Resolve returns the palette of the theme called name: a built-in one, or
+// one of custom, role -> color with an optional base. dark is asked only
+// when auto has to pick between dark and light.
+func Resolve(name string, custom map[string]map[string]string, dark func() bool) (Palette, error) {
+	if name == Auto {
+		if dark() {
+			return builtins[Dark], nil
+		}
+		return builtins[Light], nil
+	}
+	if p, ok := builtins[name]; ok {
+		return p, nil
+	}
+	colors, ok := custom[name]
+	if !ok {
+		return builtins[Dark], fmt.Errorf("unknown theme %q, use %s or a [themes.%s] table", name, strings.Join(BuiltinNames(), ", "), name)
+	}
+
+	base := colors[Base]
+	if base == "" {
+		base = Dark
+	}
+	if !Builtin(base) {
+		return builtins[Dark], fmt.Errorf("themes.%s: base must be a built-in theme, not %q", name, base)
+	}
+	p, _ := Resolve(base, nil, dark)
+	for _, role := range Roles {
+		if c, ok := colors[role.Name]; ok {
+			*role.Color(&p) = c
+		}
+	}
+	return p, nil
+}
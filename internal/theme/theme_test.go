@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	for name, p := range builtins {
+		for _, role := range Roles {
+			c := *role.Color(&p)
+			if err := ValidColor(c); err != nil {
+				t.Errorf("%s %s: %v", name, role.Name, err)
+			}
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for _, c := range []string{"0", "255", "#0af", "#00AAFF"} {
+		if err := ValidColor(c); err != nil {
+			t.Errorf("ValidColor(%q) = %v", c, err)
+		}
+	}
+	for _, c := range []string{"", "256", "-1", "red", "#12345", "#ggg"} {
+		if ValidColor(c) == nil {
+			t.Errorf("ValidColor(%q) accepted it", c)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dark := func() bool { return true }
+	light := func() bool { return false }
+
+	if p, _ := Resolve(Auto, nil, dark); p != builtins[Dark] {
+		t.Error("auto on a dark background isn't dark")
+	}
+	if p, _ := Resolve(Auto, nil, light); p != builtins[Light] {
+		t.Error("auto on a light background isn't light")
+	}
+	// Built-in themes don't ask the terminal
+	if p, _ := Resolve(HighContrast, nil, nil); p != builtins[HighContrast] {
+		t.Error("high-contrast isn't the built-in")
+	}
+
+	custom := map[string]map[string]string{
+		"paper": {Base: Auto, "accent": "#268bd2"},
+		"mine":  {"dim": "240"},
+		"bad":   {Base: "paper"},
+	}
+	p, err := Resolve("paper", custom, light)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := builtins[Light]
+	want.Accent = "#268bd2"
+	if p != want {
+		t.Errorf("paper = %+v, want light with a blue accent", p)
+	}
+	if p, _ := Resolve("mine", custom, nil); p.Dim != "240" || p.Primary != builtins[Dark].Primary {
+		t.Errorf("mine = %+v, want dark with a dim of 240", p)
+	}
+
+	if _, err := Resolve("bad", custom, nil); err == nil || !strings.Contains(err.Error(), "base must be a built-in theme") {
+		t.Errorf("bad base: %v", err)
+	}
+	p, err = Resolve("nope", custom, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown theme "nope", use auto, dark, high-contrast, light`) {
+		t.Errorf("unknown theme: %v", err)
+	}
+	if p != builtins[Dark] {
+		t.Error("an unknown theme doesn't fall back to dark")
+	}
+}
@@ -1,236 +1,47 @@
 package ui
 
 import (
-	"fmt"
-	"io"
-	"strings"
-
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
-
-	"charm.land/bubbles/v2/list"
-	"charm.land/bubbles/v2/textinput"
-	tea "charm.land/bubbletea/v2"
-	"charm.land/lipgloss/v2"
 )
 
-// AuthorItem wraps an Author for the list component
-type AuthorItem struct {
-	Author platform.Author
-}
-
-func (i AuthorItem) Title() string {
-	return i.Author.Username
-}
-
-func (i AuthorItem) Description() string {
-	if i.Author.Name != i.Author.Username {
-		return i.Author.Name
-	}
-	return ""
-}
-
-func (i AuthorItem) FilterValue() string {
-	return i.Author.Username
-}
-
-// AuthorDelegate is a custom delegate for author list rendering
-type AuthorDelegate struct{}
-
-func (d AuthorDelegate) Height() int                             { return 1 }
-func (d AuthorDelegate) Spacing() int                            { return 0 }
-func (d AuthorDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
-
-func (d AuthorDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
-	authorItem, ok := item.(AuthorItem)
-	if !ok {
-		return
-	}
-
-	author := authorItem.Author
-	isSelected := index == m.Index()
-
-	username := author.Username
-	name := author.Name
-
-	var content string
-	if name != "" && name != username {
-		content = fmt.Sprintf("%s (%s)", username, name)
-	} else {
-		content = username
-	}
-	maxWidth := m.Width() - 3 // left border=1, padding=1, margin=1
-	if maxWidth < 10 {
-		maxWidth = 10
-	}
-	if len(content) > maxWidth {
-		content = content[:maxWidth-3] + "..."
-	}
-
-	var line string
-	if isSelected {
-		line = SelectedRowStyle.Render(SelectedItemStyle.Render(content))
-	} else {
-		line = NormalRowStyle.Render(NormalItemStyle.Render(content))
-	}
-
-	_, _ = fmt.Fprint(w, line)
-}
-
-// AuthorPicker is a modal for selecting an author
-type AuthorPicker struct {
-	list        list.Model
-	listWidth   int
-	allAuthors  []platform.Author
-	searching   bool
-	searchInput textinput.Model
-}
-
-// NewAuthorPicker creates a new author picker
-func NewAuthorPicker(authors []platform.Author, currentAuthor string, width, height int) AuthorPicker {
-	// Add @me as the first option
-	allAuthors := make([]platform.Author, 0, len(authors)+1)
-	allAuthors = append(allAuthors, platform.Author{Username: "@me", Name: ""})
-	allAuthors = append(allAuthors, authors...)
-
-	items := make([]list.Item, len(allAuthors))
-	for i, author := range allAuthors {
-		items[i] = AuthorItem{Author: author}
-	}
-
-	listWidth := width - 4
-	listHeight := height - 4
-	if listWidth < 40 {
-		listWidth = 40
-	}
-	if listHeight < 15 {
-		listHeight = 15
-	}
-
-	l := list.New(items, AuthorDelegate{}, listWidth, listHeight)
-	l.Title = ""
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false) // We handle filtering ourselves
-	l.SetShowHelp(false)
-	l.SetShowPagination(false)
-	l.Styles.TitleBar = lipgloss.NewStyle()
-	l.Styles.Title = lipgloss.NewStyle()
-
-	ti := textinput.New()
-	ti.Placeholder = "search..."
-	ti.CharLimit = 50
-	ti.SetWidth(30)
-	tiStyles := ti.Styles()
-	tiStyles.Focused.Prompt = lipgloss.NewStyle().Foreground(accentColor)
-	tiStyles.Focused.Text = lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
-	ti.SetStyles(tiStyles)
-
-	return AuthorPicker{
-		list:        l,
-		listWidth:   listWidth,
-		allAuthors:  allAuthors,
-		searchInput: ti,
-	}
-}
-
-// Update handles messages
-func (p AuthorPicker) Update(msg tea.Msg) (AuthorPicker, tea.Cmd) {
-	// Handle search mode
-	if p.searching {
-		switch msg := msg.(type) {
-		case tea.KeyPressMsg:
-			switch msg.String() {
-			case "esc":
-				p.searching = false
-				p.searchInput.Blur()
-				p.searchInput.SetValue("")
-				p.filterItems()
-				return p, nil
-			case "enter":
-				p.searching = false
-				p.searchInput.Blur()
-				return p, nil
-			}
-		}
-
-		var cmd tea.Cmd
-		p.searchInput, cmd = p.searchInput.Update(msg)
-		p.filterItems()
-		return p, cmd
-	}
-
-	// Not in search mode
-	switch msg := msg.(type) {
-	case tea.KeyPressMsg:
-		switch msg.String() {
-		case "f", "/":
-			p.searching = true
-			p.searchInput.Focus()
-			return p, nil
+// NewAuthorPicker creates a single-select picker for filtering MRs by author,
+// with the configured default author first
+func NewAuthorPicker(authors []platform.Author, currentAuthor, defaultAuthor string, width, height int) Picker {
+	items := make([]PickerItem, 0, len(authors)+2)
+	items = append(items, PickerItem{Key: defaultAuthor, Detail: "default"})
+	if defaultAuthor != "@me" {
+		items = append(items, PickerItem{Key: "@me"})
+	}
+	for _, item := range authorItems(authors) {
+		if item.Key != defaultAuthor {
+			items = append(items, item)
 		}
 	}
 
-	var cmd tea.Cmd
-	p.list, cmd = p.list.Update(msg)
-	return p, cmd
-}
-
-// filterItems filters the list based on search input
-func (p *AuthorPicker) filterItems() {
-	query := strings.ToLower(p.searchInput.Value())
-	if query == "" {
-		// Show all items
-		items := make([]list.Item, len(p.allAuthors))
-		for i, author := range p.allAuthors {
-			items[i] = AuthorItem{Author: author}
-		}
-		p.list.SetItems(items)
-		return
-	}
-
-	// Filter items
-	var filtered []list.Item
-	for _, author := range p.allAuthors {
-		username := strings.ToLower(author.Username)
-		name := strings.ToLower(author.Name)
-		if strings.Contains(username, query) || strings.Contains(name, query) {
-			filtered = append(filtered, AuthorItem{Author: author})
-		}
-	}
-	p.list.SetItems(filtered)
-}
-
-// SearchBar returns the search bar view if searching, empty string otherwise
-func (p AuthorPicker) SearchBar() string {
-	if !p.searching {
-		return ""
-	}
-	searchStyle := lipgloss.NewStyle().
-		Foreground(accentColor).
-		Bold(true)
-	return searchStyle.Render("Find: ") + p.searchInput.View()
+	return NewPicker("", items, width, height)
 }
 
-// IsSearching returns true if search mode is active
-func (p AuthorPicker) IsSearching() bool {
-	return p.searching
+// NewPeoplePicker creates a multi-select picker over repository members,
+// with the currently assigned usernames pre-checked
+func NewPeoplePicker(title string, authors []platform.Author, selected []string, width, height int) Picker {
+	return NewMultiPicker(title, authorItems(authors), selected, width, height)
 }
 
-// View renders the picker
-func (p AuthorPicker) View() string {
-	content := p.list.View()
-	if p.searching {
-		content = content + "\n" + p.SearchBar()
+// NewLabelPicker creates a multi-select picker over repository labels,
+// with the labels currently applied pre-checked
+func NewLabelPicker(labels []platform.Label, selected []string, width, height int) Picker {
+	items := make([]PickerItem, len(labels))
+	for i, l := range labels {
+		items[i] = PickerItem{Key: l.Name, Detail: l.Description}
 	}
-	footer := DimStyle.Render("↑↓ nav │ f find │ enter select │ esc close")
-	content = content + "\n" + footer
-	return ModalStyle.Width(p.listWidth).Render(content)
+	return NewMultiPicker("Labels", items, selected, width, height)
 }
 
-// SelectedAuthor returns the currently highlighted author
-func (p AuthorPicker) SelectedAuthor() string {
-	item, ok := p.list.SelectedItem().(AuthorItem)
-	if !ok {
-		return "@me"
+// authorItems converts authors to picker items keyed by username
+func authorItems(authors []platform.Author) []PickerItem {
+	items := make([]PickerItem, len(authors))
+	for i, author := range authors {
+		items[i] = PickerItem{Key: author.Username, Detail: author.Name}
 	}
-	return item.Author.Username
+	return items
 }
@@ -1,9 +1,14 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
 	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
 
 	"charm.land/bubbles/v2/spinner"
@@ -17,74 +22,306 @@ const (
 	CheckoutInProgress CheckoutState = iota
 	CheckoutDone
 	CheckoutError
+	CheckoutCancelling
+	CheckoutCancelled
+	CheckoutConfirmReset // waiting for the user to confirm a hard reset
 )
 
 // CheckoutModal handles the checkout flow UI
 type CheckoutModal struct {
-	mr       *platform.MR // nil for direct branch checkout
-	branch   string       // branch to checkout
-	repoPath string
-	state    CheckoutState
-	spinner  spinner.Model
-	err      error
-	errStep  string
+	mr          *platform.MR // nil for direct branch checkout
+	branch      string       // branch to checkout
+	repoPath    string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	state       CheckoutState
+	spinner     spinner.Model
+	err         error
+	errStep     string
+	steps       []checkoutStep
+	result      git.CheckoutResult
+	repo        *git.RepoState // state the repo was left in after a cancel
+	stash       bool           // stash uncommitted changes first
+	untracked   bool           // include untracked files in the stash
+	baseBranch  string         // fetched along with the branch
+	worktreeDir string         // check out into a worktree under this directory instead
+	events      progressEvents // progress from the running checkout, then its CheckoutCompleteMsg
+	keys        keymap.Keymap
+}
+
+// checkoutStep is one line of the step list
+type checkoutStep struct {
+	id      string
+	label   string
+	state   git.StepState
+	phase   string
+	percent int
+}
+
+// CheckoutProgressMsg carries a step update from a running checkout
+type CheckoutProgressMsg struct {
+	Progress git.Progress
 }
 
 // CheckoutCompleteMsg is sent when checkout finishes
 type CheckoutCompleteMsg struct {
-	Err error
+	Result git.CheckoutResult
+	Err    error
+	State  *git.RepoState // set when the checkout was cancelled
 }
 
+// progressBarWidth is the width of the fetch progress bar
+const progressBarWidth = 20
+
 // NewCheckoutModal creates a new checkout modal for an MR
-func NewCheckoutModal(mr platform.MR, repoPath string) CheckoutModal {
+func NewCheckoutModal(ctx context.Context, mr platform.MR, repoPath string) CheckoutModal {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
 
 	return CheckoutModal{
+		ctx:      ctx,
+		cancel:   cancel,
 		mr:       &mr,
 		branch:   mr.Branch,
 		repoPath: repoPath,
 		state:    CheckoutInProgress,
 		spinner:  s,
+		events:   newProgressEvents(),
 	}
 }
 
 // NewBranchCheckoutModal creates a checkout modal for a direct branch checkout
-func NewBranchCheckoutModal(branch, repoPath string) CheckoutModal {
+func NewBranchCheckoutModal(ctx context.Context, branch, repoPath string) CheckoutModal {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
 
 	return CheckoutModal{
+		ctx:      ctx,
+		cancel:   cancel,
 		mr:       nil,
 		branch:   branch,
 		repoPath: repoPath,
 		state:    CheckoutInProgress,
 		spinner:  s,
+		events:   newProgressEvents(),
 	}
 }
 
+// NewWorktreeCheckoutModal creates a checkout modal that checks the MR out in
+// its own worktree under dir, leaving the main working tree alone
+func NewWorktreeCheckoutModal(ctx context.Context, mr platform.MR, repoPath, dir string) CheckoutModal {
+	m := NewCheckoutModal(ctx, mr, repoPath)
+	m.worktreeDir = dir
+	return m
+}
+
+// WithKeys sets the keymap, whose checkout bindings the modal and the
+// dashboard handle
+func (m CheckoutModal) WithKeys(keys keymap.Keymap) CheckoutModal {
+	m.keys = keys
+	return m
+}
+
+// WithBaseBranch makes the checkout fetch branch too, usually the default
+// branch MRs are compared against
+func (m CheckoutModal) WithBaseBranch(branch string) CheckoutModal {
+	m.baseBranch = branch
+	return m
+}
+
+// WithStash makes the checkout stash uncommitted changes first, including
+// untracked files if untracked is set. They are restored on returning to
+// the current branch.
+func (m CheckoutModal) WithStash(untracked bool) CheckoutModal {
+	m.stash = true
+	m.untracked = untracked
+	return m
+}
+
 // Init starts the checkout process
 func (m CheckoutModal) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.doCheckout())
 }
 
+// doCheckout runs the checkout in the background and returns its first event
 func (m CheckoutModal) doCheckout() tea.Cmd {
-	return func() tea.Msg {
-		err := git.Checkout(m.repoPath, m.branch)
-		return CheckoutCompleteMsg{Err: err}
+	ctx, mr, branch, repoPath := m.ctx, m.mr, m.branch, m.repoPath
+	stash, untracked, worktreeDir, base := m.stash, m.untracked, m.worktreeDir, m.baseBranch
+
+	return m.background(func(report func(git.Progress)) (git.CheckoutResult, error) {
+		var result git.CheckoutResult
+		strategy, err := git.GetPullStrategy(ctx, repoPath)
+		if err != nil {
+			return result, err
+		}
+		opts := git.CheckoutOptions{Strategy: strategy, BaseBranch: base}
+
+		if worktreeDir != "" {
+			head := git.MRHead{Number: mr.Number, Ref: "refs/heads/" + mr.Branch, Branch: mr.Branch}
+			if mr.CrossRepo && mr.HeadRef != "" {
+				head = git.MRHead{Number: mr.Number, Ref: mr.HeadRef, Branch: mr.Branch, Owner: mr.HeadOwner}
+			}
+			return git.CheckoutWorktree(ctx, repoPath, worktreeDir, head, opts, report)
+		}
+
+		from, _ := git.GetCurrentBranch(ctx, repoPath)
+		var stashedFrom string
+		if stash {
+			if stashedFrom, err = git.AutoStash(ctx, repoPath, untracked, report); err != nil {
+				return result, err
+			}
+		}
+		if mr != nil && mr.CrossRepo && mr.HeadRef != "" {
+			// Fork branches aren't on origin, fetch those through the MR ref
+			result, err = git.CheckoutMRHead(ctx, repoPath, git.MRHead{
+				Number: mr.Number,
+				Ref:    mr.HeadRef,
+				Branch: mr.Branch,
+				Owner:  mr.HeadOwner,
+			}, opts, report)
+		} else {
+			result, err = git.CheckoutWithProgress(ctx, repoPath, branch, opts, report)
+		}
+		result.StashedFrom = stashedFrom
+		if err != nil {
+			if stashedFrom != "" && restoreAfterFailure(repoPath, stashedFrom, report) {
+				result.StashedFrom = ""
+			}
+			return result, err
+		}
+		if result.NeedsReset {
+			// A reset would throw restored changes away, restore after it
+			recordCheckout(ctx, repoPath, from, mr, result)
+			return result, nil
+		}
+		// Bring back what was stashed when this branch was last left
+		result.Restored, err = git.RestoreAutoStash(ctx, repoPath, result.Branch, report)
+		recordCheckout(ctx, repoPath, from, mr, result)
+		return result, err
+	})
+}
+
+// restoreAfterFailure puts back the changes stashed on from when a checkout
+// failed or was cancelled without leaving it, and reports whether it did
+func restoreAfterFailure(repoPath, from string, report func(git.Progress)) bool {
+	// The checkout's context may be cancelled, so restore with a fresh one
+	ctx, cancel := context.WithTimeout(context.Background(), cmd.DefaultTimeout)
+	defer cancel()
+	if branch, err := git.GetCurrentBranch(ctx, repoPath); err != nil || branch != from {
+		return false
 	}
+	restored, err := git.RestoreAutoStash(ctx, repoPath, from, report)
+	return restored && err == nil
+}
+
+// recordCheckout adds a checkout to the repo's history. The history only
+// helps to get back, so failing to write it doesn't fail the checkout.
+func recordCheckout(ctx context.Context, repoPath, from string, mr *platform.MR, result git.CheckoutResult) {
+	entry := git.HistoryEntry{Branch: result.Branch}
+	if mr != nil {
+		entry.MR = mr.Number
+	}
+	_ = git.RecordCheckout(ctx, repoPath, from, entry)
+}
+
+// finishReset resets the branch to its upstream if reset is set, or keeps
+// it, then restores stashed changes as the checkout would have
+func (m CheckoutModal) finishReset(reset bool) tea.Cmd {
+	ctx, repoPath, result := m.ctx, m.repoPath, m.result
+	dir := repoPath
+	if result.Worktree != "" {
+		dir = result.Worktree
+	}
+
+	return m.background(func(report func(git.Progress)) (git.CheckoutResult, error) {
+		result.NeedsReset = false
+		var err error
+		if reset {
+			if result, err = git.ResetToUpstream(ctx, dir, result, report); err != nil {
+				return result, err
+			}
+		}
+		if result.Worktree == "" {
+			result.Restored, err = git.RestoreAutoStash(ctx, repoPath, result.Branch, report)
+		}
+		return result, err
+	})
+}
+
+// background runs work in a goroutine, streaming its progress, and returns
+// the command waiting for the first event
+func (m CheckoutModal) background(work func(report func(git.Progress)) (git.CheckoutResult, error)) tea.Cmd {
+	repoPath := m.repoPath
+	wrap := func(p git.Progress) tea.Msg { return CheckoutProgressMsg{Progress: p} }
+	return m.events.run(wrap, func(report func(git.Progress)) tea.Msg {
+		result, err := work(report)
+		stateDir := repoPath
+		if result.Worktree != "" {
+			stateDir = result.Worktree
+		}
+		return CheckoutCompleteMsg{Result: result, Err: err, State: cancelledState(stateDir, err)}
+	})
+}
+
+// cancelledState describes what a cancelled checkout left behind in
+// repoPath, or returns nil if err isn't a cancellation
+func cancelledState(repoPath string, err error) *git.RepoState {
+	if !errors.Is(err, context.Canceled) {
+		return nil
+	}
+	// The checkout's context is gone, so look with a fresh one
+	state, serr := git.DescribeState(context.Background(), repoPath)
+	if serr != nil {
+		return nil
+	}
+	return &state
 }
 
 // Update handles messages
 func (m CheckoutModal) Update(msg tea.Msg) (CheckoutModal, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if m.state != CheckoutConfirmReset {
+			return m, nil
+		}
+		switch key := msg.String(); {
+		case m.keys.Matches(key, keymap.CheckoutReset):
+			m.state = CheckoutInProgress
+			return m, m.finishReset(true)
+		case m.keys.Matches(key, keymap.CheckoutKeep):
+			m.state = CheckoutInProgress
+			return m, m.finishReset(false)
+		}
+		return m, nil
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case CheckoutProgressMsg:
+		if m.state != CheckoutInProgress && m.state != CheckoutCancelling {
+			return m, nil
+		}
+		m.applyProgress(msg.Progress)
+		return m, m.events.next()
+
 	case CheckoutCompleteMsg:
-		if msg.Err != nil {
+		m.result = msg.Result
+		if msg.Err == nil && msg.Result.NeedsReset {
+			m.state = CheckoutConfirmReset
+			return m, nil
+		}
+		m.cancel()
+		if errors.Is(msg.Err, context.Canceled) {
+			m.state = CheckoutCancelled
+			m.repo = msg.State
+			if ce, ok := msg.Err.(*git.CheckoutError); ok {
+				m.errStep = ce.Step
+			}
+		} else if msg.Err != nil {
 			m.state = CheckoutError
 			m.err = msg.Err
 			if ce, ok := msg.Err.(*git.CheckoutError); ok {
@@ -92,6 +329,7 @@ func (m CheckoutModal) Update(msg tea.Msg) (CheckoutModal, tea.Cmd) {
 			}
 		} else {
 			m.state = CheckoutDone
+			m.branch = msg.Result.Branch
 		}
 		return m, nil
 	}
@@ -104,33 +342,216 @@ func (m CheckoutModal) View() string {
 	var content string
 	if m.mr != nil {
 		content = fmt.Sprintf("#%d %s\n", m.mr.Number, m.mr.Title)
-		content += fmt.Sprintf("Branch: %s\n\n", m.branch)
+		if m.mr.CrossRepo && m.state != CheckoutDone {
+			content += fmt.Sprintf("Branch: %s:%s (fork)\n\n", m.mr.HeadOwner, m.mr.Branch)
+		} else {
+			content += fmt.Sprintf("Branch: %s\n\n", m.branch)
+		}
 	} else {
-		content = fmt.Sprintf("Checkout to default branch\n")
+		if m.branch == m.baseBranch {
+			content = "Checkout to default branch\n"
+		} else {
+			content = "Checkout branch\n"
+		}
 		content += fmt.Sprintf("Branch: %s\n\n", m.branch)
 	}
 
+	content += m.renderSteps()
+
 	switch m.state {
 	case CheckoutInProgress:
-		content += m.spinner.View() + " Checking out...\n"
-		content += "\n[esc] cancel"
-	case CheckoutDone:
-		content += SuccessStyle.Render("✓ Checkout complete") + "\n"
+		if len(m.steps) == 0 {
+			content += m.spinner.View() + " Checking out...\n"
+		}
+		content += "\n" + "[" + keyHint(m.keys, keymap.CheckoutCancel) + "] cancel"
+	case CheckoutCancelling:
+		content += m.spinner.View() + " Cancelling...\n"
+	case CheckoutConfirmReset:
+		if m.result.ForcePushed {
+			content += "\n" + WarningStyle.Render(m.result.Upstream+" was force-pushed") + "\n"
+		} else {
+			content += "\n" + WarningStyle.Render(m.result.Branch+" has diverged from "+m.result.Upstream) + "\n"
+		}
+		content += fmt.Sprintf("Reset %s to %s (%s)? This drops %s.\n",
+			m.result.Branch, m.result.Upstream, git.ShortSHA(m.result.RemoteAfter), plural(m.result.LocalOnly, "local commit"))
+		content += fmt.Sprintf("\n[%s] Reset  |  [%s] Keep the local branch",
+			keyHint(m.keys, keymap.CheckoutReset), keyHint(m.keys, keymap.CheckoutKeep))
+	case CheckoutCancelled:
+		if m.errStep != "" {
+			content += WarningStyle.Render(fmt.Sprintf("Cancelled during %s", m.errStep)) + "\n"
+		} else {
+			content += WarningStyle.Render("Cancelled") + "\n"
+		}
+		if m.repo != nil {
+			content += DimStyle.Render(m.repo.Summary()) + "\n"
+		}
+		content += m.stashNote()
 		content += "\nPress any key to continue"
+	case CheckoutDone:
+		content += "\n" + SuccessStyle.Render("✓ Checkout complete") + "\n"
+		content += DimStyle.Render(m.summary()) + "\n"
+		if m.result.Worktree != "" {
+			content += "Worktree: " + BranchStyle.Render(m.result.Worktree) + "\n"
+			content += fmt.Sprintf("\n[%s] open shell there  |  [%s] open editor there  |  any other key to continue",
+				keyHint(m.keys, keymap.CheckoutShell), keyHint(m.keys, keymap.CheckoutEditor))
+		} else {
+			content += "\nPress any key to continue"
+		}
 	case CheckoutError:
 		if m.errStep != "" {
 			content += ErrorStyle.Render(fmt.Sprintf("✗ Failed at %s", m.errStep)) + "\n"
 		}
-		content += "\n" + ErrorStyle.Render("Error: "+m.err.Error())
-		content += "\n\nPress any key to continue"
+		content += "\n" + ErrorStyle.Render("Error: "+m.err.Error()) + "\n"
+		content += m.stashNote()
+		content += "\nPress any key to continue"
 	}
 
 	return ModalStyle.Render(content)
 }
 
-// IsDone returns true if checkout is complete (success or error)
+// stashNote says where uncommitted changes went when a checkout stopped
+// after stashing them and they couldn't be put back
+func (m CheckoutModal) stashNote() string {
+	from := m.result.StashedFrom
+	if from == "" {
+		return ""
+	}
+	return WarningStyle.Render(fmt.Sprintf("Your changes are stashed as \"gq autostash from %s\"", from)) + "\n" +
+		DimStyle.Render(fmt.Sprintf("They come back when you check %s out again, or run git stash pop", from)) + "\n"
+}
+
+// applyProgress records a step update, adding the step when it starts
+func (m *CheckoutModal) applyProgress(p git.Progress) {
+	m.steps = applyStep(m.steps, p)
+}
+
+// renderSteps renders the step list, with git's progress under the running step
+func (m CheckoutModal) renderSteps() string {
+	return renderSteps(m.steps, m.spinner)
+}
+
+// applyStep records a step update in steps, adding the step when it starts
+func applyStep(steps []checkoutStep, p git.Progress) []checkoutStep {
+	for i := range steps {
+		if steps[i].id != p.Step {
+			continue
+		}
+		step := &steps[i]
+		step.state = p.State
+		if p.Label != "" {
+			step.label = p.Label
+		}
+		step.phase, step.percent = p.Phase, p.Percent
+		return steps
+	}
+	return append(steps, checkoutStep{
+		id:      p.Step,
+		label:   p.Label,
+		state:   p.State,
+		phase:   p.Phase,
+		percent: p.Percent,
+	})
+}
+
+// renderSteps renders a step list, with git's progress under the running step
+func renderSteps(steps []checkoutStep, spin spinner.Model) string {
+	var b strings.Builder
+	for _, step := range steps {
+		switch step.state {
+		case git.StepDone:
+			b.WriteString(SuccessStyle.Render("✓") + " " + step.label + "\n")
+		case git.StepFailed:
+			b.WriteString(ErrorStyle.Render("✗") + " " + step.label + "\n")
+		default:
+			b.WriteString(spin.View() + " " + step.label + "...\n")
+			if step.phase != "" && step.percent >= 0 {
+				b.WriteString("  " + DimStyle.Render(fmt.Sprintf("%s %s %3d%%",
+					progressBar(step.percent, progressBarWidth), step.phase, step.percent)) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// summary describes what the checkout changed
+func (m CheckoutModal) summary() string {
+	var parts []string
+	if m.result.ForcePushed {
+		parts = append(parts, fmt.Sprintf("%s was force-pushed (%s → %s)",
+			m.result.Upstream, git.ShortSHA(m.result.RemoteBefore), git.ShortSHA(m.result.RemoteAfter)))
+	}
+	if m.result.Reset {
+		parts = append(parts, "Reset to "+m.result.Upstream)
+	}
+	switch m.result.Pulled {
+	case 0:
+	case 1:
+		parts = append(parts, "Pulled 1 commit")
+	default:
+		parts = append(parts, fmt.Sprintf("Pulled %d commits", m.result.Pulled))
+	}
+	switch {
+	case m.result.Head == "":
+	case m.result.PreviousHead == "" || m.result.PreviousHead == m.result.Head:
+		parts = append(parts, "HEAD "+m.result.Head)
+	default:
+		parts = append(parts, fmt.Sprintf("HEAD %s → %s", m.result.PreviousHead, m.result.Head))
+	}
+	if m.result.Prefetched {
+		parts = append(parts, "head was prefetched")
+	}
+	if m.result.StashedFrom != "" && m.result.StashedFrom != m.result.Branch {
+		parts = append(parts, "stashed changes return with "+m.result.StashedFrom)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// plural formats n with noun, adding an s unless n is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// progressBar renders percent as a bar of the given width
+func progressBar(percent, width int) string {
+	filled := percent * width / 100
+	filled = max(0, min(filled, width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// Cancel stops the running checkout. The modal reports what state the repo
+// was left in once git has exited. A pending reset is declined.
+func (m *CheckoutModal) Cancel() tea.Cmd {
+	if m.state == CheckoutConfirmReset {
+		m.state = CheckoutInProgress
+		return m.finishReset(false)
+	}
+	if m.state != CheckoutInProgress {
+		return nil
+	}
+	m.state = CheckoutCancelling
+	m.cancel()
+	return nil
+}
+
+// IsDone returns true if checkout is complete (success, error or cancelled)
 func (m CheckoutModal) IsDone() bool {
-	return m.state == CheckoutDone || m.state == CheckoutError
+	return m.state == CheckoutDone || m.state == CheckoutError || m.state == CheckoutCancelled
+}
+
+// Worktree returns the worktree a completed checkout used, empty for the main one
+func (m CheckoutModal) Worktree() string {
+	if m.state != CheckoutDone {
+		return ""
+	}
+	return m.result.Worktree
+}
+
+// MR returns the MR being checked out, nil for a branch
+func (m CheckoutModal) MR() *platform.MR {
+	return m.mr
 }
 
 // HasError returns true if checkout failed
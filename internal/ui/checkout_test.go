@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestCheckoutModalSteps(t *testing.T) {
+	m := NewCheckoutModal(context.Background(), platform.MR{Number: 7, Title: "Fix", Branch: "fix"}, t.TempDir())
+
+	updates := []git.Progress{
+		{Step: "fetch", State: git.StepRunning, Label: "Fetching origin", Percent: -1},
+		{Step: "fetch", State: git.StepRunning, Label: "Fetching origin", Phase: "Receiving objects", Percent: 45},
+	}
+	for _, p := range updates {
+		m, _ = m.Update(CheckoutProgressMsg{Progress: p})
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "Fetching origin...") || !strings.Contains(view, "Receiving objects  45%") {
+		t.Errorf("expected the running fetch with its progress, got:\n%s", view)
+	}
+
+	m, _ = m.Update(CheckoutProgressMsg{Progress: git.Progress{Step: "fetch", State: git.StepDone, Label: "Fetched origin", Percent: -1}})
+	m, _ = m.Update(CheckoutProgressMsg{Progress: git.Progress{Step: "pull", State: git.StepDone, Label: "Pulled 3 commits", Percent: -1}})
+	m, _ = m.Update(CheckoutCompleteMsg{Result: git.CheckoutResult{Branch: "fix", PreviousHead: "abc1234", Head: "def5678", Pulled: 3}})
+
+	view = ansi.Strip(m.View())
+	for _, want := range []string{"✓ Fetched origin", "✓ Pulled 3 commits", "Pulled 3 commits · HEAD abc1234 → def5678"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Receiving objects") {
+		t.Error("progress of a finished step should not be shown")
+	}
+}
+
+func TestCheckoutModalCancel(t *testing.T) {
+	m := NewBranchCheckoutModal(context.Background(), "main", t.TempDir())
+	m, _ = m.Update(CheckoutProgressMsg{Progress: git.Progress{Step: "fetch", State: git.StepRunning, Label: "Fetching origin", Percent: -1}})
+
+	m.Cancel()
+	if m.IsDone() {
+		t.Fatal("modal should wait for git to exit before it is done")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Cancelling...") {
+		t.Errorf("expected cancelling state, got:\n%s", view)
+	}
+
+	err := &git.CheckoutError{Step: "fetch", Err: fmt.Errorf("git cancelled: %w", context.Canceled)}
+	state := &git.RepoState{Branch: "main", Head: "abc1234", IndexLock: true}
+	m, _ = m.Update(CheckoutCompleteMsg{Err: err, State: state})
+
+	if !m.IsDone() || m.HasError() {
+		t.Fatal("a cancelled checkout should be done without an error")
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Cancelled during fetch", "On main at abc1234", "stale index.lock"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+}
+
+func TestCheckoutModalConfirmReset(t *testing.T) {
+	m := NewBranchCheckoutModal(context.Background(), "feature", t.TempDir())
+	m, _ = m.Update(CheckoutCompleteMsg{Result: git.CheckoutResult{
+		Branch:       "feature",
+		Upstream:     "origin/feature",
+		RemoteBefore: "1111111aaaa",
+		RemoteAfter:  "2222222bbbb",
+		ForcePushed:  true,
+		NeedsReset:   true,
+		LocalOnly:    2,
+	}})
+	if m.IsDone() {
+		t.Fatal("checkout should wait for the reset to be confirmed")
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"origin/feature was force-pushed", "Reset feature to origin/feature (2222222)? This drops 2 local commits.", "[y] Reset"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+}
+
+func TestCheckoutModalRestoresStashOnFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(kv, "test")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(kv, "test@example.com")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "initial"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// There's no origin to fetch the MR's branch from
+	m := NewCheckoutModal(context.Background(), platform.MR{Number: 7, Branch: "feature"}, repo).WithStash(true)
+	msg := m.doCheckout()()
+	for {
+		var next tea.Cmd
+		m, next = m.Update(msg)
+		if _, ok := msg.(CheckoutCompleteMsg); ok {
+			break
+		}
+		msg = next()
+	}
+
+	if !m.HasError() {
+		t.Fatalf("expected the checkout to fail, got:\n%s", ansi.Strip(m.View()))
+	}
+	out, err := exec.Command("git", "-C", repo, "status", "--porcelain").Output()
+	if err != nil || !strings.Contains(string(out), "notes.txt") {
+		t.Errorf("expected notes.txt back in the worktree, git status: %q, %v", out, err)
+	}
+	if view := ansi.Strip(m.View()); strings.Contains(view, "stashed as") {
+		t.Errorf("restored changes reported as stashed:\n%s", view)
+	}
+}
+
+func TestCheckoutModalStashNote(t *testing.T) {
+	m := NewBranchCheckoutModal(context.Background(), "feature", t.TempDir())
+	m, _ = m.Update(CheckoutCompleteMsg{
+		Result: git.CheckoutResult{StashedFrom: "main"},
+		Err:    &git.CheckoutError{Step: "checkout", Err: fmt.Errorf("branch is checked out elsewhere")},
+	})
+	view := ansi.Strip(m.View())
+	for _, want := range []string{`Your changes are stashed as "gq autostash from main"`, "check main out again"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+}
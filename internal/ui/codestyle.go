@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/highlight"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+)
+
+// codePalette holds the colors used to render source lines in diffs.
+// A nil color means "leave the terminal default".
+type codePalette struct {
+	syntax            map[highlight.TokenKind]color.Color
+	syntaxOnChanges   bool // keep syntax colors on added/removed lines
+	addedFg           color.Color
+	removedFg         color.Color
+	addedBg           color.Color
+	removedBg         color.Color
+	addedEmphasisBg   color.Color
+	removedEmphasisBg color.Color
+	reverseEmphasis   bool // emphasise changed words with reverse video instead of a background
+}
+
+// paletteFor picks a palette that suits the terminal's color profile.
+// 256-color and truecolor terminals get the theme's syntax colors layered
+// under its tinted add/delete backgrounds; 16-color terminals get plain
+// green/red lines with reverse-video word emphasis, since their backgrounds
+// are too saturated to read text on, and the terminal's own colors follow its
+// theme; terminals without color only get the emphasis.
+func paletteFor(profile colorprofile.Profile) codePalette {
+	switch profile {
+	case colorprofile.NoTTY, colorprofile.ASCII:
+		return codePalette{reverseEmphasis: true}
+	case colorprofile.ANSI:
+		return codePalette{
+			syntax: map[highlight.TokenKind]color.Color{
+				highlight.Keyword: lipgloss.Color("4"),
+				highlight.Type:    lipgloss.Color("6"),
+				highlight.String:  lipgloss.Color("3"),
+				highlight.Number:  lipgloss.Color("5"),
+				highlight.Comment: lipgloss.Color("8"),
+			},
+			addedFg:         lipgloss.Color("2"),
+			removedFg:       lipgloss.Color("1"),
+			reverseEmphasis: true,
+		}
+	default:
+		return codePalette{
+			syntax: map[highlight.TokenKind]color.Color{
+				highlight.Keyword:  paletteColor(palette.SyntaxKeyword),
+				highlight.Type:     paletteColor(palette.SyntaxType),
+				highlight.String:   paletteColor(palette.SyntaxString),
+				highlight.Number:   paletteColor(palette.SyntaxNumber),
+				highlight.Comment:  paletteColor(palette.SyntaxComment),
+				highlight.Function: paletteColor(palette.SyntaxFunction),
+			},
+			syntaxOnChanges:   true,
+			addedFg:           paletteColor(palette.Success),
+			removedFg:         paletteColor(palette.Error),
+			addedBg:           paletteColor(palette.AddedBg),
+			removedBg:         paletteColor(palette.RemovedBg),
+			addedEmphasisBg:   paletteColor(palette.AddedEmphasisBg),
+			removedEmphasisBg: paletteColor(palette.RemovedEmphasisBg),
+		}
+	}
+}
+
+// paletteColor returns a color of the active theme, nil if the theme leaves
+// it to the terminal
+func paletteColor(s string) color.Color {
+	if s == "" {
+		return nil
+	}
+	return lipgloss.Color(s)
+}
+
+// renderCode renders one source line: syntax tokens, the add/delete line
+// coloring on top, and emphasis for the changed word spans
+func renderCode(p codePalette, kind diff.LineKind, tokens []highlight.Token, spans []diff.Span) string {
+	var lineFg, lineBg, emphasisBg color.Color
+	switch kind {
+	case diff.Added:
+		lineFg, lineBg, emphasisBg = p.addedFg, p.addedBg, p.addedEmphasisBg
+	case diff.Removed:
+		lineFg, lineBg, emphasisBg = p.removedFg, p.removedBg, p.removedEmphasisBg
+	}
+	useSyntax := kind == diff.Context || p.syntaxOnChanges
+
+	var b strings.Builder
+	pos := 0
+	for _, tok := range tokens {
+		for len(tok.Text) > 0 {
+			// Split the token where emphasis starts or stops
+			emphasised, until := spanState(spans, pos)
+			n := len(tok.Text)
+			if until > pos && until-pos < n {
+				n = until - pos
+			}
+			piece := tok.Text[:n]
+
+			style := lipgloss.NewStyle()
+			if fg := p.syntax[tok.Kind]; useSyntax && fg != nil {
+				style = style.Foreground(fg)
+			} else if lineFg != nil {
+				style = style.Foreground(lineFg)
+			} else if text := paletteColor(palette.Text); kind == diff.Context && text != nil {
+				style = style.Foreground(text)
+			}
+			bg := lineBg
+			if emphasised {
+				if p.reverseEmphasis {
+					style = style.Reverse(true)
+				} else if emphasisBg != nil {
+					bg = emphasisBg
+				}
+			}
+			if bg != nil {
+				style = style.Background(bg)
+			}
+			b.WriteString(style.Render(expandTabs(piece)))
+
+			tok.Text = tok.Text[n:]
+			pos += n
+		}
+	}
+	return b.String()
+}
+
+// spanState reports whether pos falls inside one of the spans and the byte
+// offset at which that changes (0 if it never does)
+func spanState(spans []diff.Span, pos int) (inside bool, until int) {
+	for _, s := range spans {
+		if pos < s.Start {
+			return false, s.Start
+		}
+		if pos < s.End {
+			return true, s.End
+		}
+	}
+	return false, 0
+}
+
+// markerStyle returns the style for the +/- marker of a line
+func markerStyle(p codePalette, kind diff.LineKind) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch kind {
+	case diff.Added:
+		if p.addedFg != nil {
+			style = style.Foreground(p.addedFg)
+		}
+		if p.addedBg != nil {
+			style = style.Background(p.addedBg)
+		}
+	case diff.Removed:
+		if p.removedFg != nil {
+			style = style.Foreground(p.removedFg)
+		}
+		if p.removedBg != nil {
+			style = style.Background(p.removedBg)
+		}
+	}
+	return style
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/highlight"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderCode_Profiles(t *testing.T) {
+	tokens := highlight.Line(highlight.Detect("main.go"), "return foo(a)", nil)
+	spans := []diff.Span{{Start: 7, End: 10}}
+
+	tests := []struct {
+		profile     colorprofile.Profile
+		wantBg      bool // 256-color backgrounds for the line tint
+		wantReverse bool // reverse video for the changed word
+	}{
+		{colorprofile.TrueColor, true, false},
+		{colorprofile.ANSI256, true, false},
+		{colorprofile.ANSI, false, true},
+		{colorprofile.ASCII, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile.String(), func(t *testing.T) {
+			out := renderCode(paletteFor(tt.profile), diff.Added, tokens, spans)
+
+			if got := ansi.Strip(out); got != "return foo(a)" {
+				t.Errorf("text changed: got %q", got)
+			}
+			if hasBg := strings.Contains(out, "48;5;"); hasBg != tt.wantBg {
+				t.Errorf("background tint: got %v, want %v in %q", hasBg, tt.wantBg, out)
+			}
+			if hasReverse := strings.Contains(out, "\x1b[7"); hasReverse != tt.wantReverse {
+				t.Errorf("reverse emphasis: got %v, want %v in %q", hasReverse, tt.wantReverse, out)
+			}
+		})
+	}
+}
@@ -43,7 +43,7 @@ func NewCommitsViewer(title string, commits []platform.Commit, width, height int
 
 	vp := viewport.New(viewport.WithWidth(contentWidth), viewport.WithHeight(modalHeight-4)) // Leave room for title and footer
 	vp.SetContent(content)
-	vp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	vp.Style = TextStyle
 
 	return CommitsViewer{
 		title:    title,
@@ -67,7 +67,7 @@ func formatCommits(commits []platform.Commit, width int) string {
 		sha := shaStyle.Render(c.SHA)
 
 		// Author in dim
-		authorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+		authorStyle := lipgloss.NewStyle().Foreground(dimColor)
 		author := authorStyle.Render(c.Author)
 
 		// Calculate max message length: width - sha(7) - spaces(4) - author
@@ -79,7 +79,7 @@ func formatCommits(commits []platform.Commit, width int) string {
 		if len(msg) > maxMsgLen {
 			msg = msg[:maxMsgLen-3] + "..."
 		}
-		msgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+		msgStyle := lipgloss.NewStyle().Foreground(brightColor)
 		message := msgStyle.Render(msg)
 
 		// Single line: SHA message (author)
@@ -1,16 +1,23 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
 	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+	"github.com/Constantine-Kostikas/GitQuick/internal/webhook"
 
+	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 )
@@ -32,19 +39,41 @@ type PendingCheckout struct {
 
 // Dashboard is the main UI component
 type Dashboard struct {
+	ctx             context.Context // cancelled on quit, stopping any running command
+	cancel          context.CancelFunc
 	platform        platform.Platform
 	repoInfo        platform.RepoInfo
 	repoPath        string
 	currentBranch   string
 	author          string
+	defaultAuthor   string // the configured author, listed when nothing is picked
 	authors         []platform.Author
-	authorPicker    *AuthorPicker
+	labels          []platform.Label
+	authorPicker    *Picker
+	historyPicker   *Picker  // recently checked out branches
+	editPicker      *Picker  // reviewers/assignees/labels picker over the MR detail
+	editKind        EditKind // what editPicker edits, or what is waiting for labels to load
 	activeTab       Tab
 	mrList          MRList
 	mrDetail        *MRDetailModal
 	checkout        *CheckoutModal
+	update          *UpdateModal // updating the current branch from the default branch
 	dirtyConfirm    *DirtyConfirmModal
+	workingDiff     *DiffViewer // uncommitted changes, opened from the dirty confirm
 	pendingCheckout *PendingCheckout
+	worktrees       *WorktreeModal
+	help            *HelpModal // every key binding, over whatever is open
+	keys            keymap.Keymap
+	worktreeDir     string // where MR worktrees are created
+	tracker         config.Tracker
+	ticketRe        *regexp.Regexp   // finds the ticket in an MR title
+	prefetching     bool             // a background prefetch is running
+	cache           *platform.Cached // nil when results aren't cached
+	staleSince      time.Time        // when the listed MRs were cached, zero once loaded
+	listed          bool             // the list shows the author's MRs, loaded or cached
+	pollEvery       time.Duration    // how often the list reloads by itself, 0 never
+	polling         bool             // a background reload is running
+	diffOptions     DiffOptions
 	width           int
 	height          int
 	err             error
@@ -54,8 +83,17 @@ type Dashboard struct {
 
 // MRsLoadedMsg is sent when MRs are loaded
 type MRsLoadedMsg struct {
-	MRs []platform.MR
-	Err error
+	Author string // whose MRs they are
+	MRs    []platform.MR
+	Err    error
+}
+
+// pollMsg reloads the MR list in the background
+type pollMsg struct{}
+
+// WebhookMsg is sent when a webhook reports an event on an MR of the repository
+type WebhookMsg struct {
+	Event webhook.Event
 }
 
 // RepoInfoLoadedMsg is sent when repo info is loaded
@@ -70,6 +108,12 @@ type AuthorsLoadedMsg struct {
 	Err     error
 }
 
+// LabelsLoadedMsg is sent when repository labels are loaded
+type LabelsLoadedMsg struct {
+	Labels []platform.Label
+	Err    error
+}
+
 // BranchLoadedMsg is sent when current branch is loaded
 type BranchLoadedMsg struct {
 	Branch string
@@ -78,7 +122,19 @@ type BranchLoadedMsg struct {
 
 // DirtyCheckMsg is sent when dirty check completes
 type DirtyCheckMsg struct {
-	IsDirty bool
+	Files []git.FileStatus
+	Err   error
+}
+
+// WorkingDiffLoadedMsg is sent when the patch of uncommitted changes is loaded
+type WorkingDiffLoadedMsg struct {
+	Patch string
+	Err   error
+}
+
+// PrefetchDoneMsg is sent when a background prefetch of MR heads finishes
+type PrefetchDoneMsg struct {
+	Fetched int
 	Err     error
 }
 
@@ -86,14 +142,54 @@ type DirtyCheckMsg struct {
 type ClearStatusMsg struct{}
 
 // NewDashboard creates a new dashboard
-func NewDashboard(p platform.Platform, repoPath string) Dashboard {
-	return Dashboard{
-		platform:  p,
-		repoPath:  repoPath,
-		author:    "@me",
-		activeTab: TabMRs,
-		mrList:    NewMRList(nil, 80, 20),
-		loading:   true,
+func NewDashboard(p platform.Platform, repoPath string, cfg config.Config) Dashboard {
+	ctx, cancel := context.WithCancel(context.Background())
+	worktreeDir := cfg.Defaults.WorktreeDir
+	if worktreeDir == "" {
+		worktreeDir = git.DefaultWorktreeDir(repoPath)
+	}
+	// Load already reported invalid bindings, the keymap keeps the valid ones
+	keys, _ := keymap.New(cfg.Keys)
+	cached, _ := p.(*platform.Cached)
+	d := Dashboard{
+		ctx:           ctx,
+		cancel:        cancel,
+		worktreeDir:   worktreeDir,
+		tracker:       cfg.Tracker,
+		ticketRe:      regexp.MustCompile(cfg.Tracker.Pattern),
+		platform:      p,
+		repoPath:      repoPath,
+		author:        cfg.Defaults.Author,
+		defaultAuthor: cfg.Defaults.Author,
+		activeTab:     TabMRs,
+		mrList:        NewMRList(nil, 80, 20).WithKeys(keys),
+		keys:          keys,
+		diffOptions:   DiffOptions{Split: cfg.UI.SideBySide, Wrap: cfg.UI.Wrap},
+		loading:       true,
+		cache:         cached,
+	}
+	if cfg.Poll.Enabled && !cfg.Webhook.Enabled {
+		// Webhooks already say when the list changes
+		d.pollEvery = cfg.Poll.Interval
+	}
+	// Show the last run's results right away, the loads in Init refresh them
+	if info, ok := cached.CachedRepoInfo(); ok {
+		d.repoInfo = info
+	}
+	if authors, ok := cached.CachedAuthors(); ok {
+		d.authors = authors
+	}
+	d.showCachedMRs()
+	return d
+}
+
+// showCachedMRs lists the author's cached MRs, marked stale until they load
+func (d *Dashboard) showCachedMRs() {
+	if mrs, saved, ok := d.cache.CachedMRs(d.author); ok {
+		d.mrList.SetItems(mrs)
+		d.loading = false
+		d.staleSince = saved
+		d.listed = true
 	}
 }
 
@@ -104,98 +200,481 @@ func (d Dashboard) Init() tea.Cmd {
 		d.loadMRs(),
 		d.loadAuthors(),
 		d.loadBranch(),
+		d.schedulePoll(),
 	)
 }
 
+// schedulePoll reloads the MR list after the poll interval
+func (d Dashboard) schedulePoll() tea.Cmd {
+	if d.pollEvery <= 0 {
+		return nil
+	}
+	return tea.Tick(d.pollEvery, func(time.Time) tea.Msg { return pollMsg{} })
+}
+
+// poll reloads the MR list in the background and schedules the next poll
+func (d Dashboard) poll() (tea.Model, tea.Cmd) {
+	// Skip a beat while another load is running
+	if d.loading || d.polling || !d.listed {
+		return d, d.schedulePoll()
+	}
+	d.polling = true
+	d.cache.InvalidateMRs()
+	return d, tea.Batch(d.loadMRs(), d.schedulePoll())
+}
+
+// mrsLoaded lists loaded MRs, highlighting what changed on a reload
+func (d Dashboard) mrsLoaded(msg MRsLoadedMsg) (tea.Model, tea.Cmd) {
+	d.polling = false
+	if msg.Author != d.author {
+		// Loaded before the author changed
+		return d, nil
+	}
+	d.loading = false
+	if msg.Err != nil && d.listed {
+		// Keep showing the MRs already listed
+		d.statusMsg = "Refresh failed: " + DescribeError(msg.Err)
+		return d, clearStatusAfter(3 * time.Second)
+	}
+	d.staleSince = time.Time{}
+	if msg.Err != nil {
+		d.err = msg.Err
+		return d, nil
+	}
+
+	var cmds []tea.Cmd
+	if d.listed {
+		changed := d.mrList.UpdateItems(msg.MRs)
+		for number, change := range changed {
+			if change&(MRPushed|MRStatusChanged) != 0 {
+				// The cached detail shows the MR as it was
+				d.cache.InvalidateMRDetail(number)
+			}
+		}
+		if len(changed) > 0 {
+			d.statusMsg = plural(len(changed), "MR") + " changed"
+			cmds = append(cmds, clearStatusAfter(3*time.Second))
+		}
+	} else {
+		d.mrList.SetItems(msg.MRs)
+	}
+	d.listed = true
+	if !d.prefetching {
+		d.prefetching = true
+		cmds = append(cmds, d.prefetchMRs(d.mrList.VisibleMRs()))
+	}
+	return d, tea.Batch(cmds...)
+}
+
+// webhookEvent updates the listed MR a webhook reported on in place. An open
+// MR that isn't listed may belong in the list, it's reloaded to find out.
+func (d Dashboard) webhookEvent(e webhook.Event) (tea.Model, tea.Cmd) {
+	d.cache.InvalidateMR(e.MR.Number)
+	if !d.listed {
+		return d, nil
+	}
+	switch e.Kind {
+	case webhook.KindMR:
+		open := e.MR.Status == "open" || e.MR.Status == "draft"
+		if !d.mrList.UpdateMR(e.MR) && open && !d.loading && !d.polling {
+			d.polling = true
+			return d, d.loadMRs()
+		}
+	case webhook.KindReview:
+		d.mrList.Mark(e.MR.Number, MRMark{Change: MRReviewed, Review: e.State})
+	case webhook.KindPipeline:
+		d.mrList.Mark(e.MR.Number, MRMark{Change: MRPipeline, Pipeline: e.State})
+	}
+	return d, nil
+}
+
+// mrContext returns the context for loads that belong to the open MR detail,
+// which are cancelled when it closes
+func (d Dashboard) mrContext() context.Context {
+	if d.mrDetail != nil {
+		return d.mrDetail.Context()
+	}
+	return d.ctx
+}
+
+// quit stops any running command and exits
+func (d Dashboard) quit() tea.Cmd {
+	d.cancel()
+	return tea.Quit
+}
+
 func (d Dashboard) loadRepoInfo() tea.Cmd {
+	ctx := d.ctx
 	return func() tea.Msg {
-		info, err := d.platform.GetRepoInfo()
+		info, err := d.platform.GetRepoInfo(ctx)
 		return RepoInfoLoadedMsg{Info: info, Err: err}
 	}
 }
 
+// loadMRs loads the author's MRs. Stale cached MRs on screen are replaced
+// from the forge, however fresh the cache says they are.
 func (d Dashboard) loadMRs() tea.Cmd {
+	ctx := d.ctx
+	author := d.author
+	list := d.platform.ListMRs
+	if d.cache != nil && !d.staleSince.IsZero() {
+		list = d.cache.RefreshMRs
+	}
 	return func() tea.Msg {
-		mrs, err := d.platform.ListMRs(d.author)
-		return MRsLoadedMsg{MRs: mrs, Err: err}
+		mrs, err := list(ctx, author)
+		return MRsLoadedMsg{Author: author, MRs: mrs, Err: err}
 	}
 }
 
 func (d Dashboard) loadAuthors() tea.Cmd {
+	ctx := d.ctx
 	return func() tea.Msg {
-		authors, err := d.platform.ListAuthors()
+		authors, err := d.platform.ListAuthors(ctx)
 		return AuthorsLoadedMsg{Authors: authors, Err: err}
 	}
 }
 
+func (d Dashboard) loadMRDiff(number int) tea.Cmd {
+	ctx := d.mrContext()
+	return func() tea.Msg {
+		patch, err := d.platform.GetMRDiff(ctx, number)
+		return MRDiffLoadedMsg{Patch: patch, Err: err}
+	}
+}
+
+func (d Dashboard) loadReviewComments(number int) tea.Cmd {
+	ctx := d.mrContext()
+	return func() tea.Msg {
+		comments, err := d.platform.ListReviewComments(ctx, number)
+		return ReviewCommentsLoadedMsg{Comments: comments, Err: err}
+	}
+}
+
+func (d Dashboard) submitReview(number int, comments []platform.ReviewComment) tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		err := d.platform.SubmitReview(ctx, number, comments)
+		return ReviewSubmittedMsg{Count: len(comments), Err: err}
+	}
+}
+
+func (d Dashboard) loadMRFileContent(number int, path string) tea.Cmd {
+	ctx := d.mrContext()
+	return func() tea.Msg {
+		content, err := d.platform.GetMRFileContent(ctx, number, path)
+		return MRFileContentLoadedMsg{Path: path, Content: content, Err: err}
+	}
+}
+
+func (d Dashboard) loadLabels() tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		labels, err := d.platform.ListLabels(ctx)
+		return LabelsLoadedMsg{Labels: labels, Err: err}
+	}
+}
+
+// applyEdit adds and removes reviewers, assignees or labels on an MR
+func (d Dashboard) applyEdit(kind EditKind, number int, add, remove []string) tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		var err error
+		switch kind {
+		case EditReviewers:
+			err = d.platform.EditReviewers(ctx, number, add, remove)
+		case EditAssignees:
+			err = d.platform.EditAssignees(ctx, number, add, remove)
+		case EditLabels:
+			err = d.platform.EditLabels(ctx, number, add, remove)
+		}
+		return MREditedMsg{Kind: kind, Err: err}
+	}
+}
+
 func (d Dashboard) loadBranch() tea.Cmd {
+	ctx := d.ctx
 	return func() tea.Msg {
-		branch, err := git.GetCurrentBranch(d.repoPath)
+		branch, err := git.GetCurrentBranch(ctx, d.repoPath)
 		return BranchLoadedMsg{Branch: branch, Err: err}
 	}
 }
 
+// loadMRDetail loads an MR's detail from the forge, a cached one only being
+// shown until it arrives
 func (d Dashboard) loadMRDetail(number int) tea.Cmd {
+	ctx := d.mrContext()
+	get := d.platform.GetMRDetail
+	if d.cache != nil {
+		get = d.cache.RefreshMRDetail
+	}
 	return func() tea.Msg {
-		detail, err := d.platform.GetMRDetail(number)
+		detail, err := get(ctx, number)
 		return MRDetailLoadedMsg{Detail: detail, Err: err}
 	}
 }
 
 func (d Dashboard) checkDirty() tea.Cmd {
+	ctx := d.ctx
 	return func() tea.Msg {
-		isDirty, err := git.IsDirty(d.repoPath)
-		return DirtyCheckMsg{IsDirty: isDirty, Err: err}
+		files, err := git.Status(ctx, d.repoPath)
+		return DirtyCheckMsg{Files: files, Err: err}
 	}
 }
 
+func (d Dashboard) loadWorkingDiff() tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		patch, err := git.WorkingDiff(ctx, d.repoPath)
+		return WorkingDiffLoadedMsg{Patch: patch, Err: err}
+	}
+}
+
+func (d Dashboard) loadWorktrees() tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		worktrees, err := git.ListWorktrees(ctx, d.repoPath)
+		return WorktreesLoadedMsg{Worktrees: worktrees, Err: err}
+	}
+}
+
+func (d Dashboard) loadWorktreeStatus(number int) tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		status, err := d.platform.GetMRStatus(ctx, number)
+		return WorktreeStatusMsg{Number: number, Status: status, Err: err}
+	}
+}
+
+func (d Dashboard) removeWorktree(path string) tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		return WorktreeRemovedMsg{Path: path, Err: git.RemoveWorktree(ctx, d.repoPath, path)}
+	}
+}
+
+// prefetchMRs fetches the heads of the MRs the repo's gq.prefetch setting
+// asks for in the background, the top of visible or those awaiting review
+func (d Dashboard) prefetchMRs(visible []platform.MR) tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		config, err := git.GetPrefetchConfig(ctx, d.repoPath)
+		if err != nil || !config.Enabled() {
+			return PrefetchDoneMsg{Err: err}
+		}
+		mrs := visible[:min(config.Top, len(visible))]
+		if config.Review {
+			if mrs, err = d.platform.ListReviewRequested(ctx); err != nil {
+				return PrefetchDoneMsg{Err: err}
+			}
+		}
+		var heads []git.MRHead
+		for _, mr := range mrs {
+			if mr.HeadRef != "" && (mr.Status == "open" || mr.Status == "draft") {
+				heads = append(heads, git.MRHead{Number: mr.Number, Ref: mr.HeadRef})
+			}
+		}
+		fetched, err := git.Prefetch(ctx, d.repoPath, heads, git.PrefetchJobs)
+		return PrefetchDoneMsg{Fetched: fetched, Err: err}
+	}
+}
+
+func (d Dashboard) loadPreviousBranch() tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		branch, err := git.PreviousBranch(ctx, d.repoPath, d.currentBranch)
+		return PreviousBranchMsg{Branch: branch, Err: err}
+	}
+}
+
+func (d Dashboard) loadHistory() tea.Cmd {
+	ctx := d.ctx
+	return func() tea.Msg {
+		entries, err := git.LoadHistory(ctx, d.repoPath)
+		return HistoryLoadedMsg{Entries: entries, Err: err}
+	}
+}
+
+// checkoutBranch checks out branch through the dirty check
+func (d *Dashboard) checkoutBranch(branch string) tea.Cmd {
+	d.pendingCheckout = &PendingCheckout{MR: nil, Branch: branch}
+	return d.checkDirty()
+}
+
+// startCheckout opens the checkout modal for the pending checkout
+func (d *Dashboard) startCheckout(stash, untracked bool) tea.Cmd {
+	var checkout CheckoutModal
+	if d.pendingCheckout.MR != nil {
+		checkout = NewCheckoutModal(d.ctx, *d.pendingCheckout.MR, d.repoPath)
+	} else {
+		checkout = NewBranchCheckoutModal(d.ctx, d.pendingCheckout.Branch, d.repoPath)
+	}
+	checkout = checkout.WithBaseBranch(d.repoInfo.DefaultBranch).WithKeys(d.keys)
+	if stash {
+		checkout = checkout.WithStash(untracked)
+	}
+	d.checkout = &checkout
+	d.pendingCheckout = nil
+	return d.checkout.Init()
+}
+
 func (d Dashboard) loadMRCommits(number int) tea.Cmd {
+	ctx := d.mrContext()
 	return func() tea.Msg {
-		commits, err := d.platform.GetMRCommits(number)
+		commits, err := d.platform.GetMRCommits(ctx, number)
 		return MRCommitsLoadedMsg{Commits: commits, Err: err}
 	}
 }
 
 // Update handles messages
 func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The color profile is reported once at startup and applies to every view
+	if profile, ok := msg.(tea.ColorProfileMsg); ok {
+		d.diffOptions.Profile = profile.Profile
+		return d, nil
+	}
+
+	// Resizes reach the list and the MR detail even while a modal is open
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		d.width = size.Width
+		d.height = size.Height
+		d.mrList.SetSize(size.Width-4, size.Height-10)
+		if d.mrDetail != nil {
+			newDetail, _ := d.mrDetail.Update(size)
+			d.mrDetail = &newDetail
+		}
+		if d.workingDiff != nil {
+			newViewer, _ := d.workingDiff.Update(size)
+			d.workingDiff = &newViewer
+		}
+		return d, nil
+	}
+
+	// The MR list reloads behind whatever is open
+	switch msg := msg.(type) {
+	case pollMsg:
+		return d.poll()
+	case MRsLoadedMsg:
+		return d.mrsLoaded(msg)
+	case WebhookMsg:
+		return d.webhookEvent(msg.Event)
+	}
+
+	// A shell or editor started from gq has exited and the TUI is back
+	if finished, ok := msg.(ExecFinishedMsg); ok {
+		if d.update != nil {
+			// The shell was for resolving conflicts, see how far the user got
+			return d, tea.Batch(d.update.Recheck(), d.loadBranch())
+		}
+		if finished.Err != nil {
+			d.statusMsg = "Command failed: " + finished.Err.Error()
+			return d, tea.Batch(d.loadBranch(), clearStatusAfter(3*time.Second))
+		}
+		return d, d.loadBranch()
+	}
+
+	// The help sits on top of everything, any key but tab closes it
+	if d.help != nil {
+		if key, ok := msg.(tea.KeyPressMsg); ok {
+			if key.String() == "ctrl+c" {
+				return d, d.quit()
+			}
+			help, closed := d.help.Update(key)
+			d.help = &help
+			if closed {
+				d.help = nil
+			}
+			return d, nil
+		}
+	}
+
+	// The working tree diff sits on top of the dirty confirm
+	if d.workingDiff != nil {
+		if key, ok := msg.(tea.KeyPressMsg); ok && d.keys.Matches(key.String(), keymap.DiffClose) {
+			d.workingDiff = nil
+			return d, nil
+		}
+		newViewer, cmd := d.workingDiff.Update(msg)
+		d.workingDiff = &newViewer
+		d.diffOptions = d.workingDiff.Options()
+		return d, cmd
+	}
+
 	// If dirty confirm modal is active, delegate to it
 	if d.dirtyConfirm != nil {
 		newConfirm, cmd := d.dirtyConfirm.Update(msg)
 		d.dirtyConfirm = &newConfirm
 
-		if d.dirtyConfirm.IsConfirmed() {
-			// User confirmed, proceed with checkout
+		if loaded, ok := msg.(WorkingDiffLoadedMsg); ok {
+			return d.openWorkingDiff(loaded)
+		}
+		if d.dirtyConfirm.TakeDiffRequest() {
+			return d, d.loadWorkingDiff()
+		}
+
+		switch choice := d.dirtyConfirm.Choice(); choice {
+		case DirtyCarry, DirtyStash:
+			// Stashing an untracked-only tree has to include untracked files
+			untracked := d.dirtyConfirm.OnlyUntracked()
 			d.dirtyConfirm = nil
 			if d.pendingCheckout != nil {
-				if d.pendingCheckout.MR != nil {
-					checkout := NewCheckoutModal(*d.pendingCheckout.MR, d.repoPath)
-					d.checkout = &checkout
-				} else {
-					checkout := NewBranchCheckoutModal(d.pendingCheckout.Branch, d.repoPath)
-					d.checkout = &checkout
-				}
-				d.pendingCheckout = nil
-				return d, d.checkout.Init()
+				return d, d.startCheckout(choice == DirtyStash, untracked)
 			}
-		} else if d.dirtyConfirm.IsCancelled() {
-			// User cancelled
+		case DirtyCancel:
 			d.dirtyConfirm = nil
 			d.pendingCheckout = nil
 		}
 		return d, cmd
 	}
 
+	// If the update modal is active, keys and its own messages go to it
+	if d.update != nil {
+		switch msg.(type) {
+		case tea.KeyPressMsg, spinner.TickMsg, UpdateProgressMsg, UpdateCompleteMsg, UpdateAbortedMsg, PushCompleteMsg:
+			if key, ok := msg.(tea.KeyPressMsg); ok && key.String() == "ctrl+c" {
+				return d, d.quit()
+			}
+			newUpdate, cmd := d.update.Update(msg)
+			d.update = &newUpdate
+			if d.update.Closed() {
+				d.update.Close()
+				d.update = nil
+				return d, d.loadBranch()
+			}
+			if d.update.TakeShellRequest() {
+				return d, tea.Batch(cmd, execIn(execRequest{dir: d.repoPath, program: "shell"}))
+			}
+			return d, cmd
+		}
+	}
+
 	// If checkout modal is active, delegate to it
 	if d.checkout != nil {
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
 			if d.checkout.IsDone() {
+				worktree := d.checkout.Worktree()
 				d.checkout = nil
+				switch key := msg.String(); {
+				case d.keys.Matches(key, keymap.CheckoutShell):
+					if worktree != "" {
+						return d, execIn(execRequest{dir: worktree, program: "shell"})
+					}
+				case d.keys.Matches(key, keymap.CheckoutEditor):
+					if worktree != "" {
+						return d, execIn(execRequest{dir: worktree, program: "editor"})
+					}
+				}
 				return d, d.loadBranch()
 			}
-			if msg.String() == "esc" {
-				d.checkout = nil
-				return d, nil
+			if d.keys.Matches(msg.String(), keymap.CheckoutCancel) {
+				// Stop git and wait for it to exit, the modal then shows where the repo was left
+				return d, d.checkout.Cancel()
+			}
+		}
+		if done, ok := msg.(CheckoutCompleteMsg); ok && done.Err == nil {
+			// Reload the MR after checking it out rather than trusting the cache
+			if mr := d.checkout.MR(); mr != nil {
+				d.cache.InvalidateMR(mr.Number)
 			}
 		}
 		newCheckout, cmd := d.checkout.Update(msg)
@@ -203,17 +682,61 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return d, cmd
 	}
 
+	// If the edit picker is open over the MR detail, it gets all keys
+	if d.editPicker != nil {
+		if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
+			return d.updateEditPicker(keyMsg)
+		}
+	}
+
 	// If MR detail modal is active, delegate to it
 	if d.mrDetail != nil {
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
-			if msg.String() == "esc" && !d.mrDetail.HasSubViewer() {
-				d.mrDetail = nil
+			if d.mrDetail.HasSubViewer() {
+				break
+			}
+			switch key := msg.String(); {
+			case d.keys.Matches(key, keymap.DetailClose):
+				if d.mrDetail.ConfirmClose() {
+					d.mrDetail.Close()
+					d.mrDetail = nil
+				}
+				return d, nil
+			case d.keys.Matches(key, keymap.DetailHelp):
+				help := NewHelpModal(d.keys, d.width, d.height)
+				d.help = &help
 				return d, nil
 			}
 		case MRDetailLoadedMsg:
 			d.mrDetail.SetDetail(msg.Detail, msg.Err)
 			return d, nil
+		case LabelsLoadedMsg:
+			if msg.Err != nil {
+				d.editKind = EditNone
+				d.mrDetail.SetNotice(ErrorStyle.Render("Loading labels failed: " + DescribeError(msg.Err)))
+				return d, nil
+			}
+			d.labels = msg.Labels
+			if d.editKind == EditLabels {
+				d.openEditPicker(EditLabels)
+			}
+			return d, nil
+		case MREditedMsg:
+			if msg.Err != nil {
+				d.mrDetail.SetNotice(ErrorStyle.Render("Update failed: " + DescribeError(msg.Err)))
+				return d, nil
+			}
+			d.mrDetail.SetNotice(SuccessStyle.Render("✓ " + editKindName(msg.Kind) + " updated"))
+			return d, d.loadMRDetail(d.mrDetail.GetMR().Number)
+		case ReviewSubmittedMsg:
+			newDetail, cmd := d.mrDetail.Update(msg)
+			d.mrDetail = &newDetail
+			if msg.Err == nil {
+				// Reload so the submitted comments show as regular threads
+				return d, tea.Batch(cmd, d.loadReviewComments(d.mrDetail.GetMR().Number))
+			}
+			return d, cmd
 		case MRCommitsLoadedMsg:
 			// Pass commits loaded message to the detail modal
 			newDetail, cmd := d.mrDetail.Update(msg)
@@ -223,22 +746,65 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		newDetail, cmd := d.mrDetail.Update(msg)
 		d.mrDetail = &newDetail
+		// Diff layout toggles persist for the rest of the session
+		d.diffOptions = d.mrDetail.DiffOptions()
 
 		// Check if user wants to proceed to checkout
 		if d.mrDetail.WantsCheckout() {
 			mr := d.mrDetail.GetMR()
+			d.mrDetail.Close()
 			d.mrDetail = nil
 			// Store pending checkout and check dirty state
 			d.pendingCheckout = &PendingCheckout{MR: &mr, Branch: mr.Branch}
 			return d, d.checkDirty()
 		}
 
+		// Check out into a worktree, which leaves the working tree alone
+		// so there's nothing to check for uncommitted changes
+		if d.mrDetail.WantsWorktree() {
+			mr := d.mrDetail.GetMR()
+			d.mrDetail.Close()
+			d.mrDetail = nil
+			checkout := NewWorktreeCheckoutModal(d.ctx, mr, d.repoPath, d.worktreeDir).
+				WithBaseBranch(d.repoInfo.DefaultBranch).
+				WithKeys(d.keys)
+			d.checkout = &checkout
+			return d, d.checkout.Init()
+		}
+
 		// Check if user wants to view commits
 		if d.mrDetail.WantsCommits() {
 			mr := d.mrDetail.GetMR()
 			return d, d.loadMRCommits(mr.Number)
 		}
 
+		// Check if user wants to view the diff
+		if d.mrDetail.TakeDiffRequest() {
+			number := d.mrDetail.GetMR().Number
+			return d, tea.Batch(d.loadMRDiff(number), d.loadReviewComments(number))
+		}
+
+		// Check if the pending review comments should be submitted
+		if comments := d.mrDetail.TakeSubmitRequest(); comments != nil {
+			return d, tea.Batch(cmd, d.submitReview(d.mrDetail.GetMR().Number, comments))
+		}
+
+		// Check if the diff viewer needs a file's full content
+		if path := d.mrDetail.TakeContentRequest(); path != "" {
+			return d, d.loadMRFileContent(d.mrDetail.GetMR().Number, path)
+		}
+
+		// Check if user wants to edit reviewers, assignees or labels
+		if kind := d.mrDetail.TakeEditRequest(); kind != EditNone {
+			if kind == EditLabels && d.labels == nil {
+				// Labels are loaded lazily, the picker opens once they arrive
+				d.editKind = EditLabels
+				return d, d.loadLabels()
+			}
+			d.openEditPicker(kind)
+			return d, nil
+		}
+
 		return d, cmd
 	}
 
@@ -248,7 +814,7 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if d.authorPicker.IsSearching() {
 			if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
 				if keyMsg.String() == "ctrl+c" {
-					return d, tea.Quit
+					return d, d.quit()
 				}
 				newPicker, cmd := d.authorPicker.Update(msg)
 				d.authorPicker = &newPicker
@@ -258,13 +824,19 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
-			switch msg.String() {
-			case "enter":
-				d.author = d.authorPicker.SelectedAuthor()
+			switch key := msg.String(); {
+			case d.keys.Matches(key, keymap.PickerSelect):
+				d.author = d.authorPicker.SelectedKey()
+				if d.author == "" {
+					d.author = d.defaultAuthor
+				}
 				d.authorPicker = nil
 				d.loading = true
+				d.staleSince = time.Time{}
+				d.listed = false
+				d.showCachedMRs()
 				return d, d.loadMRs()
-			case "esc":
+			case d.keys.Matches(key, keymap.PickerClose):
 				d.authorPicker = nil
 				return d, nil
 			}
@@ -274,11 +846,57 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return d, cmd
 	}
 
+	// Handle the checkout history picker
+	if d.historyPicker != nil {
+		if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
+			if d.historyPicker.IsSearching() && keyMsg.String() == "ctrl+c" {
+				return d, d.quit()
+			}
+			if !d.historyPicker.IsSearching() {
+				switch key := keyMsg.String(); {
+				case d.keys.Matches(key, keymap.PickerSelect):
+					branch := d.historyPicker.SelectedKey()
+					d.historyPicker = nil
+					if branch == "" {
+						return d, nil
+					}
+					return d, d.checkoutBranch(branch)
+				case d.keys.Matches(key, keymap.PickerClose):
+					d.historyPicker = nil
+					return d, nil
+				}
+			}
+			newPicker, cmd := d.historyPicker.Update(msg)
+			d.historyPicker = &newPicker
+			return d, cmd
+		}
+	}
+
+	// If the worktree list is open, keys go to it
+	if d.worktrees != nil {
+		if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
+			if d.keys.Matches(keyMsg.String(), keymap.WorktreeClose) {
+				d.worktrees = nil
+				return d, nil
+			}
+			newModal, cmd := d.worktrees.Update(keyMsg)
+			d.worktrees = &newModal
+			cmds := []tea.Cmd{cmd}
+			if req := d.worktrees.TakeExecRequest(); req != nil {
+				cmds = append(cmds, execIn(*req))
+			}
+			for _, path := range d.worktrees.TakeRemoveRequest() {
+				cmds = append(cmds, d.removeWorktree(path))
+			}
+			return d, tea.Batch(cmds...)
+		}
+	}
+
 	// If MR list is in search mode, pass all keys to it (except ctrl+c)
 	if d.activeTab == TabMRs && d.mrList.IsSearching() {
 		if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
 			if keyMsg.String() == "ctrl+c" {
-				return d, tea.Quit
+				return d, d.quit()
 			}
 			var cmd tea.Cmd
 			d.mrList, cmd = d.mrList.Update(msg)
@@ -287,30 +905,31 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		d.width = msg.Width
-		d.height = msg.Height
-		d.mrList.SetSize(msg.Width-4, msg.Height-10)
-		return d, nil
-
 	case tea.KeyPressMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return d, tea.Quit
-		case "a":
-			picker := NewAuthorPicker(d.authors, d.author, d.width-10, d.height-6)
+		switch key := msg.String(); {
+		case key == "ctrl+c" || d.keys.Matches(key, keymap.Quit):
+			return d, d.quit()
+		case d.keys.Matches(key, keymap.Help):
+			help := NewHelpModal(d.keys, d.width, d.height)
+			d.help = &help
+			return d, nil
+		case d.keys.Matches(key, keymap.Author):
+			picker := NewAuthorPicker(d.authors, d.author, d.defaultAuthor, d.width-10, d.height-6).WithKeys(d.keys)
 			d.authorPicker = &picker
 			return d, nil
-		case "tab":
+		case d.keys.Matches(key, keymap.NextTab):
 			d.activeTab = (d.activeTab + 1) % 3
 			return d, nil
-		case "r", "R":
+		case d.keys.Matches(key, keymap.Refresh):
 			if d.activeTab == TabMRs && !d.loading {
+				// A refresh always asks the forge
+				d.cache.InvalidateMRs()
 				d.loading = true
+				d.staleSince = time.Time{}
 				return d, d.loadMRs()
 			}
 			return d, nil
-		case "m":
+		case d.keys.Matches(key, keymap.Main):
 			// Checkout to default branch
 			if d.repoInfo.DefaultBranch != "" && d.currentBranch != d.repoInfo.DefaultBranch {
 				// Store pending checkout and check dirty state
@@ -318,43 +937,70 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return d, d.checkDirty()
 			}
 			return d, nil
-		case "enter":
+		case d.keys.Matches(key, keymap.Update):
+			// Update the current branch from the default branch
+			if d.repoInfo.DefaultBranch == "" || d.currentBranch == d.repoInfo.DefaultBranch {
+				return d, nil
+			}
+			update := NewUpdateModal(d.ctx, d.repoPath, d.repoInfo.DefaultBranch).WithKeys(d.keys)
+			d.update = &update
+			return d, d.update.Init()
+		case d.keys.Matches(key, keymap.Back):
+			// Back to the branch checked out before this one
+			return d, d.loadPreviousBranch()
+		case d.keys.Matches(key, keymap.History):
+			return d, d.loadHistory()
+		case d.keys.Matches(key, keymap.Details):
 			if d.activeTab == TabMRs {
 				if mr := d.mrList.SelectedMR(); mr != nil {
 					// Open MR detail modal first (checkout happens from there)
-					detail := NewMRDetailModal(*mr, d.repoInfo.Platform, d.width, d.height)
+					detail := NewMRDetailModal(d.ctx, *mr, d.repoInfo.Platform, d.diffOptions, d.width, d.height).
+						WithKeys(d.keys)
 					d.mrDetail = &detail
+					d.mrList.MarkViewed(mr.Number)
+					if cached, ok := d.cache.CachedMRDetail(mr.Number); ok {
+						d.mrDetail.SetCachedDetail(cached)
+					}
 					return d, tea.Batch(d.mrDetail.Init(), d.loadMRDetail(mr.Number))
 				}
 			}
 			return d, nil
-		case "w":
+		case d.keys.Matches(key, keymap.Worktrees):
+			modal := NewWorktreeModal(d.width, d.height).WithKeys(d.keys)
+			d.worktrees = &modal
+			return d, d.loadWorktrees()
+		case d.keys.Matches(key, keymap.Open):
 			if d.activeTab == TabMRs {
 				if mr := d.mrList.SelectedMR(); mr != nil && mr.URL != "" {
-					openBrowser(mr.URL)
+					d.mrList.MarkViewed(mr.Number)
+					_ = OpenBrowser(mr.URL)
 				}
 			}
 			return d, nil
-		case "t":
+		case d.keys.Matches(key, keymap.Ticket):
 			if d.activeTab == TabMRs {
 				if mr := d.mrList.SelectedMR(); mr != nil {
-					ticket := extractJiraTicket(mr.Title)
+					ticket := extractTicket(d.ticketRe, mr.Title)
 					if ticket == "" {
-						d.statusMsg = "No Jira ticket in title"
+						d.statusMsg = "No ticket in title"
 						return d, clearStatusAfter(2 * time.Second)
 					}
-					jiraURL := os.Getenv("JIRA_URL")
-					if jiraURL == "" {
-						d.statusMsg = "JIRA_URL not set"
+					ticketURL := d.tracker.TicketURL(ticket)
+					if ticketURL == "" {
+						d.statusMsg = "No tracker set, add tracker.url to the config or set JIRA_URL"
 						return d, clearStatusAfter(2 * time.Second)
 					}
-					openBrowser(jiraURL + "/browse/" + ticket)
+					_ = OpenBrowser(ticketURL)
 				}
 			}
 			return d, nil
 		}
 
 	case RepoInfoLoadedMsg:
+		if msg.Err != nil && d.repoInfo.Name != "" {
+			// Keep the cached info
+			return d, nil
+		}
 		if msg.Err != nil {
 			d.err = msg.Err
 		} else {
@@ -362,14 +1008,19 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return d, nil
 
-	case MRsLoadedMsg:
-		d.loading = false
-		if msg.Err != nil {
-			d.err = msg.Err
-		} else {
-			d.mrList.SetItems(msg.MRs)
+	case PrefetchDoneMsg:
+		d.prefetching = false
+		switch {
+		case errors.Is(msg.Err, context.Canceled):
+			return d, nil
+		case msg.Err != nil:
+			d.statusMsg = "Prefetch failed: " + DescribeError(msg.Err)
+		case msg.Fetched > 0:
+			d.statusMsg = "Prefetched " + plural(msg.Fetched, "MR")
+		default:
+			return d, nil
 		}
-		return d, nil
+		return d, clearStatusAfter(3 * time.Second)
 
 	case AuthorsLoadedMsg:
 		if msg.Err == nil {
@@ -377,6 +1028,14 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return d, nil
 
+	case LabelsLoadedMsg:
+		// The detail modal was closed before labels arrived
+		d.editKind = EditNone
+		if msg.Err == nil {
+			d.labels = msg.Labels
+		}
+		return d, nil
+
 	case BranchLoadedMsg:
 		if msg.Err == nil {
 			d.currentBranch = msg.Branch
@@ -388,23 +1047,64 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return d, nil
 		}
 		// If error checking dirty, proceed anyway
-		if msg.Err != nil || !msg.IsDirty {
+		if msg.Err != nil || len(msg.Files) == 0 {
 			// Not dirty or error, proceed to checkout
-			if d.pendingCheckout.MR != nil {
-				checkout := NewCheckoutModal(*d.pendingCheckout.MR, d.repoPath)
-				d.checkout = &checkout
-			} else {
-				checkout := NewBranchCheckoutModal(d.pendingCheckout.Branch, d.repoPath)
-				d.checkout = &checkout
-			}
-			d.pendingCheckout = nil
-			return d, d.checkout.Init()
+			return d, d.startCheckout(false, false)
 		}
 		// Dirty, show confirmation
-		confirm := NewDirtyConfirmModal(d.pendingCheckout.Branch)
+		confirm := NewDirtyConfirmModal(d.pendingCheckout.Branch, msg.Files).WithKeys(d.keys)
 		d.dirtyConfirm = &confirm
 		return d, nil
 
+	case WorktreesLoadedMsg:
+		if d.worktrees == nil {
+			return d, nil
+		}
+		d.worktrees.SetWorktrees(msg.Worktrees, msg.Err)
+		var cmds []tea.Cmd
+		for _, number := range d.worktrees.Numbers() {
+			cmds = append(cmds, d.loadWorktreeStatus(number))
+		}
+		return d, tea.Batch(cmds...)
+
+	case WorktreeStatusMsg:
+		if d.worktrees != nil {
+			d.worktrees.SetStatus(msg)
+		}
+		return d, nil
+
+	case WorktreeRemovedMsg:
+		if d.worktrees == nil {
+			return d, nil
+		}
+		d.worktrees.SetRemoved(msg)
+		return d, d.loadWorktrees()
+
+	case PreviousBranchMsg:
+		switch {
+		case msg.Err != nil:
+			d.statusMsg = "History unavailable: " + msg.Err.Error()
+		case msg.Branch == "":
+			d.statusMsg = "No previous branch"
+		default:
+			return d, d.checkoutBranch(msg.Branch)
+		}
+		return d, clearStatusAfter(2 * time.Second)
+
+	case HistoryLoadedMsg:
+		if msg.Err != nil {
+			d.statusMsg = "History unavailable: " + msg.Err.Error()
+			return d, clearStatusAfter(2 * time.Second)
+		}
+		recent := git.RecentBranches(msg.Entries, d.currentBranch)
+		if len(recent) == 0 {
+			d.statusMsg = "No checkout history yet"
+			return d, clearStatusAfter(2 * time.Second)
+		}
+		picker := NewHistoryPicker(recent, time.Now(), d.width-10, d.height-6).WithKeys(d.keys)
+		d.historyPicker = &picker
+		return d, nil
+
 	case ClearStatusMsg:
 		d.statusMsg = ""
 		return d, nil
@@ -458,7 +1158,7 @@ func (d Dashboard) View() tea.View {
 	if d.loading {
 		rawContent = "Loading..."
 	} else if d.err != nil {
-		rawContent = ErrorStyle.Render("Error: " + d.err.Error())
+		rawContent = ErrorStyle.Render("Error: " + DescribeError(d.err))
 	} else {
 		switch d.activeTab {
 		case TabMRs:
@@ -496,6 +1196,17 @@ func (d Dashboard) View() tea.View {
 		)
 	}
 
+	// Overlay update modal if active
+	if d.update != nil {
+		modalView := d.update.View()
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			modalView,
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
 	// Overlay checkout modal if active
 	if d.checkout != nil {
 		modalView := d.checkout.View()
@@ -504,7 +1215,7 @@ func (d Dashboard) View() tea.View {
 			lipgloss.Center, lipgloss.Center,
 			modalView,
 			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("236"))),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
 		)
 	}
 
@@ -515,7 +1226,7 @@ func (d Dashboard) View() tea.View {
 			lipgloss.Center, lipgloss.Center,
 			modalView,
 			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("236"))),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
 		)
 	}
 
@@ -526,7 +1237,40 @@ func (d Dashboard) View() tea.View {
 			lipgloss.Center, lipgloss.Center,
 			modalView,
 			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("236"))),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
+	// Overlay checkout history if active
+	if d.historyPicker != nil {
+		modalView := d.historyPicker.View()
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			modalView,
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
+	// Overlay edit picker if active
+	if d.editPicker != nil {
+		modalView := d.editPicker.View()
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			modalView,
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
+	// Overlay worktree list if active
+	if d.worktrees != nil {
+		modalView := d.worktrees.View()
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			modalView,
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
 		)
 	}
 
@@ -537,7 +1281,27 @@ func (d Dashboard) View() tea.View {
 			lipgloss.Center, lipgloss.Center,
 			modalView,
 			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("236"))),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
+	// Overlay the working tree diff over the dirty confirm
+	if d.workingDiff != nil {
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			d.workingDiff.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
+		)
+	}
+
+	// Overlay the key help over everything
+	if d.help != nil {
+		view = lipgloss.Place(d.width, d.height,
+			lipgloss.Center, lipgloss.Center,
+			d.help.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceStyle(BackdropStyle),
 		)
 	}
 
@@ -546,6 +1310,88 @@ func (d Dashboard) View() tea.View {
 	return v
 }
 
+// openWorkingDiff shows the loaded diff of uncommitted changes, or why it can't
+func (d Dashboard) openWorkingDiff(msg WorkingDiffLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		d.dirtyConfirm.SetNotice("Loading diff failed: " + msg.Err.Error())
+		return d, nil
+	}
+	files := diff.Parse(msg.Patch)
+	if len(files) == 0 {
+		d.dirtyConfirm.SetNotice("No changes to tracked files")
+		return d, nil
+	}
+	viewer := NewWorkingDiffViewer(files, d.diffOptions, d.width, d.height).WithKeys(d.keys)
+	d.workingDiff = &viewer
+	return d, nil
+}
+
+// openEditPicker opens the multi-select picker for the given MR attribute,
+// pre-checking the values currently set on the MR
+func (d *Dashboard) openEditPicker(kind EditKind) {
+	detail := d.mrDetail.Detail()
+	var picker Picker
+	switch kind {
+	case EditReviewers:
+		picker = NewPeoplePicker("Reviewers", d.authors, detail.Reviewers, d.width-10, d.height-6)
+	case EditAssignees:
+		picker = NewPeoplePicker("Assignees", d.authors, detail.Assignees, d.width-10, d.height-6)
+	case EditLabels:
+		picker = NewLabelPicker(d.labels, detail.Labels, d.width-10, d.height-6)
+	default:
+		return
+	}
+	picker = picker.WithKeys(d.keys)
+	d.editPicker = &picker
+	d.editKind = kind
+}
+
+// updateEditPicker handles keys while the edit picker is open
+func (d Dashboard) updateEditPicker(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	if d.editPicker.IsSearching() {
+		if msg.String() == "ctrl+c" {
+			return d, d.quit()
+		}
+		newPicker, cmd := d.editPicker.Update(msg)
+		d.editPicker = &newPicker
+		return d, cmd
+	}
+
+	switch key := msg.String(); {
+	case d.keys.Matches(key, keymap.PickerSelect):
+		added, removed := d.editPicker.Changes()
+		kind := d.editKind
+		d.editPicker = nil
+		d.editKind = EditNone
+		if d.mrDetail == nil || (len(added) == 0 && len(removed) == 0) {
+			return d, nil
+		}
+		d.mrDetail.SetNotice(DimStyle.Render("Updating " + strings.ToLower(editKindName(kind)) + "..."))
+		return d, d.applyEdit(kind, d.mrDetail.GetMR().Number, added, removed)
+	case d.keys.Matches(key, keymap.PickerClose):
+		d.editPicker = nil
+		d.editKind = EditNone
+		return d, nil
+	}
+
+	newPicker, cmd := d.editPicker.Update(msg)
+	d.editPicker = &newPicker
+	return d, cmd
+}
+
+// editKindName returns a human-readable name for an edit kind
+func editKindName(kind EditKind) string {
+	switch kind {
+	case EditReviewers:
+		return "Reviewers"
+	case EditAssignees:
+		return "Assignees"
+	case EditLabels:
+		return "Labels"
+	}
+	return ""
+}
+
 func (d Dashboard) renderHeader() string {
 	repoName := d.repoInfo.Name
 	if repoName == "" {
@@ -578,7 +1424,11 @@ func (d Dashboard) renderHeader() string {
 }
 
 func (d Dashboard) renderAuthorRow() string {
-	return fmt.Sprintf("  Author: [%s]", d.author)
+	row := fmt.Sprintf("  Author: [%s]", d.author)
+	if !d.staleSince.IsZero() {
+		row += DimStyle.Render("  · stale, cached " + formatAge(time.Since(d.staleSince)) + ", refreshing...")
+	}
+	return row
 }
 
 func (d Dashboard) renderTabs() string {
@@ -595,19 +1445,17 @@ func (d Dashboard) renderTabs() string {
 }
 
 func (d Dashboard) renderFooter() string {
-	help := "↑↓ nav │ enter details │ w open │ t jira │ f find │ r refresh │ a author │ m main │ q quit"
+	help := strings.Join(footerHelp(d.keys, keymap.Dashboard, "%s %s"), " │ ")
 	if d.statusMsg != "" {
 		help += " │ " + d.statusMsg
 	}
 	return FooterStyle.Align(lipgloss.Center).Width(d.width).Render(help)
 }
 
-var jiraTicketRe = regexp.MustCompile(`#([A-Z]+-\d+)`)
-
-// extractJiraTicket returns the first Jira ticket ID found in s (e.g. "JUM-271"),
+// extractTicket returns the first ticket ID re finds in s (e.g. "JUM-271"),
 // or empty string if none.
-func extractJiraTicket(s string) string {
-	m := jiraTicketRe.FindStringSubmatch(s)
+func extractTicket(re *regexp.Regexp, s string) string {
+	m := re.FindStringSubmatch(s)
 	if len(m) < 2 {
 		return ""
 	}
@@ -619,8 +1467,8 @@ func clearStatusAfter(d time.Duration) tea.Cmd {
 	return tea.Tick(d, func(time.Time) tea.Msg { return ClearStatusMsg{} })
 }
 
-// openBrowser opens a URL in the default browser
-func openBrowser(url string) {
+// OpenBrowser opens a URL in the default browser
+func OpenBrowser(url string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -630,5 +1478,5 @@ func openBrowser(url string) {
 	default: // linux, freebsd, etc.
 		cmd = exec.Command("xdg-open", url)
 	}
-	_ = cmd.Start()
+	return cmd.Start()
 }
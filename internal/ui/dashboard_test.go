@@ -1,8 +1,18 @@
 package ui
 
-import "testing"
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
 
-func TestExtractJiraTicket(t *testing.T) {
+	"github.com/Constantine-Kostikas/GitQuick/internal/cache"
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+func TestExtractTicket(t *testing.T) {
+	re := regexp.MustCompile(config.Default().Tracker.Pattern)
 	tests := []struct {
 		title    string
 		expected string
@@ -16,9 +26,52 @@ func TestExtractJiraTicket(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		got := extractJiraTicket(tc.title)
+		got := extractTicket(re, tc.title)
 		if got != tc.expected {
-			t.Errorf("extractJiraTicket(%q) = %q, want %q", tc.title, got, tc.expected)
+			t.Errorf("extractTicket(%q) = %q, want %q", tc.title, got, tc.expected)
 		}
 	}
 }
+
+func TestNewDashboard_Polling(t *testing.T) {
+	cfg := config.Default()
+	if d := NewDashboard(nil, t.TempDir(), cfg); d.pollEvery != 0 {
+		t.Errorf("polls every %v by default", d.pollEvery)
+	}
+	cfg.Poll.Enabled = true
+	if d := NewDashboard(nil, t.TempDir(), cfg); d.pollEvery != cfg.Poll.Interval {
+		t.Errorf("polls every %v, want %v", d.pollEvery, cfg.Poll.Interval)
+	}
+	// Webhooks take the polls' place
+	cfg.Webhook.Enabled = true
+	if d := NewDashboard(nil, t.TempDir(), cfg); d.pollEvery != 0 {
+		t.Errorf("polls every %v while receiving webhooks", d.pollEvery)
+	}
+}
+
+// listingPlatform counts the MR lists fetched from the forge
+type listingPlatform struct {
+	platform.Platform
+	lists int
+}
+
+func (p *listingPlatform) ListMRs(context.Context, string) ([]platform.MR, error) {
+	p.lists++
+	return []platform.MR{{Number: p.lists}}, nil
+}
+
+func TestNewDashboard_RefreshesCachedMRs(t *testing.T) {
+	forge := &listingPlatform{}
+	cached := platform.NewCached(forge, cache.Open(t.TempDir()), platform.TTLs{MRs: time.Hour})
+	cached.ListMRs(context.Background(), "@me")
+
+	// The cached list is shown as stale, so it's fetched however fresh it is
+	d := NewDashboard(cached, t.TempDir(), config.Default())
+	if d.staleSince.IsZero() {
+		t.Fatal("cached MRs aren't marked stale")
+	}
+	msg := d.loadMRs()().(MRsLoadedMsg)
+	if forge.lists != 2 || len(msg.MRs) != 1 || msg.MRs[0].Number != 2 {
+		t.Errorf("got %+v after %d lists, want the forge's", msg.MRs, forge.lists)
+	}
+}
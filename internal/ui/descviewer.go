@@ -41,7 +41,7 @@ func NewDescriptionViewer(title, content string, width, height int) DescriptionV
 
 	vp := viewport.New(viewport.WithWidth(contentWidth), viewport.WithHeight(modalHeight-4)) // Leave room for title and footer
 	vp.SetContent(wrappedContent)
-	vp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	vp.Style = TextStyle
 
 	return DescriptionViewer{
 		title:    title,
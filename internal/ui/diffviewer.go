@@ -0,0 +1,765 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/highlight"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// SplitMinWidth is the terminal width below which side-by-side diffs fall
+// back to the unified layout
+const SplitMinWidth = 140
+
+// MRDiffLoadedMsg is sent when the MR patch is loaded
+type MRDiffLoadedMsg struct {
+	Patch string
+	Err   error
+}
+
+// MRFileContentLoadedMsg is sent when a file's content at the MR head is loaded
+type MRFileContentLoadedMsg struct {
+	Path    string
+	Content string
+	Err     error
+}
+
+// DiffOptions are the diff viewer settings that persist for the session
+type DiffOptions struct {
+	Profile colorprofile.Profile
+	Split   bool // side-by-side when the terminal is wide enough
+	Wrap    bool // wrap long lines instead of truncating them
+}
+
+// diffRowKind identifies what a rendered diff row shows
+type diffRowKind int
+
+const (
+	rowHunkHeader diffRowKind = iota
+	rowLine
+	rowPair // side-by-side row, old and new refer to rows
+)
+
+// diffRow is one line of a file's diff (or content), with its highlighting
+type diffRow struct {
+	kind   diffRowKind
+	hunk   int // index of the hunk the row belongs to
+	line   diff.Line
+	tokens []highlight.Token
+	spans  []diff.Span // changed words, emphasised within the line
+}
+
+// displayRow is one cursor position in the viewer. In the unified layout it
+// refers to a single row; side-by-side it pairs an old and a new row.
+type displayRow struct {
+	kind diffRowKind
+	hunk int
+	old  int // index into rows, -1 if none
+	new  int // index into rows, -1 if none
+}
+
+// DiffViewer displays the diff of one changed file at a time, unified or
+// side-by-side, or the full content of that file at the MR head. Lines can be
+// selected and commented on; comments are collected into a pending review.
+type DiffViewer struct {
+	files        []diff.File
+	fileIdx      int
+	rows         []diffRow
+	display      []displayRow
+	cursor       int // highlighted display row
+	offset       int // first visible display row
+	palette      codePalette
+	opts         DiffOptions
+	termWidth    int
+	showFull     bool                // full file content instead of the diff
+	contents     map[string][]string // loaded file contents by path
+	wantsContent string              // path whose content should be loaded
+	notice       string
+	anchor       int    // start of the selected range, -1 if none
+	side         string // side picked for comments in the side-by-side layout
+	comments     []platform.ReviewComment
+	pending      []platform.ReviewComment // written but not yet submitted
+	threads      map[threadKey][]threadComment
+	composer     *textarea.Model // open while writing a comment
+	draft        platform.ReviewComment
+	wantsSubmit  bool
+	submitting   bool
+	local        bool // working tree changes, which can't be reviewed
+	keys         keymap.Keymap
+	width        int
+	height       int
+}
+
+// NewDiffViewer creates a diff viewer opened on files[fileIdx]
+func NewDiffViewer(files []diff.File, fileIdx int, opts DiffOptions, width, height int) DiffViewer {
+	v := DiffViewer{
+		files:    files,
+		palette:  paletteFor(opts.Profile),
+		opts:     opts,
+		contents: make(map[string][]string),
+		anchor:   -1,
+		side:     platform.SideNew,
+	}
+	v.resize(width, height)
+	v.setFile(fileIdx)
+	return v
+}
+
+// NewWorkingDiffViewer creates a read-only viewer for uncommitted changes
+func NewWorkingDiffViewer(files []diff.File, opts DiffOptions, width, height int) DiffViewer {
+	v := NewDiffViewer(files, 0, opts, width, height)
+	v.local = true
+	return v
+}
+
+// WithKeys sets the keymap, whose diff and comment bindings the viewer handles
+func (v DiffViewer) WithKeys(keys keymap.Keymap) DiffViewer {
+	v.keys = keys
+	return v
+}
+
+// resize adapts the modal to the terminal size
+func (v *DiffViewer) resize(width, height int) {
+	v.termWidth = width
+	v.width = width - 4
+	if v.width < 60 {
+		v.width = 60
+	}
+	v.height = height - 2
+	if v.height < 12 {
+		v.height = 12
+	}
+	if v.composer != nil {
+		v.composer.SetWidth(v.contentWidth())
+	}
+}
+
+// splitActive reports whether the side-by-side layout is in effect
+func (v DiffViewer) splitActive() bool {
+	return v.opts.Split && !v.showFull && v.termWidth >= SplitMinWidth
+}
+
+// setFile switches to the file at idx and rebuilds the rows
+func (v *DiffViewer) setFile(idx int) {
+	if idx < 0 || idx >= len(v.files) {
+		idx = 0
+	}
+	if idx != v.fileIdx {
+		v.showFull = false
+	}
+	v.fileIdx = idx
+	v.rows = nil
+	v.notice = ""
+	if len(v.files) > 0 {
+		file := v.files[idx]
+		if lines, ok := v.contents[file.Path()]; ok && v.showFull {
+			v.rows = buildContentRows(file, lines)
+		} else {
+			v.showFull = false
+			v.rows = buildDiffRows(file)
+		}
+	}
+	v.layout()
+	v.indexThreads()
+	v.cursor = 0
+	v.offset = 0
+	v.anchor = -1
+}
+
+// layout builds the display rows for the current layout
+func (v *DiffViewer) layout() {
+	v.display = nil
+	if !v.splitActive() {
+		for i, r := range v.rows {
+			v.display = append(v.display, displayRow{kind: r.kind, hunk: r.hunk, old: i, new: i})
+		}
+		return
+	}
+
+	// Side-by-side: walk hunks, pairing lines with diff.SplitRows
+	for i := 0; i < len(v.rows); {
+		header := v.rows[i]
+		v.display = append(v.display, displayRow{kind: rowHunkHeader, hunk: header.hunk, old: i, new: i})
+		base := i + 1
+		lines := v.files[v.fileIdx].Hunks[header.hunk].Lines
+		for _, sr := range diff.SplitRows(lines) {
+			row := displayRow{kind: rowPair, hunk: header.hunk, old: -1, new: -1}
+			if sr.Old >= 0 {
+				row.old = base + sr.Old
+			}
+			if sr.New >= 0 {
+				row.new = base + sr.New
+			}
+			v.display = append(v.display, row)
+		}
+		i = base + len(lines)
+	}
+}
+
+// relayout switches layout while keeping the cursor in the same hunk
+func (v *DiffViewer) relayout() {
+	hunk := -1
+	if v.cursor < len(v.display) {
+		hunk = v.display[v.cursor].hunk
+	}
+	v.layout()
+	v.cursor = 0
+	v.offset = 0
+	v.anchor = -1
+	for i, r := range v.display {
+		if r.kind == rowHunkHeader && r.hunk == hunk {
+			v.cursor = i
+			v.offset = i
+			break
+		}
+	}
+	v.ensureVisible()
+}
+
+// buildDiffRows lays out the hunks of a file with syntax tokens and
+// word-level emphasis for paired removed/added lines
+func buildDiffRows(file diff.File) []diffRow {
+	lang := highlight.Detect(file.Path())
+	var rows []diffRow
+	for hi, h := range file.Hunks {
+		rows = append(rows, diffRow{kind: rowHunkHeader, hunk: hi})
+		base := len(rows)
+
+		// Old and new sides are tokenised separately so that block comments
+		// opened on one side don't leak into the other
+		var oldState, newState highlight.State
+		for _, l := range h.Lines {
+			var tokens []highlight.Token
+			switch l.Kind {
+			case diff.Removed:
+				tokens = highlight.Line(lang, l.Text, &oldState)
+			case diff.Added:
+				tokens = highlight.Line(lang, l.Text, &newState)
+			default:
+				tokens = highlight.Line(lang, l.Text, &newState)
+				oldState = newState
+			}
+			rows = append(rows, diffRow{kind: rowLine, hunk: hi, line: l, tokens: tokens})
+		}
+
+		for del, add := range diff.PairLines(h.Lines) {
+			oldSpans, newSpans := diff.WordDiff(h.Lines[del].Text, h.Lines[add].Text)
+			rows[base+del].spans = oldSpans
+			rows[base+add].spans = newSpans
+		}
+	}
+	return rows
+}
+
+// buildContentRows lays out a whole file, marking the lines the MR added
+func buildContentRows(file diff.File, lines []string) []diffRow {
+	added := make(map[int]bool)
+	for _, h := range file.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == diff.Added {
+				added[l.NewNum] = true
+			}
+		}
+	}
+
+	lang := highlight.Detect(file.Path())
+	var state highlight.State
+	rows := make([]diffRow, len(lines))
+	for i, text := range lines {
+		kind := diff.Context
+		if added[i+1] {
+			kind = diff.Added
+		}
+		rows[i] = diffRow{
+			kind:   rowLine,
+			line:   diff.Line{Kind: kind, Text: text, NewNum: i + 1},
+			tokens: highlight.Line(lang, text, &state),
+		}
+	}
+	return rows
+}
+
+// bodyHeight is the number of screen lines available for the diff
+func (v DiffViewer) bodyHeight() int {
+	// border (2) + title + separator + footer
+	h := v.height - 5
+	if v.composer != nil {
+		h -= composerHeight
+	}
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+// contentWidth is the usable width inside the modal border and padding
+func (v DiffViewer) contentWidth() int {
+	return v.width - 6
+}
+
+// Options returns the session settings, including any toggles made in the viewer
+func (v DiffViewer) Options() DiffOptions {
+	return v.opts
+}
+
+// Init returns the initial command
+func (v DiffViewer) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (v DiffViewer) Update(msg tea.Msg) (DiffViewer, tea.Cmd) {
+	switch msg := msg.(type) {
+	case MRFileContentLoadedMsg:
+		v.setContent(msg)
+		return v, nil
+	case tea.WindowSizeMsg:
+		wasSplit := v.splitActive()
+		v.resize(msg.Width, msg.Height)
+		if v.splitActive() != wasSplit {
+			v.relayout()
+		}
+		v.ensureVisible()
+		return v, nil
+	}
+
+	if v.composer != nil {
+		return v.updateComposer(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return v, nil
+	}
+
+	key := keyMsg.String()
+	if v.local {
+		// No MR to load content from or comment on
+		for _, action := range []keymap.Action{keymap.DiffFullFile, keymap.DiffSelect, keymap.DiffComment, keymap.DiffDiscard, keymap.DiffSubmit} {
+			if v.keys.Matches(key, action) {
+				return v, nil
+			}
+		}
+	}
+
+	page := v.bodyHeight()
+	switch {
+	case v.keys.Matches(key, keymap.DiffDown):
+		v.moveCursor(1)
+	case v.keys.Matches(key, keymap.DiffUp):
+		v.moveCursor(-1)
+	case v.keys.Matches(key, keymap.DiffPageDown):
+		v.moveCursor(page / 2)
+	case v.keys.Matches(key, keymap.DiffPageUp):
+		v.moveCursor(-page / 2)
+	case v.keys.Matches(key, keymap.DiffTop):
+		v.moveCursor(-len(v.display))
+	case v.keys.Matches(key, keymap.DiffBottom):
+		v.moveCursor(len(v.display))
+	case v.keys.Matches(key, keymap.DiffNextHunk):
+		v.jumpHunk(1)
+	case v.keys.Matches(key, keymap.DiffPrevHunk):
+		v.jumpHunk(-1)
+	case v.keys.Matches(key, keymap.DiffNextFile):
+		if v.fileIdx < len(v.files)-1 {
+			v.setFile(v.fileIdx + 1)
+		}
+	case v.keys.Matches(key, keymap.DiffPrevFile):
+		if v.fileIdx > 0 {
+			v.setFile(v.fileIdx - 1)
+		}
+	case v.keys.Matches(key, keymap.DiffFullFile):
+		v.toggleFull()
+	case v.keys.Matches(key, keymap.DiffSplit):
+		v.opts.Split = !v.opts.Split
+		if v.opts.Split && v.termWidth < SplitMinWidth {
+			v.notice = fmt.Sprintf("Side-by-side needs %d columns, showing unified", SplitMinWidth)
+		} else {
+			v.notice = ""
+		}
+		v.relayout()
+	case v.keys.Matches(key, keymap.DiffWrap):
+		v.opts.Wrap = !v.opts.Wrap
+		v.ensureVisible()
+	case v.keys.Matches(key, keymap.DiffOldSide):
+		v.side = platform.SideOld
+	case v.keys.Matches(key, keymap.DiffNewSide):
+		v.side = platform.SideNew
+	case v.keys.Matches(key, keymap.DiffSelect):
+		if v.anchor >= 0 {
+			v.anchor = -1
+		} else if !v.showFull && len(v.display) > 0 {
+			v.anchor = v.cursor
+		}
+	case v.keys.Matches(key, keymap.DiffClose):
+		// Clears a selection, the viewer's owner closes it otherwise
+		v.anchor = -1
+	case v.keys.Matches(key, keymap.DiffComment):
+		return v, v.startComment()
+	case v.keys.Matches(key, keymap.DiffDiscard):
+		v.discardPending()
+	case v.keys.Matches(key, keymap.DiffSubmit):
+		v.requestSubmit()
+	}
+	return v, nil
+}
+
+// footer lists the keys that do something in the viewer's current state
+func (v DiffViewer) footer() string {
+	move := fmt.Sprintf("[%s/%s]", keyHint(v.keys, keymap.DiffDown), keyHint(v.keys, keymap.DiffUp))
+	closeKey := keyHint(v.keys, keymap.DiffClose)
+	var items []string
+	switch {
+	case v.composer != nil:
+		items = footerHelp(v.keys, keymap.Comment, "[%s] %s")
+	case v.anchor >= 0:
+		items = []string{
+			move + " extend selection",
+			fmt.Sprintf("[%s] comment on selection", keyHint(v.keys, keymap.DiffComment)),
+			fmt.Sprintf("[%s] clear selection", closeKey),
+		}
+	case v.showFull:
+		items = []string{
+			move + " move",
+			fmt.Sprintf("[%s/%s] file", keyHint(v.keys, keymap.DiffNextFile), keyHint(v.keys, keymap.DiffPrevFile)),
+			fmt.Sprintf("[%s] wrap", keyHint(v.keys, keymap.DiffWrap)),
+			fmt.Sprintf("[%s] back to diff", keyHint(v.keys, keymap.DiffFullFile)),
+			fmt.Sprintf("[%s] close", closeKey),
+		}
+	case v.local:
+		items = append([]string{move + " move"}, footerHelp(v.keys, keymap.Diff, "[%s] %s",
+			keymap.DiffFullFile, keymap.DiffSelect, keymap.DiffComment, keymap.DiffSubmit)...)
+	default:
+		items = append([]string{move + " move"}, footerHelp(v.keys, keymap.Diff, "[%s] %s")...)
+	}
+	return strings.Join(items, " | ")
+}
+
+// moveCursor moves the cursor by delta rows and keeps it visible
+func (v *DiffViewer) moveCursor(delta int) {
+	v.cursor += delta
+	if v.cursor >= len(v.display) {
+		v.cursor = len(v.display) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.ensureVisible()
+}
+
+// jumpHunk moves the cursor to the next (dir > 0) or previous hunk header
+func (v *DiffViewer) jumpHunk(dir int) {
+	for i := v.cursor + dir; i >= 0 && i < len(v.display); i += dir {
+		if v.display[i].kind == rowHunkHeader {
+			v.cursor = i
+			// Show the hunk from its header downwards
+			v.offset = i
+			v.ensureVisible()
+			return
+		}
+	}
+}
+
+// ensureVisible adjusts the scroll offset so the cursor is on screen,
+// taking wrapped rows and inline comments into account
+func (v *DiffViewer) ensureVisible() {
+	page := v.bodyHeight()
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	// Scroll down until the cursor row fits on the page
+	used := 0
+	for i := v.cursor; i >= v.offset; i-- {
+		used += v.rowHeight(i)
+		if used > page {
+			v.offset = min(i+1, v.cursor)
+			break
+		}
+	}
+	// Don't leave empty space below the last row
+	tail := 0
+	for i := len(v.display) - 1; i >= 0; i-- {
+		tail += v.rowHeight(i)
+		if tail > page {
+			v.offset = min(v.offset, i+1)
+			return
+		}
+	}
+	v.offset = 0
+}
+
+// rowHeight is the number of screen lines display row i takes
+func (v DiffViewer) rowHeight(i int) int {
+	if !v.opts.Wrap && len(v.threadsAt(i)) == 0 {
+		return 1
+	}
+	return len(v.renderDisplayRow(i, v.contentWidth()))
+}
+
+// toggleFull switches between the diff and the file content at the MR head,
+// requesting the content if it hasn't been loaded yet
+func (v *DiffViewer) toggleFull() {
+	if len(v.files) == 0 {
+		return
+	}
+	file := v.files[v.fileIdx]
+	if v.showFull {
+		v.showFull = false
+		v.setFile(v.fileIdx)
+		return
+	}
+	if file.IsDeleted || file.Binary {
+		v.notice = "No content to show for this file"
+		return
+	}
+	if _, ok := v.contents[file.Path()]; ok {
+		v.showFull = true
+		v.setFile(v.fileIdx)
+		return
+	}
+	v.wantsContent = file.Path()
+	v.notice = "Loading " + file.Path() + "..."
+}
+
+// setContent stores loaded file content and shows it if it's for the current file
+func (v *DiffViewer) setContent(msg MRFileContentLoadedMsg) {
+	if msg.Err != nil {
+		v.notice = "Loading content failed: " + DescribeError(msg.Err)
+		return
+	}
+	content := strings.TrimSuffix(strings.ReplaceAll(msg.Content, "\r\n", "\n"), "\n")
+	v.contents[msg.Path] = strings.Split(content, "\n")
+	if len(v.files) > 0 && v.files[v.fileIdx].Path() == msg.Path {
+		v.showFull = true
+		v.setFile(v.fileIdx)
+	}
+}
+
+// TakeContentRequest returns the path whose content should be loaded, once
+func (v *DiffViewer) TakeContentRequest() string {
+	path := v.wantsContent
+	v.wantsContent = ""
+	return path
+}
+
+// View renders the viewer
+func (v DiffViewer) View() string {
+	contentWidth := v.contentWidth()
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor)
+	var title string
+	if len(v.files) == 0 {
+		title = titleStyle.Render("No changes")
+	} else {
+		file := v.files[v.fileIdx]
+		adds, dels := file.Stats()
+		name := file.Path()
+		if file.OldPath != "" && file.NewPath != "" && file.OldPath != file.NewPath {
+			name = file.OldPath + " → " + file.NewPath
+		}
+		title = titleStyle.Render(truncateString(name, contentWidth-24)) +
+			DimStyle.Render(fmt.Sprintf("  (%d/%d)  ", v.fileIdx+1, len(v.files))) +
+			SuccessStyle.Render(fmt.Sprintf("+%d", adds)) + " " +
+			ErrorStyle.Render(fmt.Sprintf("-%d", dels))
+	}
+	if v.local {
+		title += DimStyle.Render("  [working tree]")
+	}
+	if v.showFull {
+		title += DimStyle.Render("  [full file]")
+	} else if v.splitActive() {
+		title += DimStyle.Render("  [side-by-side]")
+	}
+	if len(v.pending) > 0 {
+		title += CommentStyle.Render(fmt.Sprintf("  %d pending", len(v.pending)))
+	}
+
+	body := v.renderBody(contentWidth)
+	help := v.footer()
+	footer := DimStyle.Render(ansi.Truncate(help, contentWidth, "…"))
+	if v.notice != "" {
+		footer = DimStyle.Render(v.notice)
+	}
+
+	sections := []string{
+		title,
+		DimStyle.Render(strings.Repeat("─", contentWidth)),
+		body,
+	}
+	if v.composer != nil {
+		sections = append(sections, v.composerView())
+	}
+	sections = append(sections, footer)
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+	return ModalStyle.Width(v.width).Render(content)
+}
+
+// renderBody renders the visible rows, padded to the body height
+func (v DiffViewer) renderBody(contentWidth int) string {
+	page := v.bodyHeight()
+	var lines []string
+
+	if len(v.files) > 0 && len(v.rows) == 0 {
+		file := v.files[v.fileIdx]
+		switch {
+		case file.Binary:
+			lines = append(lines, DimStyle.Render("Binary file not shown"))
+		case file.OldPath != file.NewPath:
+			lines = append(lines, DimStyle.Render("File renamed without changes"))
+		default:
+			lines = append(lines, DimStyle.Render("No textual changes"))
+		}
+	}
+
+	for i := v.offset; i < len(v.display) && len(lines) < page; i++ {
+		lines = append(lines, v.renderDisplayRow(i, contentWidth)...)
+	}
+	if len(lines) > page {
+		lines = lines[:page]
+	}
+	for len(lines) < page {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDisplayRow renders display row i as one or more screen lines
+func (v DiffViewer) renderDisplayRow(i, width int) []string {
+	d := v.display[i]
+	marker := " "
+	switch {
+	case i == v.cursor && d.kind == rowPair && v.cursorSide() == platform.SideNew:
+		// The cursor marker moves to the separator when the new side is picked
+	case i == v.cursor:
+		marker = SelectedIndicator.Render("▌")
+	case v.inSelection(i):
+		marker = SelectedIndicator.Render("▎")
+	}
+
+	if d.kind == rowHunkHeader {
+		h := v.files[v.fileIdx].Hunks[d.hunk]
+		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldCount, h.NewStart, h.NewCount)
+		if h.Section != "" {
+			header += " " + h.Section
+		}
+		return []string{marker + HunkHeaderStyle.Render(ansi.Truncate(header, width-1, "…"))}
+	}
+
+	if d.kind != rowPair {
+		lines := v.renderSide(v.rows[d.old], !v.showFull, width-1)
+		for j := range lines {
+			if j == 0 {
+				lines[j] = marker + lines[j]
+			} else {
+				lines[j] = " " + lines[j]
+			}
+		}
+		return append(lines, renderThreads(v.threadsAt(i), width, v.opts.Wrap)...)
+	}
+
+	// Side-by-side: each half gets its own gutter, separated by a rule
+	half := (width - 1 - 1) / 2
+	var left, right []string
+	if d.old >= 0 {
+		left = v.renderSide(v.rows[d.old], false, half)
+	}
+	if d.new >= 0 {
+		right = v.renderSide(v.rows[d.new], false, half)
+	}
+	n := max(len(left), len(right), 1)
+	lines := make([]string, n)
+	for j := 0; j < n; j++ {
+		l, r := "", ""
+		if j < len(left) {
+			l = left[j]
+		}
+		if j < len(right) {
+			r = right[j]
+		}
+		prefix := " "
+		separator := DimStyle.Render("│")
+		if j == 0 {
+			prefix = marker
+			if i == v.cursor && v.cursorSide() == platform.SideNew {
+				separator = SelectedIndicator.Render("▐")
+			}
+		}
+		lines[j] = prefix + padToWidth(l, half) + separator + r
+	}
+	return append(lines, renderThreads(v.threadsAt(i), width, v.opts.Wrap)...)
+}
+
+// renderSide renders a single row with its gutter. With both numbers the
+// gutter shows old and new line numbers, otherwise just the line's own number.
+func (v DiffViewer) renderSide(row diffRow, bothNumbers bool, width int) []string {
+	var gutter string
+	switch {
+	case bothNumbers:
+		gutter = fmt.Sprintf("%s %s │", lineNum(row.line.OldNum), lineNum(row.line.NewNum))
+	case row.line.Kind == diff.Removed:
+		gutter = fmt.Sprintf("%s │", lineNum(row.line.OldNum))
+	default:
+		gutter = fmt.Sprintf("%s │", lineNum(row.line.NewNum))
+	}
+	gutterWidth := lipgloss.Width(gutter)
+	textWidth := width - gutterWidth
+	if textWidth < 4 {
+		textWidth = 4
+	}
+
+	sign := " "
+	switch row.line.Kind {
+	case diff.Added:
+		sign = "+"
+	case diff.Removed:
+		sign = "-"
+	}
+	code := markerStyle(v.palette, row.line.Kind).Render(sign) +
+		renderCode(v.palette, row.line.Kind, row.tokens, row.spans)
+
+	if !v.opts.Wrap {
+		return []string{DimStyle.Render(gutter) + ansi.Truncate(code, textWidth, "…")}
+	}
+
+	wrapped := strings.Split(ansi.Hardwrap(code, textWidth, true), "\n")
+	blank := strings.Repeat(" ", gutterWidth-1) + "│"
+	lines := make([]string, len(wrapped))
+	for j, w := range wrapped {
+		if j == 0 {
+			lines[j] = DimStyle.Render(gutter) + w
+		} else {
+			lines[j] = DimStyle.Render(blank) + w
+		}
+	}
+	return lines
+}
+
+// padToWidth pads a rendered string with spaces to the given display width
+func padToWidth(s string, width int) string {
+	if w := ansi.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// lineNum formats a line number for the gutter, blank for 0
+func lineNum(n int) string {
+	if n == 0 {
+		return "    "
+	}
+	return fmt.Sprintf("%4d", n)
+}
+
+// expandTabs replaces tabs with spaces so widths are predictable
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/colorprofile"
+)
+
+const viewerPatch = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+-one
++uno
+ two
+@@ -10,2 +10,2 @@
+ ten
+-eleven
++once
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1 +1 @@
+-x
++y
+`
+
+func TestDiffViewerNavigation(t *testing.T) {
+	v := NewDiffViewer(diff.Parse(viewerPatch), 0, DiffOptions{Profile: colorprofile.ANSI256}, 120, 40)
+
+	press := func(s string) {
+		var k tea.KeyPressMsg
+		switch s {
+		case "tab":
+			k = tea.KeyPressMsg{Code: tea.KeyTab}
+		default:
+			k = tea.KeyPressMsg{Code: rune(s[0]), Text: s}
+		}
+		v, _ = v.Update(k)
+	}
+
+	press("n")
+	if row := v.display[v.cursor]; row.kind != rowHunkHeader || row.hunk != 1 {
+		t.Fatalf("after n: cursor on %+v, want second hunk header", row)
+	}
+	press("N")
+	if v.cursor != 0 {
+		t.Errorf("after N: cursor %d, want 0", v.cursor)
+	}
+
+	press("tab")
+	if v.fileIdx != 1 || v.cursor != 0 {
+		t.Errorf("after tab: file %d cursor %d, want file 1 cursor 0", v.fileIdx, v.cursor)
+	}
+	if !strings.Contains(v.View(), "b.go") {
+		t.Error("expected view to show b.go")
+	}
+}
+
+func TestDiffViewerSplitFallback(t *testing.T) {
+	v := NewDiffViewer(diff.Parse(viewerPatch), 0, DiffOptions{Profile: colorprofile.ANSI256, Split: true}, 160, 40)
+	if !v.splitActive() {
+		t.Fatal("expected side-by-side on a wide terminal")
+	}
+	// Header, then the paired one/uno row, then two/two
+	if len(v.display) != 6 || v.display[1].old != 1 || v.display[1].new != 2 {
+		t.Errorf("unexpected side-by-side rows: %+v", v.display)
+	}
+
+	v, _ = v.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	if v.splitActive() {
+		t.Error("expected unified layout below SplitMinWidth")
+	}
+	if len(v.display) != len(v.rows) {
+		t.Errorf("unified layout has %d rows, want %d", len(v.display), len(v.rows))
+	}
+	if !v.Options().Split {
+		t.Error("split preference should survive the fallback")
+	}
+
+	v, _ = v.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	if !v.splitActive() {
+		t.Error("expected side-by-side again after widening")
+	}
+	if !strings.Contains(v.View(), "│") {
+		t.Error("expected a column separator in the side-by-side view")
+	}
+}
+
+func TestDiffViewerComments(t *testing.T) {
+	v := NewDiffViewer(diff.Parse(viewerPatch), 0, DiffOptions{Profile: colorprofile.ANSI256}, 120, 40)
+	v.SetComments([]platform.ReviewComment{
+		{Path: "a.go", Side: platform.SideNew, End: platform.LineRef{New: 2}, Body: "existing", Author: "alice"},
+	})
+
+	key := func(code rune, text string, mod tea.KeyMod) {
+		v, _ = v.Update(tea.KeyPressMsg{Code: code, Text: text, Mod: mod})
+	}
+
+	// Rows of the first hunk: header, -one, +uno, two
+	key('j', "j", 0)
+	key('j', "j", 0)
+	if got := v.threadsAt(3); len(got) != 1 || got[0].Author != "alice" {
+		t.Errorf("expected alice's comment under 'two', got %+v", got)
+	}
+
+	// Select +uno and two, then comment on the range
+	key('V', "V", 0)
+	key('j', "j", 0)
+	key('c', "c", 0)
+	if v.composer == nil {
+		t.Fatal("expected the composer to open")
+	}
+	v.composer.SetValue("looks good")
+	key('s', "", tea.ModCtrl)
+
+	pending := v.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("got %d pending comments, want 1", len(pending))
+	}
+	expected := platform.ReviewComment{
+		Path:    "a.go",
+		OldPath: "a.go",
+		Side:    platform.SideNew,
+		Start:   platform.LineRef{New: 1},
+		End:     platform.LineRef{Old: 2, New: 2},
+		Body:    "looks good",
+	}
+	if pending[0] != expected {
+		t.Errorf("got %+v, want %+v", pending[0], expected)
+	}
+
+	key('S', "S", 0)
+	if got := v.TakeSubmitRequest(); len(got) != 1 {
+		t.Errorf("expected a submit request with 1 comment, got %v", got)
+	}
+	v.SetSubmitResult(nil)
+	if len(v.Pending()) != 0 {
+		t.Error("expected pending comments to be cleared after submitting")
+	}
+}
+
+func TestDiffViewerKeys(t *testing.T) {
+	keys, err := keymap.New(map[string][]string{"diff.next_hunk": {"J"}, "diff.wrap": {"W"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewDiffViewer(diff.Parse(viewerPatch), 0, DiffOptions{Profile: colorprofile.ANSI256}, 200, 40).WithKeys(keys)
+
+	v, _ = v.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	if v.cursor != 0 {
+		t.Errorf("n moved the cursor to %d after rebinding", v.cursor)
+	}
+	v, _ = v.Update(tea.KeyPressMsg{Code: 'J', Text: "J"})
+	if row := v.display[v.cursor]; row.kind != rowHunkHeader || row.hunk != 1 {
+		t.Errorf("after J: cursor on %+v, want second hunk header", row)
+	}
+	v, _ = v.Update(tea.KeyPressMsg{Code: 'W', Text: "W"})
+	if !v.Options().Wrap {
+		t.Error("W didn't turn wrapping on")
+	}
+	if footer := v.footer(); !strings.Contains(footer, "[J] next hunk") || !strings.Contains(footer, "[W] wrap") {
+		t.Errorf("footer doesn't show the rebound keys: %s", footer)
+	}
+
+	// The working tree can't be commented on, so its footer doesn't offer it
+	local := NewWorkingDiffViewer(diff.Parse(viewerPatch), DiffOptions{Profile: colorprofile.ANSI256}, 200, 40)
+	if footer := local.footer(); strings.Contains(footer, "comment") || !strings.Contains(footer, "[esc] close") {
+		t.Errorf("working tree footer: %s", footer)
+	}
+}
@@ -1,23 +1,55 @@
 package ui
 
 import (
+	"fmt"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
 	tea "charm.land/bubbletea/v2"
 )
 
-// DirtyConfirmModal shows a warning when working tree has uncommitted changes
+// DirtyChoice is what to do with uncommitted changes before a checkout
+type DirtyChoice int
+
+const (
+	DirtyUndecided DirtyChoice = iota
+	DirtyCarry                 // check out with the changes left in place
+	DirtyStash                 // stash them and restore them on returning
+	DirtyCancel
+)
+
+// maxDirtyFiles is the number of changed files listed before summarising the rest
+const maxDirtyFiles = 10
+
+// DirtyConfirmModal lists the uncommitted changes in the working tree and
+// asks what to do with them before a checkout. Changes to tracked files can
+// conflict with the target branch, untracked files normally can't.
 type DirtyConfirmModal struct {
 	branch    string
-	confirmed bool
-	cancelled bool
+	files     []git.FileStatus
+	untracked bool // only untracked files changed
+	choice    DirtyChoice
+	wantsDiff bool
+	notice    string
+	keys      keymap.Keymap
 }
 
 // NewDirtyConfirmModal creates a new dirty confirm modal
-func NewDirtyConfirmModal(branch string) DirtyConfirmModal {
+func NewDirtyConfirmModal(branch string, files []git.FileStatus) DirtyConfirmModal {
 	return DirtyConfirmModal{
-		branch: branch,
+		branch:    branch,
+		files:     files,
+		untracked: git.OnlyUntracked(files),
 	}
 }
 
+// WithKeys sets the keymap, whose dirty bindings the modal handles
+func (m DirtyConfirmModal) WithKeys(keys keymap.Keymap) DirtyConfirmModal {
+	m.keys = keys
+	return m
+}
+
 // Init returns the initial command
 func (m DirtyConfirmModal) Init() tea.Cmd {
 	return nil
@@ -27,11 +59,20 @@ func (m DirtyConfirmModal) Init() tea.Cmd {
 func (m DirtyConfirmModal) Update(msg tea.Msg) (DirtyConfirmModal, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
-		switch msg.String() {
-		case "y", "Y", "enter":
-			m.confirmed = true
-		case "n", "N", "esc":
-			m.cancelled = true
+		m.notice = ""
+		switch key := msg.String(); {
+		case m.keys.Matches(key, keymap.DirtyCarry):
+			m.choice = DirtyCarry
+		case m.keys.Matches(key, keymap.DirtyStash):
+			m.choice = DirtyStash
+		case m.keys.Matches(key, keymap.DirtyDiff):
+			if m.untracked {
+				m.notice = "Untracked files have no diff"
+			} else {
+				m.wantsDiff = true
+			}
+		case m.keys.Matches(key, keymap.DirtyCancel):
+			m.choice = DirtyCancel
 		}
 	}
 	return m, nil
@@ -39,21 +80,68 @@ func (m DirtyConfirmModal) Update(msg tea.Msg) (DirtyConfirmModal, tea.Cmd) {
 
 // View renders the modal
 func (m DirtyConfirmModal) View() string {
-	content := ErrorStyle.Render("Warning: Uncommitted Changes") + "\n\n"
-	content += "You have uncommitted changes in your working tree.\n"
-	content += "Checking out '" + m.branch + "' may cause conflicts.\n\n"
-	content += "Proceed anyway?\n\n"
-	content += "[y] Yes, checkout  |  [n] No, cancel"
+	var content string
+	if m.untracked {
+		content = StatusDraftStyle.Render("Untracked Files") + "\n\n"
+		content += "These files aren't tracked by git and stay in place\n"
+		content += "when checking out '" + m.branch + "':\n\n"
+	} else {
+		content = ErrorStyle.Render("Warning: Uncommitted Changes") + "\n\n"
+		content += "Checking out '" + m.branch + "' with these changes may cause conflicts:\n\n"
+	}
+
+	for i, f := range m.files {
+		if i == maxDirtyFiles {
+			content += DimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.files)-maxDirtyFiles)) + "\n"
+			break
+		}
+		code := f.Code()
+		if f.Untracked() {
+			code = DimStyle.Render(code)
+		} else {
+			code = ErrorStyle.Render(code)
+		}
+		name := f.Path
+		if f.OrigPath != "" {
+			name = f.OrigPath + " → " + f.Path
+		}
+		content += "  " + code + " " + name + "\n"
+	}
+	content += "\n"
+
+	if m.notice != "" {
+		content += DimStyle.Render(m.notice) + "\n\n"
+	}
+	carry, stash, cancel := keyHint(m.keys, keymap.DirtyCarry), keyHint(m.keys, keymap.DirtyStash), keyHint(m.keys, keymap.DirtyCancel)
+	if m.untracked {
+		content += fmt.Sprintf("[%s] Checkout  |  [%s] Stash them too  |  [%s] Cancel", carry, stash, cancel)
+	} else {
+		content += fmt.Sprintf("[%s] Stash & checkout  |  [%s] Carry over  |  [%s] Diff  |  [%s] Cancel\n",
+			stash, carry, keyHint(m.keys, keymap.DirtyDiff), cancel)
+		content += DimStyle.Render("Stashed changes are restored when you check out the current branch again")
+	}
 
 	return ModalStyle.Render(content)
 }
 
-// IsConfirmed returns true if user confirmed
-func (m DirtyConfirmModal) IsConfirmed() bool {
-	return m.confirmed
+// Choice returns what the user decided, DirtyUndecided until they do
+func (m DirtyConfirmModal) Choice() DirtyChoice {
+	return m.choice
+}
+
+// OnlyUntracked reports whether the changes are all untracked files
+func (m DirtyConfirmModal) OnlyUntracked() bool {
+	return m.untracked
+}
+
+// TakeDiffRequest returns true once if the user asked to see the diff
+func (m *DirtyConfirmModal) TakeDiffRequest() bool {
+	wants := m.wantsDiff
+	m.wantsDiff = false
+	return wants
 }
 
-// IsCancelled returns true if user cancelled
-func (m DirtyConfirmModal) IsCancelled() bool {
-	return m.cancelled
+// SetNotice shows a message above the options
+func (m *DirtyConfirmModal) SetNotice(notice string) {
+	m.notice = notice
 }
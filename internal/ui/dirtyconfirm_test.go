@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDirtyConfirmModal(t *testing.T) {
+	files := []git.FileStatus{
+		{Path: "main.go", Index: ' ', Worktree: 'M'},
+		{Path: "notes.txt", Index: '?', Worktree: '?'},
+	}
+	m := NewDirtyConfirmModal("fix", files)
+	view := ansi.Strip(m.View())
+	for _, want := range []string{" M main.go", "?? notes.txt", "[s] Stash & checkout", "[d] Diff"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	if !m.TakeDiffRequest() || m.TakeDiffRequest() {
+		t.Error("diff request should be taken exactly once")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	if m.Choice() != DirtyStash {
+		t.Errorf("got choice %v, want stash", m.Choice())
+	}
+
+	// Untracked files can't conflict and have no diff
+	m = NewDirtyConfirmModal("fix", files[1:])
+	if !m.OnlyUntracked() {
+		t.Fatal("expected untracked-only changes")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	if m.TakeDiffRequest() {
+		t.Error("untracked-only changes should not request a diff")
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.Choice() != DirtyCarry {
+		t.Errorf("got choice %v, want carry over", m.Choice())
+	}
+}
+
+func TestDirtyConfirmModalKeys(t *testing.T) {
+	keys, err := keymap.New(map[string][]string{"dirty.stash": {"S"}, "dirty.cancel": {"q"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewDirtyConfirmModal("fix", []git.FileStatus{{Path: "main.go", Index: ' ', Worktree: 'M'}}).WithKeys(keys)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[S] Stash & checkout") || !strings.Contains(view, "[q] Cancel") {
+		t.Errorf("view doesn't show the rebound keys:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	if m.Choice() != DirtyUndecided {
+		t.Errorf("s chose %v after rebinding stash", m.Choice())
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'q', Text: "q"})
+	if m.Choice() != DirtyCancel {
+		t.Errorf("got choice %v, want cancel", m.Choice())
+	}
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"errors"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// installURLs says where to get each forge CLI
+var installURLs = map[string]string{
+	"gh":   "https://cli.github.com",
+	"glab": "https://gitlab.com/gitlab-org/cli",
+}
+
+// DescribeError turns a failed gh or glab command into a message saying what
+// to do about it. Errors that couldn't be classified keep their own message.
+func DescribeError(err error) string {
+	cli := "gh"
+	var platformErr *platform.Error
+	if errors.As(err, &platformErr) {
+		cli = platformErr.CLI
+	}
+
+	switch {
+	case errors.Is(err, platform.ErrNotInstalled):
+		return cli + " is not installed, get it from " + installURLs[cli]
+	case errors.Is(err, platform.ErrNotAuthenticated):
+		return "Not logged in to " + cli + ", run `" + cli + " auth login`"
+	case errors.Is(err, platform.ErrRateLimited):
+		return "Rate limited by the API, wait a few minutes and try again"
+	case errors.Is(err, platform.ErrNetwork):
+		return "Can't reach the server, check your network connection"
+	case errors.Is(err, platform.ErrNotFound):
+		return "Not found: it may have been deleted, or you may not have access"
+	case errors.Is(err, cmd.ErrTimeout):
+		return err.Error() + ", the server may be slow, try again"
+	}
+	return err.Error()
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/cmd"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+func TestDescribeError(t *testing.T) {
+	failed := &cmd.Error{Name: "glab", ExitCode: 1, Stderr: "401 Unauthorized", Err: errors.New("exit status 1")}
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&platform.Error{CLI: "glab", Kind: platform.ErrNotAuthenticated, Err: failed}, "run `glab auth login`"},
+		{&platform.Error{CLI: "gh", Kind: platform.ErrNotInstalled, Err: failed}, "https://cli.github.com"},
+		{&platform.Error{CLI: "gh", Kind: platform.ErrRateLimited, Err: failed}, "Rate limited"},
+		{&platform.Error{CLI: "gh", Err: failed}, "exit status 1: 401 Unauthorized"},
+		{errors.New("plain failure"), "plain failure"},
+	}
+	for _, tt := range tests {
+		if got := DescribeError(tt.err); !strings.Contains(got, tt.want) {
+			t.Errorf("DescribeError(%v) = %q, want it to contain %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// contextTitles names the keymap contexts in the help
+var contextTitles = map[string]string{
+	keymap.Dashboard: "MR list",
+	keymap.Detail:    "MR detail",
+	keymap.Picker:    "Pickers",
+	keymap.Diff:      "Diff viewer",
+	keymap.Comment:   "Review comment",
+	keymap.Checkout:  "Checkout",
+	keymap.Dirty:     "Uncommitted changes",
+	keymap.Updating:  "Update",
+	keymap.Worktree:  "Worktrees",
+}
+
+// HelpModal lists every binding of the active keymap, a column per context,
+// on as many pages as the screen needs
+type HelpModal struct {
+	keys   keymap.Keymap
+	width  int
+	height int
+	page   int
+}
+
+// NewHelpModal creates the help for keys
+func NewHelpModal(keys keymap.Keymap, width, height int) HelpModal {
+	return HelpModal{keys: keys, width: width, height: height}
+}
+
+// Update turns the page on tab or space. Any other key closes the help,
+// which it reports.
+func (h HelpModal) Update(msg tea.KeyPressMsg) (HelpModal, bool) {
+	switch msg.String() {
+	case "tab", "space":
+		if pages := len(h.pages()); pages > 1 {
+			h.page = (h.page + 1) % pages
+			return h, false
+		}
+	}
+	return h, true
+}
+
+// View renders the help
+func (h HelpModal) View() string {
+	pages := h.pages()
+	page := min(h.page, len(pages)-1)
+	hint := "any key closes"
+	if len(pages) > 1 {
+		hint = fmt.Sprintf("tab next page (%d/%d) · any other key closes", page+1, len(pages))
+	}
+	content := pages[page] + "\n\n" +
+		DimStyle.Render("ctrl+c always quits · rebind keys under [keys.<context>] in the config · "+hint)
+	return ModalStyle.Render(content)
+}
+
+// pages lays the context columns out in rows that fit the screen's width,
+// and the rows on pages that fit its height
+func (h HelpModal) pages() []string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor)
+	keyStyle := lipgloss.NewStyle().Foreground(primaryColor)
+	// The modal's border and padding, and the hint under the columns
+	maxWidth, maxHeight := h.width-6, h.height-5
+
+	var rows [][]string
+	rowWidth := 0
+	for _, ctx := range keymap.Contexts {
+		bindings := h.keys.Bindings(ctx)
+		names := make([]string, len(bindings))
+		width := 0
+		for i, b := range bindings {
+			names[i] = keyNames(b.Keys, ", ")
+			width = max(width, lipgloss.Width(names[i]))
+		}
+		lines := []string{titleStyle.Render(contextTitles[ctx])}
+		for i, b := range bindings {
+			pad := strings.Repeat(" ", width-lipgloss.Width(names[i]))
+			lines = append(lines, keyStyle.Render(names[i])+pad+"  "+b.Help)
+		}
+		column := lipgloss.NewStyle().PaddingRight(4).Render(strings.Join(lines, "\n"))
+
+		if len(rows) == 0 || rowWidth+lipgloss.Width(column) > maxWidth {
+			rows = append(rows, nil)
+			rowWidth = 0
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], column)
+		rowWidth += lipgloss.Width(column)
+	}
+
+	var pages []string
+	page := ""
+	for _, columns := range rows {
+		row := lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+		switch {
+		case page == "":
+			page = row
+		case lipgloss.Height(page)+1+lipgloss.Height(row) > maxHeight:
+			pages = append(pages, page)
+			page = row
+		default:
+			page += "\n\n" + row
+		}
+	}
+	return append(pages, page)
+}
+
+// keyNames joins the names of keys with sep
+func keyNames(keys []string, sep string) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = keymap.KeyName(key)
+	}
+	return strings.Join(names, sep)
+}
+
+// footerHelp renders the footer bindings of a context with format, which
+// gets the first key and the help, leaving out the skipped actions
+func footerHelp(keys keymap.Keymap, ctx, format string, skip ...keymap.Action) []string {
+	var items []string
+	for _, b := range keys.Footer(ctx) {
+		if slices.Contains(skip, b.Action) {
+			continue
+		}
+		items = append(items, fmt.Sprintf(format, keymap.KeyName(b.Keys[0]), b.Help))
+	}
+	return items
+}
+
+// keyHint returns the name of the first key bound to action, as footers show it
+func keyHint(keys keymap.Keymap, action keymap.Action) string {
+	return keymap.KeyName(keys.Keys(action)[0])
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+func TestFooterHelp(t *testing.T) {
+	keys, err := keymap.New(map[string][]string{"picker.find": {"s", "/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(footerHelp(keys, keymap.Picker, "%s %s", keymap.PickerToggle), " │ ")
+	if want := "s find │ enter select │ esc close"; got != want {
+		t.Errorf("footer = %q, want %q", got, want)
+	}
+
+	// The help lists every key of every context
+	view := NewHelpModal(keys, 120, 40).View()
+	for _, want := range []string{"MR list", "MR detail", "Pickers", "s, /", "↑, k", "next tab"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("help doesn't show %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestHelpModalPages(t *testing.T) {
+	var keys keymap.Keymap
+	// Every context is on some page of a small screen, tab turns the page
+	help := NewHelpModal(keys, 100, 30)
+	pages := len(help.pages())
+	if pages < 2 {
+		t.Fatalf("%d page(s) on a 100x30 screen", pages)
+	}
+	seen := make(map[string]bool)
+	for range pages {
+		view := help.View()
+		if h := lipgloss.Height(view); h > 30 {
+			t.Errorf("page is %d lines high", h)
+		}
+		for _, ctx := range keymap.Contexts {
+			if strings.Contains(view, contextTitles[ctx]) {
+				seen[ctx] = true
+			}
+		}
+		var closed bool
+		if help, closed = help.Update(tea.KeyPressMsg{Code: tea.KeyTab}); closed {
+			t.Fatal("tab closed the help")
+		}
+	}
+	for _, ctx := range keymap.Contexts {
+		if !seen[ctx] {
+			t.Errorf("no page shows %s", ctx)
+		}
+	}
+	if _, closed := help.Update(tea.KeyPressMsg{Code: 'q', Text: "q"}); !closed {
+		t.Error("q didn't close the help")
+	}
+}
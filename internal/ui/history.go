@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+)
+
+// PreviousBranchMsg is sent when the branch to go back to is known
+type PreviousBranchMsg struct {
+	Branch string // "" if there is none
+	Err    error
+}
+
+// HistoryLoadedMsg is sent when the checkout history is loaded
+type HistoryLoadedMsg struct {
+	Entries []git.HistoryEntry
+	Err     error
+}
+
+// NewHistoryPicker creates a single-select picker over recently checked out
+// branches, newest first, showing the MR, when and whether changes are
+// stashed on each
+func NewHistoryPicker(recent []git.HistoryEntry, now time.Time, width, height int) Picker {
+	items := make([]PickerItem, len(recent))
+	for i, e := range recent {
+		var details []string
+		if e.MR != 0 {
+			details = append(details, fmt.Sprintf("#%d", e.MR))
+		}
+		details = append(details, formatAge(now.Sub(e.Time)))
+		if e.Stash != "" {
+			details = append(details, "stashed changes")
+		}
+		items[i] = PickerItem{Key: e.Branch, Detail: strings.Join(details, " · ")}
+	}
+	return NewPicker("Recent branches", items, width, height)
+}
+
+// formatAge formats how long ago something happened, e.g. "5m ago"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestHistoryPicker(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := []git.HistoryEntry{
+		{Branch: "alice/fix", MR: 7, Time: now.Add(-5 * time.Minute)},
+		{Branch: "main", Time: now.Add(-3 * time.Hour), Stash: "abc123"},
+	}
+	p := NewHistoryPicker(recent, now, 80, 20)
+
+	items := p.allItems
+	if items[0].Detail != "#7 · 5m ago" || items[1].Detail != "3h ago · stashed changes" {
+		t.Errorf("got details %q and %q", items[0].Detail, items[1].Detail)
+	}
+	if got := p.SelectedKey(); got != "alice/fix" {
+		t.Errorf("selected %q, want the most recent branch", got)
+	}
+	p, _ = p.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+	if got := p.SelectedKey(); got != "main" {
+		t.Errorf("selected %q after moving down, want main", got)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{42 * time.Minute, "42m ago"},
+		{5 * time.Hour, "5h ago"},
+		{50 * time.Hour, "2d ago"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
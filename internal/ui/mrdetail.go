@@ -1,14 +1,16 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
 
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
-	"charm.land/lipgloss/v2"
 )
 
 // MRDetailLoadedMsg is sent when MR detail data is loaded
@@ -23,32 +25,68 @@ type MRCommitsLoadedMsg struct {
 	Err     error
 }
 
+// EditKind identifies which MR attribute the user wants to edit
+type EditKind int
+
+const (
+	EditNone EditKind = iota
+	EditReviewers
+	EditAssignees
+	EditLabels
+)
+
+// MREditedMsg is sent when reviewers, assignees or labels were updated
+type MREditedMsg struct {
+	Kind EditKind
+	Err  error
+}
+
 // MRDetailModal displays detailed information about an MR/PR
 type MRDetailModal struct {
+	ctx           context.Context // cancelled on close, stopping loads for this MR
+	cancel        context.CancelFunc
 	mr            platform.MR
 	detail        platform.MRDetail
 	platformName  string // "github" or "gitlab"
 	loading       bool
 	err           error
 	spinner       spinner.Model
-	cursor        int  // cursor position in file list
-	wantsCheckout bool // signals dashboard to start checkout
-	wantsCommits  bool // signals dashboard to load commits
+	cursor        int      // cursor position in file list
+	wantsCheckout bool     // signals dashboard to start checkout
+	wantsWorktree bool     // signals dashboard to check out into a worktree
+	wantsCommits  bool     // signals dashboard to load commits
+	wantsEdit     EditKind // signals dashboard to open an edit picker
+	notice        string   // result of the last edit, shown above the footer
+	stale         bool     // the detail is from the cache, a load is running
+	wantsDiff     bool     // signals dashboard to load the MR patch
 	descViewer    *DescriptionViewer
 	commitsViewer *CommitsViewer
+	diffViewer    *DiffViewer
 	commits       []platform.Commit
+	diffFiles     []diff.File              // parsed MR patch, nil until loaded
+	wantsContent  string                   // signals dashboard to load a file's content
+	diffOptions   DiffOptions              // diff viewer settings, kept across openings
+	comments      []platform.ReviewComment // existing line comments
+	pending       []platform.ReviewComment // review comments not yet submitted
+	wantsSubmit   []platform.ReviewComment // signals dashboard to submit a review
+	closeWarned   bool                     // warned that closing discards pending comments
+	keys          keymap.Keymap
 	width         int
 	height        int
 }
 
 // NewMRDetailModal creates a new MR detail modal
-func NewMRDetailModal(mr platform.MR, platformName string, width, height int) MRDetailModal {
+func NewMRDetailModal(ctx context.Context, mr platform.MR, platformName string, diffOptions DiffOptions, width, height int) MRDetailModal {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
 
 	return MRDetailModal{
+		ctx:          ctx,
+		cancel:       cancel,
 		mr:           mr,
 		platformName: platformName,
+		diffOptions:  diffOptions,
 		loading:      true,
 		spinner:      s,
 		cursor:       0,
@@ -57,6 +95,22 @@ func NewMRDetailModal(mr platform.MR, platformName string, width, height int) MR
 	}
 }
 
+// WithKeys sets the keymap, whose detail bindings the modal handles
+func (m MRDetailModal) WithKeys(keys keymap.Keymap) MRDetailModal {
+	m.keys = keys
+	return m
+}
+
+// Context returns the context for loads that belong to this MR
+func (m MRDetailModal) Context() context.Context {
+	return m.ctx
+}
+
+// Close cancels any load still running for this MR
+func (m MRDetailModal) Close() {
+	m.cancel()
+}
+
 // Init returns the initial command (spinner tick)
 func (m MRDetailModal) Init() tea.Cmd {
 	return m.spinner.Tick
@@ -65,20 +119,48 @@ func (m MRDetailModal) Init() tea.Cmd {
 // SetDetail sets the detail data after loading
 func (m *MRDetailModal) SetDetail(detail platform.MRDetail, err error) {
 	m.loading = false
-	if err != nil {
+	stale := m.stale
+	m.stale = false
+	switch {
+	case err != nil && stale:
+		// Keep showing the cached detail
+		m.notice = ErrorStyle.Render("Refresh failed: " + DescribeError(err))
+	case err != nil:
 		m.err = err
-	} else {
+	default:
 		m.detail = detail
+		if stale {
+			m.notice = ""
+		}
 	}
 }
 
+// SetCachedDetail shows a cached detail until SetDetail brings the current one
+func (m *MRDetailModal) SetCachedDetail(detail platform.MRDetail) {
+	m.loading = false
+	m.detail = detail
+	m.stale = true
+	m.notice = DimStyle.Render("Cached, refreshing...")
+}
+
 // Update handles messages
 func (m MRDetailModal) Update(msg tea.Msg) (MRDetailModal, tea.Cmd) {
+	// Resizes apply to the modal and any open diff viewer
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = size.Width
+		m.height = size.Height
+		if m.diffViewer != nil {
+			newViewer, _ := m.diffViewer.Update(size)
+			m.diffViewer = &newViewer
+		}
+		return m, nil
+	}
+
 	// If description viewer is active, delegate to it
 	if m.descViewer != nil {
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
-			if msg.String() == "esc" {
+			if m.keys.Matches(msg.String(), keymap.DetailClose) {
 				m.descViewer = nil
 				return m, nil
 			}
@@ -88,11 +170,40 @@ func (m MRDetailModal) Update(msg tea.Msg) (MRDetailModal, tea.Cmd) {
 		return m, cmd
 	}
 
+	// If diff viewer is active, delegate to it
+	if m.diffViewer != nil {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			if m.keys.Matches(msg.String(), keymap.DiffClose) && !m.diffViewer.CapturesClose() {
+				m.diffViewer = nil
+				return m, nil
+			}
+		case ReviewCommentsLoadedMsg:
+			m.setComments(msg)
+			return m, nil
+		case ReviewSubmittedMsg:
+			m.setSubmitResult(msg)
+			return m, nil
+		}
+		newViewer, cmd := m.diffViewer.Update(msg)
+		m.diffViewer = &newViewer
+		m.diffOptions = m.diffViewer.Options()
+		m.pending = m.diffViewer.Pending()
+		m.closeWarned = false
+		if comments := m.diffViewer.TakeSubmitRequest(); comments != nil {
+			m.wantsSubmit = comments
+		}
+		if path := m.diffViewer.TakeContentRequest(); path != "" {
+			m.wantsContent = path
+		}
+		return m, cmd
+	}
+
 	// If commits viewer is active, delegate to it
 	if m.commitsViewer != nil {
 		switch msg := msg.(type) {
 		case tea.KeyPressMsg:
-			if msg.String() == "esc" {
+			if m.keys.Matches(msg.String(), keymap.DetailClose) {
 				m.commitsViewer = nil
 				return m, nil
 			}
@@ -135,17 +246,38 @@ func (m MRDetailModal) Update(msg tea.Msg) (MRDetailModal, tea.Cmd) {
 		m.wantsCommits = false
 		return m, nil
 
+	case ReviewCommentsLoadedMsg:
+		m.setComments(msg)
+		return m, nil
+
+	case ReviewSubmittedMsg:
+		m.setSubmitResult(msg)
+		return m, nil
+
+	case MRDiffLoadedMsg:
+		if msg.Err != nil {
+			m.notice = ErrorStyle.Render("Loading diff failed: " + DescribeError(msg.Err))
+			return m, nil
+		}
+		m.notice = ""
+		m.diffFiles = diff.Parse(msg.Patch)
+		if m.diffFiles == nil {
+			m.diffFiles = []diff.File{}
+		}
+		m.openDiffViewer()
+		return m, nil
+
 	case tea.KeyPressMsg:
 		if m.loading {
 			return m, nil
 		}
 
-		switch msg.String() {
-		case "up", "k":
+		switch key := msg.String(); {
+		case m.keys.Matches(key, keymap.DetailUp):
 			if m.cursor > 0 {
 				m.cursor--
 			}
-		case "down", "j":
+		case m.keys.Matches(key, keymap.DetailDown):
 			maxCursor := len(m.detail.Files) - 1
 			if maxCursor < 0 {
 				maxCursor = 0
@@ -153,9 +285,24 @@ func (m MRDetailModal) Update(msg tea.Msg) (MRDetailModal, tea.Cmd) {
 			if m.cursor < maxCursor {
 				m.cursor++
 			}
-		case "enter":
+		case m.keys.Matches(key, keymap.DetailCheckout):
 			m.wantsCheckout = true
-		case "d", "D":
+		case m.keys.Matches(key, keymap.DetailWorktree):
+			m.wantsWorktree = true
+		case m.keys.Matches(key, keymap.DetailDiff):
+			if m.diffFiles != nil {
+				m.openDiffViewer()
+			} else if !m.wantsDiff {
+				m.wantsDiff = true
+				m.notice = DimStyle.Render("Loading diff...")
+			}
+		case m.keys.Matches(key, keymap.DetailReviewers):
+			m.wantsEdit = EditReviewers
+		case m.keys.Matches(key, keymap.DetailAssignees):
+			m.wantsEdit = EditAssignees
+		case m.keys.Matches(key, keymap.DetailLabels):
+			m.wantsEdit = EditLabels
+		case m.keys.Matches(key, keymap.DetailDescription):
 			if m.detail.Body != "" {
 				viewer := NewDescriptionViewer(
 					fmt.Sprintf("#%d Description", m.mr.Number),
@@ -165,7 +312,7 @@ func (m MRDetailModal) Update(msg tea.Msg) (MRDetailModal, tea.Cmd) {
 				)
 				m.descViewer = &viewer
 			}
-		case "c", "C":
+		case m.keys.Matches(key, keymap.DetailCommits):
 			// If commits already loaded, show viewer directly
 			if len(m.commits) > 0 {
 				viewer := NewCommitsViewer(
@@ -197,6 +344,11 @@ func (m MRDetailModal) View() string {
 		return m.commitsViewer.View()
 	}
 
+	// If diff viewer is active, show it
+	if m.diffViewer != nil {
+		return m.diffViewer.View()
+	}
+
 	// Calculate modal width - use available width with some margin
 	modalWidth := m.width - 10
 	if modalWidth < 50 {
@@ -213,7 +365,13 @@ func (m MRDetailModal) View() string {
 	// Header section: title and branch
 	titleLine := fmt.Sprintf("#%d %s", m.mr.Number, truncateString(m.mr.Title, contentWidth-8))
 	branchLine := fmt.Sprintf("Branch: %s", m.mr.Branch)
+	if m.mr.CrossRepo {
+		branchLine = fmt.Sprintf("Branch: %s:%s", m.mr.HeadOwner, m.mr.Branch) + DimStyle.Render(" (fork)")
+	}
 	headerSection := titleLine + "\n" + branchLine
+	if !m.loading && m.err == nil {
+		headerSection += "\n" + m.renderPeople(contentWidth)
+	}
 	sections = append(sections, headerSection)
 
 	// Loading state
@@ -230,7 +388,7 @@ func (m MRDetailModal) View() string {
 
 	// Error state
 	if m.err != nil {
-		errorSection := ErrorStyle.Render("Error: " + m.err.Error())
+		errorSection := ErrorStyle.Render("Error: " + DescribeError(m.err))
 		sections = append(sections, errorSection)
 
 		footerSection := "[esc] close"
@@ -264,8 +422,12 @@ func (m MRDetailModal) View() string {
 	}
 	sections = append(sections, summarySection)
 
+	if m.notice != "" {
+		sections = append(sections, m.notice)
+	}
+
 	// Footer section with keybinds
-	footerSection := DimStyle.Render("[j/k] scroll | [d] desc | [c] commits | [enter] checkout | [esc] close")
+	footerSection := DimStyle.Render(strings.Join(footerHelp(m.keys, keymap.Detail, "[%s] %s"), " | "))
 	sections = append(sections, footerSection)
 
 	// Join sections with dividers
@@ -352,11 +514,71 @@ func (m MRDetailModal) renderFileList(contentWidth int) string {
 	return result.String()
 }
 
+// openDiffViewer opens the diff viewer on the file under the cursor
+func (m *MRDetailModal) openDiffViewer() {
+	fileIdx := 0
+	if m.cursor < len(m.detail.Files) {
+		if i := diff.FindFile(m.diffFiles, m.detail.Files[m.cursor].Path); i >= 0 {
+			fileIdx = i
+		}
+	}
+	viewer := NewDiffViewer(m.diffFiles, fileIdx, m.diffOptions, m.width, m.height).WithKeys(m.keys)
+	viewer.SetComments(m.comments)
+	viewer.SetPending(m.pending)
+	m.diffViewer = &viewer
+}
+
+// setComments stores the existing line comments and shows them in the diff
+func (m *MRDetailModal) setComments(msg ReviewCommentsLoadedMsg) {
+	if msg.Err != nil {
+		m.notice = ErrorStyle.Render("Loading comments failed: " + DescribeError(msg.Err))
+		return
+	}
+	m.comments = msg.Comments
+	if m.diffViewer != nil {
+		m.diffViewer.SetComments(m.comments)
+	}
+}
+
+// setSubmitResult records the outcome of submitting the pending review
+func (m *MRDetailModal) setSubmitResult(msg ReviewSubmittedMsg) {
+	if msg.Err == nil {
+		m.pending = nil
+	}
+	if m.diffViewer != nil {
+		m.diffViewer.SetSubmitResult(msg.Err)
+		return
+	}
+	if msg.Err != nil {
+		m.notice = ErrorStyle.Render("Submitting review failed: " + DescribeError(msg.Err))
+	} else {
+		m.notice = SuccessStyle.Render(fmt.Sprintf("✓ Review submitted with %d comment(s)", msg.Count))
+	}
+}
+
+// renderPeople renders the reviewers, assignees and labels lines
+func (m MRDetailModal) renderPeople(contentWidth int) string {
+	join := func(values []string) string {
+		if len(values) == 0 {
+			return DimStyle.Render("none")
+		}
+		return truncateString(strings.Join(values, ", "), contentWidth-12)
+	}
+	return "Reviewers: " + join(m.detail.Reviewers) + "\n" +
+		"Assignees: " + join(m.detail.Assignees) + "\n" +
+		"Labels:    " + join(m.detail.Labels)
+}
+
 // IsLoading returns true if the modal is still loading data
 func (m MRDetailModal) IsLoading() bool {
 	return m.loading
 }
 
+// WantsWorktree returns true if user pressed W to check out into a worktree
+func (m MRDetailModal) WantsWorktree() bool {
+	return m.wantsWorktree
+}
+
 // WantsCheckout returns true if user pressed enter to checkout
 func (m MRDetailModal) WantsCheckout() bool {
 	return m.wantsCheckout
@@ -367,14 +589,71 @@ func (m MRDetailModal) WantsCommits() bool {
 	return m.wantsCommits
 }
 
+// TakeDiffRequest returns true once if the user asked for the diff before it was loaded
+func (m *MRDetailModal) TakeDiffRequest() bool {
+	if !m.wantsDiff {
+		return false
+	}
+	m.wantsDiff = false
+	return true
+}
+
+// TakeContentRequest returns the path of a file whose content the diff viewer needs, once
+func (m *MRDetailModal) TakeContentRequest() string {
+	path := m.wantsContent
+	m.wantsContent = ""
+	return path
+}
+
+// TakeEditRequest returns the pending edit request, if any, and clears it
+func (m *MRDetailModal) TakeEditRequest() EditKind {
+	kind := m.wantsEdit
+	m.wantsEdit = EditNone
+	return kind
+}
+
+// SetNotice sets a one-line message shown above the footer
+func (m *MRDetailModal) SetNotice(notice string) {
+	m.notice = notice
+}
+
+// Detail returns the loaded MR detail
+func (m MRDetailModal) Detail() platform.MRDetail {
+	return m.detail
+}
+
+// TakeSubmitRequest returns the comments to submit as a review, once
+func (m *MRDetailModal) TakeSubmitRequest() []platform.ReviewComment {
+	comments := m.wantsSubmit
+	m.wantsSubmit = nil
+	return comments
+}
+
+// ConfirmClose reports whether the modal can close. With pending review
+// comments the first request only warns that they will be discarded.
+func (m *MRDetailModal) ConfirmClose() bool {
+	if len(m.pending) == 0 || m.closeWarned {
+		return true
+	}
+	m.closeWarned = true
+	m.notice = ErrorStyle.Render(fmt.Sprintf("%d pending review comment(s) will be discarded, press %s again to close",
+		len(m.pending), keymap.KeyName(m.keys.Keys(keymap.DetailClose)[0])))
+	return false
+}
+
+// DiffOptions returns the diff viewer settings, including toggles made while viewing
+func (m MRDetailModal) DiffOptions() DiffOptions {
+	return m.diffOptions
+}
+
 // GetMR returns the MR associated with this modal
 func (m MRDetailModal) GetMR() platform.MR {
 	return m.mr
 }
 
-// HasSubViewer returns true if a sub-viewer (description or commits) is currently active
+// HasSubViewer returns true if a sub-viewer (description, commits or diff) is currently active
 func (m MRDetailModal) HasSubViewer() bool {
-	return m.descViewer != nil || m.commitsViewer != nil
+	return m.descViewer != nil || m.commitsViewer != nil || m.diffViewer != nil
 }
 
 // truncateString truncates a string to maxLen, adding ellipsis if needed
@@ -431,7 +710,7 @@ func truncateBody(body string, maxLines, maxWidth int) string {
 		}
 	}
 
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("250")).Render(strings.Join(result, "\n"))
+	return TextStyle.Render(strings.Join(result, "\n"))
 }
 
 // wrapLine wraps a single line to fit within maxWidth
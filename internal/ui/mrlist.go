@@ -3,8 +3,10 @@ package ui
 import (
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
 	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
 
 	"charm.land/bubbles/v2/list"
@@ -13,9 +15,61 @@ import (
 	"charm.land/lipgloss/v2"
 )
 
+// MRChange is what changed on an MR since the user last viewed it
+type MRChange int
+
+const (
+	MRNew           MRChange = 1 << iota // not listed before
+	MRStatusChanged                      // opened, drafted, merged or closed
+	MRPushed                             // new commits on its head
+	MRReviewed                           // reviewed or commented on
+	MRPipeline                           // its pipeline changed status
+)
+
+// MRMark is what's highlighted on an MR until the user views it
+type MRMark struct {
+	Change   MRChange
+	Review   string // state of the last review, with MRReviewed
+	Pipeline string // status of the last pipeline, with MRPipeline
+}
+
+// merge adds the changes of next, whose review and pipeline are the latest
+func (m MRMark) merge(next MRMark) MRMark {
+	m.Change |= next.Change
+	if next.Review != "" {
+		m.Review = next.Review
+	}
+	if next.Pipeline != "" {
+		m.Pipeline = next.Pipeline
+	}
+	return m
+}
+
+// Tag labels the changes, empty for none
+func (m MRMark) Tag(status string) string {
+	var tags []string
+	if m.Change&MRNew != 0 {
+		tags = append(tags, "new")
+	}
+	if m.Change&MRStatusChanged != 0 {
+		tags = append(tags, status)
+	}
+	if m.Change&MRPushed != 0 {
+		tags = append(tags, "pushed")
+	}
+	if m.Change&MRReviewed != 0 {
+		tags = append(tags, m.Review)
+	}
+	if m.Change&MRPipeline != 0 {
+		tags = append(tags, "pipeline "+m.Pipeline)
+	}
+	return strings.Join(tags, ", ")
+}
+
 // MRItem wraps an MR for the list component
 type MRItem struct {
-	MR platform.MR
+	MR   platform.MR
+	Mark MRMark // unviewed changes, highlighted
 }
 
 func (i MRItem) Title() string {
@@ -67,6 +121,16 @@ func (d CompactDelegate) Render(w io.Writer, m list.Model, index int, item list.
 		availableWidth = 20
 	}
 
+	// Unviewed changes go after the title
+	tag := mrItem.Mark.Tag(mr.Status)
+	if tag != "" {
+		tag = " " + WarningStyle.Render("["+tag+"]")
+		availableWidth -= lipgloss.Width(tag)
+		if availableWidth < 10 {
+			availableWidth = 10
+		}
+	}
+
 	number := fmt.Sprintf("#%d", mr.Number)
 	title := mr.Title
 	if len(title) > availableWidth {
@@ -86,12 +150,12 @@ func (d CompactDelegate) Render(w io.Writer, m list.Model, index int, item list.
 
 	var output string
 	if isSelected {
-		titleLine := fmt.Sprintf("%s %s %s", status, SelectedItemStyle.Render(number), SelectedItemStyle.Render(title))
+		titleLine := fmt.Sprintf("%s %s %s", status, SelectedItemStyle.Render(number), SelectedItemStyle.Render(title)) + tag
 		branchLine := branchIndent + BranchStyle.Render(branch)
 		content := titleLine + "\n" + branchLine
 		output = SelectedRowStyle.Render(content)
 	} else {
-		titleLine := fmt.Sprintf("%s %s %s", status, NormalItemStyle.Render(number), NormalItemStyle.Render(title))
+		titleLine := fmt.Sprintf("%s %s %s", status, NormalItemStyle.Render(number), NormalItemStyle.Render(title)) + tag
 		branchLine := branchIndent + BranchStyle.Render(branch)
 		content := titleLine + "\n" + branchLine
 		output = NormalRowStyle.Render(content)
@@ -103,11 +167,13 @@ func (d CompactDelegate) Render(w io.Writer, m list.Model, index int, item list.
 // MRList is a bubbletea component for displaying MRs
 type MRList struct {
 	list        list.Model
-	allItems    []platform.MR // All MRs (unfiltered)
+	allItems    []platform.MR  // All MRs (unfiltered)
+	marks       map[int]MRMark // MR number -> changes not viewed yet
 	width       int
 	height      int
 	searching   bool
 	searchInput textinput.Model
+	keys        keymap.Keymap
 }
 
 // NewMRList creates a new MR list component
@@ -125,6 +191,8 @@ func NewMRList(mrs []platform.MR, width, height int) MRList {
 	l.SetShowPagination(false)
 	l.Styles.TitleBar = lipgloss.NewStyle()
 	l.Styles.Title = lipgloss.NewStyle()
+	// The dashboard quits, with its own keys
+	l.KeyMap.Quit.SetEnabled(false)
 
 	ti := textinput.New()
 	ti.Placeholder = "search..."
@@ -132,7 +200,7 @@ func NewMRList(mrs []platform.MR, width, height int) MRList {
 	ti.SetWidth(30)
 	tiStyles := ti.Styles()
 	tiStyles.Focused.Prompt = lipgloss.NewStyle().Foreground(accentColor)
-	tiStyles.Focused.Text = lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+	tiStyles.Focused.Text = lipgloss.NewStyle().Foreground(brightColor)
 	ti.SetStyles(tiStyles)
 
 	return MRList{
@@ -144,17 +212,134 @@ func NewMRList(mrs []platform.MR, width, height int) MRList {
 	}
 }
 
-// SetItems updates the list items
+// WithKeys moves the cursor and starts a search with keys' dashboard bindings
+func (m MRList) WithKeys(keys keymap.Keymap) MRList {
+	m.keys = keys
+	m.list.KeyMap.CursorUp.SetKeys(keys.Keys(keymap.Up)...)
+	m.list.KeyMap.CursorDown.SetKeys(keys.Keys(keymap.Down)...)
+	return m
+}
+
+// SetItems replaces the list items, keeping the cursor on the selected MR
 func (m *MRList) SetItems(mrs []platform.MR) {
 	m.allItems = mrs
+	m.marks = nil
+	// Clear search when new items are set
+	m.searching = false
+	m.searchInput.SetValue("")
+	m.showItems(mrs)
+}
+
+// UpdateItems replaces the MRs with a reload of the same list, keeping the
+// search and the cursor. MRs that are new, changed status or were pushed to
+// are highlighted until MarkViewed. It returns what changed since the last
+// load, by MR number.
+func (m *MRList) UpdateItems(mrs []platform.MR) map[int]MRChange {
+	before := make(map[int]platform.MR, len(m.allItems))
+	for _, mr := range m.allItems {
+		before[mr.Number] = mr
+	}
+	marks := make(map[int]MRMark)
+	changed := make(map[int]MRChange)
+	for _, mr := range mrs {
+		change := diffMR(before, mr)
+		if change != 0 {
+			changed[mr.Number] = change
+		}
+		// Changes stay until viewed, MRs no longer listed drop theirs
+		if mark := m.marks[mr.Number].merge(MRMark{Change: change}); mark.Change != 0 {
+			marks[mr.Number] = mark
+		}
+	}
+	m.allItems = mrs
+	m.marks = marks
+	m.filterItems()
+	return changed
+}
+
+// UpdateMR applies what a webhook says about a listed MR, in place. Fields
+// mr leaves empty keep their value. It reports whether the MR is listed.
+func (m *MRList) UpdateMR(mr platform.MR) bool {
+	i := slices.IndexFunc(m.allItems, func(listed platform.MR) bool { return listed.Number == mr.Number })
+	if i < 0 {
+		return false
+	}
+	prev := m.allItems[i]
+	next := prev
+	for _, f := range []struct{ dst, src *string }{
+		{&next.Title, &mr.Title}, {&next.Branch, &mr.Branch}, {&next.Status, &mr.Status},
+		{&next.URL, &mr.URL}, {&next.HeadSHA, &mr.HeadSHA},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	m.allItems[i] = next
+	m.Mark(next.Number, MRMark{Change: diffMR(map[int]platform.MR{prev.Number: prev}, next)})
+	m.filterItems()
+	return true
+}
+
+// Mark highlights changes on a listed MR until it's viewed, and reports
+// whether the MR is listed
+func (m *MRList) Mark(number int, mark MRMark) bool {
+	if !slices.ContainsFunc(m.allItems, func(mr platform.MR) bool { return mr.Number == number }) {
+		return false
+	}
+	if mark.Change == 0 {
+		return true
+	}
+	if m.marks == nil {
+		m.marks = make(map[int]MRMark)
+	}
+	m.marks[number] = m.marks[number].merge(mark)
+	m.filterItems()
+	return true
+}
+
+// diffMR returns how mr changed from the MR with its number in before
+func diffMR(before map[int]platform.MR, mr platform.MR) MRChange {
+	prev, ok := before[mr.Number]
+	if !ok {
+		return MRNew
+	}
+	var change MRChange
+	if prev.Status != mr.Status {
+		change |= MRStatusChanged
+	}
+	// Lists cached before head SHAs were recorded have none
+	if prev.HeadSHA != "" && prev.HeadSHA != mr.HeadSHA {
+		change |= MRPushed
+	}
+	return change
+}
+
+// MarkViewed stops highlighting an MR's changes
+func (m *MRList) MarkViewed(number int) {
+	if _, ok := m.marks[number]; ok {
+		delete(m.marks, number)
+		m.filterItems()
+	}
+}
+
+// showItems shows mrs, keeping the cursor on the selected MR if it's among them
+func (m *MRList) showItems(mrs []platform.MR) {
+	selected := m.SelectedMR()
+	index := -1
 	items := make([]list.Item, len(mrs))
 	for i, mr := range mrs {
-		items[i] = MRItem{MR: mr}
+		items[i] = MRItem{MR: mr, Mark: m.marks[mr.Number]}
+		if selected != nil && mr.Number == selected.Number {
+			index = i
+		}
 	}
 	m.list.SetItems(items)
-	// Clear search when new items are set
-	m.searching = false
-	m.searchInput.SetValue("")
+	switch {
+	case index >= 0:
+		m.list.Select(index)
+	case m.list.Index() >= len(items):
+		m.list.Select(max(len(items)-1, 0))
+	}
 }
 
 // SelectedMR returns the currently selected MR, or nil if none
@@ -166,6 +351,17 @@ func (m MRList) SelectedMR() *platform.MR {
 	return &item.MR
 }
 
+// VisibleMRs returns the MRs currently shown, in list order
+func (m MRList) VisibleMRs() []platform.MR {
+	var mrs []platform.MR
+	for _, item := range m.list.Items() {
+		if mrItem, ok := item.(MRItem); ok {
+			mrs = append(mrs, mrItem.MR)
+		}
+	}
+	return mrs
+}
+
 // Update handles messages for the list
 func (m MRList) Update(msg tea.Msg) (MRList, tea.Cmd) {
 	// Handle search mode
@@ -192,8 +388,7 @@ func (m MRList) Update(msg tea.Msg) (MRList, tea.Cmd) {
 	// Not in search mode
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
-		switch msg.String() {
-		case "f", "/":
+		if m.keys.Matches(msg.String(), keymap.Find) {
 			m.searching = true
 			m.searchInput.Focus()
 			return m, nil
@@ -210,25 +405,21 @@ func (m *MRList) filterItems() {
 	query := strings.ToLower(m.searchInput.Value())
 	if query == "" {
 		// Show all items
-		items := make([]list.Item, len(m.allItems))
-		for i, mr := range m.allItems {
-			items[i] = MRItem{MR: mr}
-		}
-		m.list.SetItems(items)
+		m.showItems(m.allItems)
 		return
 	}
 
 	// Filter items
-	var filtered []list.Item
+	var filtered []platform.MR
 	for _, mr := range m.allItems {
 		title := strings.ToLower(mr.Title)
 		branch := strings.ToLower(mr.Branch)
 		number := fmt.Sprintf("#%d", mr.Number)
 		if strings.Contains(title, query) || strings.Contains(branch, query) || strings.Contains(number, query) {
-			filtered = append(filtered, MRItem{MR: mr})
+			filtered = append(filtered, mr)
 		}
 	}
-	m.list.SetItems(filtered)
+	m.showItems(filtered)
 }
 
 // View renders the list
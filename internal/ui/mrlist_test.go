@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestMRList_UpdateItems(t *testing.T) {
+	m := NewMRList(nil, 80, 20)
+	m.SetItems([]platform.MR{
+		{Number: 3, Title: "Fix login", Status: "open", HeadSHA: "aaa"},
+		{Number: 2, Title: "Add prefs", Status: "draft", HeadSHA: "bbb"},
+		{Number: 1, Title: "Fix typo", Status: "open", HeadSHA: "ccc"},
+	})
+	m, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+
+	changed := m.UpdateItems([]platform.MR{
+		{Number: 4, Title: "New feature", Status: "open", HeadSHA: "ddd"},
+		{Number: 3, Title: "Fix login", Status: "open", HeadSHA: "aa2"},
+		{Number: 2, Title: "Add prefs", Status: "open", HeadSHA: "bbb"},
+		{Number: 1, Title: "Fix typo", Status: "open", HeadSHA: "ccc"},
+	})
+	want := map[int]MRChange{4: MRNew, 3: MRPushed, 2: MRStatusChanged}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("UpdateItems reported %v, want %v", changed, want)
+	}
+	for number, change := range want {
+		if got := m.marks[number].Change; got != change {
+			t.Errorf("#%d change = %v, want %v", number, got, change)
+		}
+	}
+	if mr := m.SelectedMR(); mr == nil || mr.Number != 2 {
+		t.Errorf("selected %v after the reload, want #2", mr)
+	}
+
+	// Changes stay highlighted across reloads until viewed
+	m.UpdateItems(m.allItems)
+	m.MarkViewed(3)
+	if m.marks[3].Change != 0 || m.marks[4].Change != MRNew {
+		t.Errorf("marks after viewing #3: %v", m.marks)
+	}
+	if tag := (MRMark{Change: MRNew | MRStatusChanged}).Tag("merged"); tag != "new, merged" {
+		t.Errorf("Tag = %q", tag)
+	}
+}
+
+func TestMRList_UpdateItemsKeepsSearch(t *testing.T) {
+	m := NewMRList(nil, 80, 20)
+	m.SetItems([]platform.MR{{Number: 2, Title: "Fix login"}, {Number: 1, Title: "Add prefs"}})
+	m.searching = true
+	m.searchInput.SetValue("fix")
+	m.filterItems()
+
+	m.UpdateItems([]platform.MR{{Number: 3, Title: "Fix typo"}, {Number: 2, Title: "Fix login"}, {Number: 1, Title: "Add prefs"}})
+	if !m.IsSearching() || len(m.VisibleMRs()) != 2 {
+		t.Errorf("search lost on reload, showing %v", m.VisibleMRs())
+	}
+	if mr := m.SelectedMR(); mr == nil || mr.Number != 2 {
+		t.Errorf("selected %v after the reload, want #2", mr)
+	}
+
+	// A new list clears the search and the highlights but keeps the cursor
+	m.SetItems([]platform.MR{{Number: 1, Title: "Add prefs"}, {Number: 2, Title: "Fix login"}})
+	if m.IsSearching() || len(m.marks) != 0 {
+		t.Error("SetItems kept the search or the highlights")
+	}
+	if mr := m.SelectedMR(); mr == nil || mr.Number != 2 {
+		t.Errorf("selected %v after SetItems, want #2", mr)
+	}
+}
+
+func TestMRList_UpdateMR(t *testing.T) {
+	m := NewMRList(nil, 80, 20)
+	m.SetItems([]platform.MR{
+		{Number: 2, Title: "Fix login", Branch: "fix", Status: "open", URL: "https://x/2", HeadSHA: "aaa"},
+		{Number: 1, Title: "Add prefs", Status: "open"},
+	})
+
+	// A webhook's MR carries what the payload had, the rest is kept
+	if !m.UpdateMR(platform.MR{Number: 2, Title: "Fix login timeout", Status: "merged", HeadSHA: "bbb"}) {
+		t.Fatal("UpdateMR didn't find #2")
+	}
+	want := platform.MR{Number: 2, Title: "Fix login timeout", Branch: "fix", Status: "merged", URL: "https://x/2", HeadSHA: "bbb"}
+	if got := m.VisibleMRs()[0]; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := m.marks[2].Change; got != MRStatusChanged|MRPushed {
+		t.Errorf("#2 change = %v", got)
+	}
+	if m.UpdateMR(platform.MR{Number: 3, Status: "open"}) {
+		t.Error("UpdateMR updated an MR that isn't listed")
+	}
+
+	m.Mark(1, MRMark{Change: MRPipeline, Pipeline: "running"})
+	m.Mark(1, MRMark{Change: MRPipeline | MRReviewed, Pipeline: "failed", Review: "approved"})
+	if tag := m.marks[1].Tag("open"); tag != "approved, pipeline failed" {
+		t.Errorf("#1 tag = %q", tag)
+	}
+}
+
+func TestMRList_UpdateMRDraft(t *testing.T) {
+	// Lists and webhooks both call an open draft "draft", so a webhook
+	// about a listed draft that wasn't pushed to changes nothing
+	m := NewMRList(nil, 80, 20)
+	m.SetItems([]platform.MR{{Number: 17, Title: "Draft: Move config to TOML", Status: "draft", HeadSHA: "c0ffee"}})
+	m.UpdateMR(platform.MR{Number: 17, Title: "Draft: Move config to TOML", Status: "draft", HeadSHA: "c0ffee"})
+	if got := m.marks[17].Change; got != 0 {
+		t.Errorf("#17 change = %v", got)
+	}
+}
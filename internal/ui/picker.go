@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// PickerItem is a single entry in a Picker
+type PickerItem struct {
+	Key    string // value returned on selection (username, label name, ...)
+	Detail string // optional secondary text shown next to the key
+}
+
+func (i PickerItem) Title() string {
+	return i.Key
+}
+
+func (i PickerItem) Description() string {
+	return i.Detail
+}
+
+func (i PickerItem) FilterValue() string {
+	return i.Key
+}
+
+// PickerDelegate renders picker rows, with a checkbox in multi-select mode
+type PickerDelegate struct {
+	multi   bool
+	checked map[string]bool
+}
+
+func (d PickerDelegate) Height() int                             { return 1 }
+func (d PickerDelegate) Spacing() int                            { return 0 }
+func (d PickerDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d PickerDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	pickerItem, ok := item.(PickerItem)
+	if !ok {
+		return
+	}
+
+	isSelected := index == m.Index()
+
+	var content string
+	if pickerItem.Detail != "" && pickerItem.Detail != pickerItem.Key {
+		content = fmt.Sprintf("%s (%s)", pickerItem.Key, pickerItem.Detail)
+	} else {
+		content = pickerItem.Key
+	}
+	if d.multi {
+		box := "[ ] "
+		if d.checked[pickerItem.Key] {
+			box = "[x] "
+		}
+		content = box + content
+	}
+	maxWidth := m.Width() - 3 // left border=1, padding=1, margin=1
+	if maxWidth < 10 {
+		maxWidth = 10
+	}
+	if len(content) > maxWidth {
+		content = content[:maxWidth-3] + "..."
+	}
+
+	var line string
+	if isSelected {
+		line = SelectedRowStyle.Render(SelectedItemStyle.Render(content))
+	} else {
+		line = NormalRowStyle.Render(NormalItemStyle.Render(content))
+	}
+
+	_, _ = fmt.Fprint(w, line)
+}
+
+// Picker is a searchable modal list supporting single or multiple selection
+type Picker struct {
+	title       string
+	list        list.Model
+	listWidth   int
+	allItems    []PickerItem
+	multi       bool
+	checked     map[string]bool // current selection (multi-select only)
+	initial     map[string]bool // selection when the picker was opened
+	searching   bool
+	searchInput textinput.Model
+	keys        keymap.Keymap
+}
+
+// NewPicker creates a single-select picker
+func NewPicker(title string, items []PickerItem, width, height int) Picker {
+	return newPicker(title, items, false, nil, width, height)
+}
+
+// NewMultiPicker creates a multi-select picker with the given keys pre-checked
+func NewMultiPicker(title string, items []PickerItem, selected []string, width, height int) Picker {
+	return newPicker(title, items, true, selected, width, height)
+}
+
+func newPicker(title string, items []PickerItem, multi bool, selected []string, width, height int) Picker {
+	checked := make(map[string]bool, len(selected))
+	initial := make(map[string]bool, len(selected))
+	for _, key := range selected {
+		checked[key] = true
+		initial[key] = true
+	}
+
+	// Make sure pre-checked values show up even if they are not candidates
+	allItems := make([]PickerItem, 0, len(items)+len(selected))
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.Key] = true
+	}
+	for _, key := range selected {
+		if !known[key] {
+			allItems = append(allItems, PickerItem{Key: key})
+			known[key] = true
+		}
+	}
+	allItems = append(allItems, items...)
+
+	listItems := make([]list.Item, len(allItems))
+	for i, item := range allItems {
+		listItems[i] = item
+	}
+
+	listWidth := width - 4
+	listHeight := height - 4
+	if listWidth < 40 {
+		listWidth = 40
+	}
+	if listHeight < 15 {
+		listHeight = 15
+	}
+
+	l := list.New(listItems, PickerDelegate{multi: multi, checked: checked}, listWidth, listHeight)
+	l.Title = ""
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false) // We handle filtering ourselves
+	l.SetShowHelp(false)
+	l.SetShowPagination(false)
+	l.Styles.TitleBar = lipgloss.NewStyle()
+	l.Styles.Title = lipgloss.NewStyle()
+	// The dashboard closes pickers, with its own keys
+	l.KeyMap.Quit.SetEnabled(false)
+
+	ti := textinput.New()
+	ti.Placeholder = "search..."
+	ti.CharLimit = 50
+	ti.SetWidth(30)
+	tiStyles := ti.Styles()
+	tiStyles.Focused.Prompt = lipgloss.NewStyle().Foreground(accentColor)
+	tiStyles.Focused.Text = lipgloss.NewStyle().Foreground(brightColor)
+	ti.SetStyles(tiStyles)
+
+	return Picker{
+		title:       title,
+		list:        l,
+		listWidth:   listWidth,
+		allItems:    allItems,
+		multi:       multi,
+		checked:     checked,
+		initial:     initial,
+		searchInput: ti,
+	}
+}
+
+// WithKeys moves the cursor, searches and toggles with keys' picker bindings
+func (p Picker) WithKeys(keys keymap.Keymap) Picker {
+	p.keys = keys
+	p.list.KeyMap.CursorUp.SetKeys(keys.Keys(keymap.PickerUp)...)
+	p.list.KeyMap.CursorDown.SetKeys(keys.Keys(keymap.PickerDown)...)
+	return p
+}
+
+// Update handles messages
+func (p Picker) Update(msg tea.Msg) (Picker, tea.Cmd) {
+	// Handle search mode
+	if p.searching {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch msg.String() {
+			case "esc":
+				p.searching = false
+				p.searchInput.Blur()
+				p.searchInput.SetValue("")
+				p.filterItems()
+				return p, nil
+			case "enter":
+				p.searching = false
+				p.searchInput.Blur()
+				return p, nil
+			}
+		}
+
+		var cmd tea.Cmd
+		p.searchInput, cmd = p.searchInput.Update(msg)
+		p.filterItems()
+		return p, cmd
+	}
+
+	// Not in search mode
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch key := msg.String(); {
+		case p.keys.Matches(key, keymap.PickerFind):
+			p.searching = true
+			p.searchInput.Focus()
+			return p, nil
+		case p.keys.Matches(key, keymap.PickerToggle):
+			if p.multi {
+				if item, ok := p.list.SelectedItem().(PickerItem); ok {
+					p.checked[item.Key] = !p.checked[item.Key]
+				}
+				return p, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	return p, cmd
+}
+
+// filterItems filters the list based on search input
+func (p *Picker) filterItems() {
+	query := strings.ToLower(p.searchInput.Value())
+	if query == "" {
+		// Show all items
+		items := make([]list.Item, len(p.allItems))
+		for i, item := range p.allItems {
+			items[i] = item
+		}
+		p.list.SetItems(items)
+		return
+	}
+
+	// Filter items
+	var filtered []list.Item
+	for _, item := range p.allItems {
+		key := strings.ToLower(item.Key)
+		detail := strings.ToLower(item.Detail)
+		if strings.Contains(key, query) || strings.Contains(detail, query) {
+			filtered = append(filtered, item)
+		}
+	}
+	p.list.SetItems(filtered)
+}
+
+// SearchBar returns the search bar view if searching, empty string otherwise
+func (p Picker) SearchBar() string {
+	if !p.searching {
+		return ""
+	}
+	searchStyle := lipgloss.NewStyle().
+		Foreground(accentColor).
+		Bold(true)
+	return searchStyle.Render("Find: ") + p.searchInput.View()
+}
+
+// IsSearching returns true if search mode is active
+func (p Picker) IsSearching() bool {
+	return p.searching
+}
+
+// View renders the picker
+func (p Picker) View() string {
+	content := p.list.View()
+	if p.title != "" {
+		titleLine := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(accentColor).
+			Render(p.title)
+		content = titleLine + "\n" + content
+	}
+	if p.searching {
+		content = content + "\n" + p.SearchBar()
+	}
+	var skip []keymap.Action
+	if !p.multi {
+		skip = append(skip, keymap.PickerToggle)
+	}
+	help := footerHelp(p.keys, keymap.Picker, "%s %s", skip...)
+	content = content + "\n" + DimStyle.Render(strings.Join(help, " │ "))
+	return ModalStyle.Width(p.listWidth).Render(content)
+}
+
+// SelectedKey returns the key of the highlighted item, or empty string if none
+func (p Picker) SelectedKey() string {
+	item, ok := p.list.SelectedItem().(PickerItem)
+	if !ok {
+		return ""
+	}
+	return item.Key
+}
+
+// Checked returns the checked keys in list order (multi-select only)
+func (p Picker) Checked() []string {
+	var keys []string
+	for _, item := range p.allItems {
+		if p.checked[item.Key] {
+			keys = append(keys, item.Key)
+		}
+	}
+	return keys
+}
+
+// Changes returns the keys checked and unchecked since the picker was opened
+func (p Picker) Changes() (added, removed []string) {
+	for _, item := range p.allItems {
+		switch {
+		case p.checked[item.Key] && !p.initial[item.Key]:
+			added = append(added, item.Key)
+		case !p.checked[item.Key] && p.initial[item.Key]:
+			removed = append(removed, item.Key)
+		}
+	}
+	return added, removed
+}
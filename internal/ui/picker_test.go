@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestPickerChanges(t *testing.T) {
+	items := []PickerItem{{Key: "alice"}, {Key: "bob"}, {Key: "carol"}}
+	p := NewMultiPicker("Reviewers", items, []string{"bob", "dave"}, 80, 20)
+
+	// Pre-checked values that are not candidates are still listed
+	if got := p.Checked(); !reflect.DeepEqual(got, []string{"dave", "bob"}) {
+		t.Fatalf("Checked() = %v, want [dave bob]", got)
+	}
+
+	// Cursor starts on "dave": uncheck it, move down to "alice" and check it
+	space := tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}
+	down := tea.KeyPressMsg{Code: tea.KeyDown}
+	p, _ = p.Update(space)
+	p, _ = p.Update(down)
+	p, _ = p.Update(space)
+
+	added, removed := p.Changes()
+	if !reflect.DeepEqual(added, []string{"alice"}) {
+		t.Errorf("added = %v, want [alice]", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"dave"}) {
+		t.Errorf("removed = %v, want [dave]", removed)
+	}
+}
+
+func TestPickerChanges_Untouched(t *testing.T) {
+	p := NewMultiPicker("Labels", []PickerItem{{Key: "bug"}}, []string{"bug"}, 80, 20)
+	added, removed := p.Changes()
+	if added != nil || removed != nil {
+		t.Errorf("expected no changes, got added=%v removed=%v", added, removed)
+	}
+}
+
+func TestPickerKeys(t *testing.T) {
+	keys, err := keymap.New(map[string][]string{"picker.find": {"s"}, "picker.toggle": {"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewMultiPicker("Labels", []PickerItem{{Key: "bug"}}, nil, 80, 20).WithKeys(keys)
+
+	// f no longer searches, s does
+	p, _ = p.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+	if p.IsSearching() {
+		t.Fatal("f still starts a search")
+	}
+	p, _ = p.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	if got := p.Checked(); !reflect.DeepEqual(got, []string{"bug"}) {
+		t.Errorf("Checked() = %v after x, want [bug]", got)
+	}
+	p, _ = p.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	if !p.IsSearching() {
+		t.Error("s doesn't start a search")
+	}
+}
+
+func TestNewAuthorPicker(t *testing.T) {
+	authors := []platform.Author{{Username: "alice", Name: "Alice"}, {Username: "bob", Name: "Bob"}}
+	keys := func(p Picker) []string {
+		var keys []string
+		for _, item := range p.allItems {
+			keys = append(keys, item.Key)
+		}
+		return keys
+	}
+
+	if got := keys(NewAuthorPicker(authors, "@me", "@me", 80, 20)); !reflect.DeepEqual(got, []string{"@me", "alice", "bob"}) {
+		t.Errorf("with the @me default got %v", got)
+	}
+	// A configured default comes first, listed once
+	p := NewAuthorPicker(authors, "@me", "bob", 80, 20)
+	if got := keys(p); !reflect.DeepEqual(got, []string{"bob", "@me", "alice"}) {
+		t.Errorf("with the bob default got %v", got)
+	}
+	if got := p.SelectedKey(); got != "bob" {
+		t.Errorf("selected %q, want the default", got)
+	}
+}
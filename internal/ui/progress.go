@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// progressEvents carries the progress of background git work to a modal,
+// then its result, in order on the one channel. Steps starting, finishing
+// or failing are always delivered; updates that only move the percentage
+// are dropped when the modal falls behind, rather than blocking git.
+type progressEvents chan tea.Msg
+
+func newProgressEvents() progressEvents {
+	return make(progressEvents, 32)
+}
+
+// run runs work in a goroutine, wrap turning each progress report into a
+// message, and returns the command waiting for the first event
+func (e progressEvents) run(wrap func(git.Progress) tea.Msg, work func(report func(git.Progress)) tea.Msg) tea.Cmd {
+	go func() {
+		var last git.Progress
+		report := func(p git.Progress) {
+			if p.Step == last.Step && p.State == last.State && p.Label == last.Label && p.Phase == last.Phase {
+				select {
+				case e <- wrap(p):
+				default:
+				}
+				return
+			}
+			last = p
+			e <- wrap(p)
+		}
+		e <- work(report)
+	}()
+	return e.next()
+}
+
+// next waits for the next progress update or the result
+func (e progressEvents) next() tea.Cmd {
+	return func() tea.Msg {
+		return <-e
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+type doneMsg struct{}
+
+func TestProgressEvents(t *testing.T) {
+	events := newProgressEvents()
+	wrap := func(p git.Progress) tea.Msg { return p }
+	next := events.run(wrap, func(report func(git.Progress)) tea.Msg {
+		report(git.Progress{Step: "fetch", State: git.StepRunning, Percent: -1})
+		// Far more percentages than the buffer holds, with nobody reading
+		for i := range 500 {
+			report(git.Progress{Step: "fetch", State: git.StepRunning, Phase: "Receiving objects", Percent: i % 100})
+		}
+		report(git.Progress{Step: "fetch", State: git.StepDone})
+		report(git.Progress{Step: "checkout", State: git.StepRunning})
+		report(git.Progress{Step: "checkout", State: git.StepDone})
+		return doneMsg{}
+	})
+
+	var states []string
+	for {
+		msg := next()
+		if _, ok := msg.(doneMsg); ok {
+			break
+		}
+		p := msg.(git.Progress)
+		if p.Phase == "" {
+			states = append(states, p.Step+":"+map[git.StepState]string{git.StepRunning: "running", git.StepDone: "done"}[p.State])
+		}
+	}
+	want := []string{"fetch:running", "fetch:done", "checkout:running", "checkout:done"}
+	if len(states) != len(want) {
+		t.Fatalf("got state changes %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("got state changes %v, want %v", states, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/diff"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ReviewCommentsLoadedMsg is sent when the existing line comments of an MR are loaded
+type ReviewCommentsLoadedMsg struct {
+	Comments []platform.ReviewComment
+	Err      error
+}
+
+// ReviewSubmittedMsg is sent when the pending review comments have been published
+type ReviewSubmittedMsg struct {
+	Count int
+	Err   error
+}
+
+// composerHeight is the number of lines the comment composer takes (label + input)
+const composerHeight = 5
+
+// maxCommentLines bounds how many body lines of a comment are shown inline
+const maxCommentLines = 4
+
+// threadComment is a comment shown inline under the line it is anchored to
+type threadComment struct {
+	platform.ReviewComment
+	pending bool // not yet submitted
+}
+
+// threadKey identifies a line on one side of a file
+type threadKey struct {
+	side string
+	line int
+}
+
+// SetComments sets the existing line comments of the MR
+func (v *DiffViewer) SetComments(comments []platform.ReviewComment) {
+	v.comments = comments
+	v.indexThreads()
+}
+
+// SetPending sets the comments waiting to be submitted
+func (v *DiffViewer) SetPending(pending []platform.ReviewComment) {
+	v.pending = pending
+	v.indexThreads()
+}
+
+// Pending returns the comments waiting to be submitted
+func (v DiffViewer) Pending() []platform.ReviewComment {
+	return v.pending
+}
+
+// TakeSubmitRequest returns the comments to submit as a review, once
+func (v *DiffViewer) TakeSubmitRequest() []platform.ReviewComment {
+	if !v.wantsSubmit {
+		return nil
+	}
+	v.wantsSubmit = false
+	return append([]platform.ReviewComment(nil), v.pending...)
+}
+
+// SetSubmitResult records the outcome of submitting the pending comments
+func (v *DiffViewer) SetSubmitResult(err error) {
+	v.submitting = false
+	if err != nil {
+		v.notice = ErrorStyle.Render("Submitting review failed: " + DescribeError(err))
+		return
+	}
+	v.pending = nil
+	v.indexThreads()
+	v.notice = SuccessStyle.Render("✓ Review submitted")
+}
+
+// CapturesClose reports whether the close key is handled by the viewer
+// (typed into the composer or clearing a selection) rather than closing it
+func (v DiffViewer) CapturesClose() bool {
+	return v.composer != nil || v.anchor >= 0
+}
+
+// indexThreads groups the existing and pending comments on the current file
+// by the line they are anchored to
+func (v *DiffViewer) indexThreads() {
+	v.threads = make(map[threadKey][]threadComment)
+	if len(v.files) == 0 {
+		return
+	}
+	path := v.files[v.fileIdx].Path()
+	add := func(c platform.ReviewComment, pending bool) {
+		if c.Path != path {
+			return
+		}
+		key := threadKey{side: c.Side, line: c.Line()}
+		v.threads[key] = append(v.threads[key], threadComment{ReviewComment: c, pending: pending})
+	}
+	for _, c := range v.comments {
+		add(c, false)
+	}
+	for _, c := range v.pending {
+		add(c, true)
+	}
+}
+
+// keysAt returns the lines display row i shows, on each side they exist
+func (v DiffViewer) keysAt(i int) []threadKey {
+	d := v.display[i]
+	if d.kind == rowHunkHeader {
+		return nil
+	}
+	oldIdx, newIdx := d.old, d.new
+	var keys []threadKey
+	if oldIdx >= 0 {
+		if n := v.rows[oldIdx].line.OldNum; n != 0 {
+			keys = append(keys, threadKey{side: platform.SideOld, line: n})
+		}
+	}
+	if newIdx >= 0 {
+		if n := v.rows[newIdx].line.NewNum; n != 0 {
+			keys = append(keys, threadKey{side: platform.SideNew, line: n})
+		}
+	}
+	return keys
+}
+
+// threadsAt returns the comments anchored to display row i
+func (v DiffViewer) threadsAt(i int) []threadComment {
+	if len(v.threads) == 0 {
+		return nil
+	}
+	var comments []threadComment
+	for _, k := range v.keysAt(i) {
+		comments = append(comments, v.threads[k]...)
+	}
+	return comments
+}
+
+// renderThreads renders comments as indented lines under their anchor line
+func renderThreads(comments []threadComment, width int, wrap bool) []string {
+	const indent = "      "
+	bar := CommentStyle.Render("┃ ")
+	textWidth := width - len(indent) - 2
+	if textWidth < 10 {
+		textWidth = 10
+	}
+
+	var lines []string
+	for _, c := range comments {
+		header := CommentStyle.Bold(true).Render("@" + c.Author)
+		if c.pending {
+			header = CommentStyle.Bold(true).Render("pending")
+		}
+		if start := c.StartLine(); start != 0 {
+			header += DimStyle.Render(fmt.Sprintf(" lines %d-%d", start, c.Line()))
+		}
+		lines = append(lines, indent+bar+header)
+
+		body := strings.Split(strings.TrimRight(c.Body, "\n"), "\n")
+		truncated := len(body) > maxCommentLines
+		if truncated {
+			body = body[:maxCommentLines]
+		}
+		for _, l := range body {
+			l = expandTabs(strings.TrimRight(l, "\r"))
+			if wrap {
+				for _, w := range strings.Split(ansi.Hardwrap(l, textWidth, true), "\n") {
+					lines = append(lines, indent+bar+w)
+				}
+			} else {
+				lines = append(lines, indent+bar+ansi.Truncate(l, textWidth, "…"))
+			}
+		}
+		if truncated {
+			lines = append(lines, indent+bar+DimStyle.Render("…"))
+		}
+	}
+	return lines
+}
+
+// cursorSide returns the side a comment on the cursor row applies to
+func (v DiffViewer) cursorSide() string {
+	if v.cursor >= len(v.display) {
+		return platform.SideNew
+	}
+	d := v.display[v.cursor]
+	switch d.kind {
+	case rowPair:
+		if (v.side == platform.SideOld && d.old >= 0) || d.new < 0 {
+			return platform.SideOld
+		}
+	case rowLine:
+		if v.rows[d.old].line.Kind == diff.Removed {
+			return platform.SideOld
+		}
+	}
+	return platform.SideNew
+}
+
+// lineRefAt returns the line display row i shows on the given side
+func (v DiffViewer) lineRefAt(i int, side string) (platform.LineRef, bool) {
+	d := v.display[i]
+	idx := d.old
+	switch d.kind {
+	case rowHunkHeader:
+		return platform.LineRef{}, false
+	case rowPair:
+		if side == platform.SideNew {
+			idx = d.new
+		}
+		if idx < 0 {
+			return platform.LineRef{}, false
+		}
+	}
+	l := v.rows[idx].line
+	if (side == platform.SideOld && l.OldNum == 0) || (side == platform.SideNew && l.NewNum == 0) {
+		return platform.LineRef{}, false
+	}
+	return platform.LineRef{Old: l.OldNum, New: l.NewNum}, true
+}
+
+// selection returns the first and last display rows of the selected range,
+// or just the cursor row when nothing is selected
+func (v DiffViewer) selection() (first, last int) {
+	if v.anchor < 0 {
+		return v.cursor, v.cursor
+	}
+	return min(v.anchor, v.cursor), max(v.anchor, v.cursor)
+}
+
+// inSelection reports whether display row i is part of the selected range
+func (v DiffViewer) inSelection(i int) bool {
+	if v.anchor < 0 {
+		return false
+	}
+	first, last := v.selection()
+	return i >= first && i <= last
+}
+
+// startComment opens the composer for the selected range or the cursor line
+func (v *DiffViewer) startComment() tea.Cmd {
+	if v.showFull {
+		v.notice = "Switch back to the diff ([" + keyHint(v.keys, keymap.DiffFullFile) + "]) to comment"
+		return nil
+	}
+	if len(v.display) == 0 {
+		return nil
+	}
+
+	first, last := v.selection()
+	if v.display[first].hunk != v.display[last].hunk {
+		v.notice = "A comment range must stay within one hunk"
+		return nil
+	}
+
+	side := v.cursorSide()
+	var refs []platform.LineRef
+	for i := first; i <= last; i++ {
+		if ref, ok := v.lineRefAt(i, side); ok {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		v.notice = "Nothing to comment on here"
+		return nil
+	}
+
+	file := v.files[v.fileIdx]
+	oldPath := file.OldPath
+	if oldPath == "" {
+		oldPath = file.Path()
+	}
+	v.draft = platform.ReviewComment{
+		Path:    file.Path(),
+		OldPath: oldPath,
+		Side:    side,
+		End:     refs[len(refs)-1],
+	}
+	if len(refs) > 1 {
+		v.draft.Start = refs[0]
+	}
+
+	input := textarea.New()
+	input.Placeholder = "Leave a comment"
+	input.ShowLineNumbers = false
+	input.SetWidth(v.contentWidth())
+	input.SetHeight(composerHeight - 1)
+	cmd := input.Focus()
+	v.composer = &input
+	v.notice = ""
+	v.ensureVisible()
+	return cmd
+}
+
+// updateComposer handles messages while a comment is being written
+func (v DiffViewer) updateComposer(msg tea.Msg) (DiffViewer, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyPressMsg); ok {
+		switch key := keyMsg.String(); {
+		case v.keys.Matches(key, keymap.CommentCancel):
+			v.composer = nil
+			v.ensureVisible()
+			return v, nil
+		case v.keys.Matches(key, keymap.CommentSave):
+			body := strings.TrimSpace(v.composer.Value())
+			if body == "" {
+				v.notice = "Comment is empty"
+				return v, nil
+			}
+			v.draft.Body = body
+			v.pending = append(v.pending, v.draft)
+			v.composer = nil
+			v.anchor = -1
+			v.indexThreads()
+			v.notice = fmt.Sprintf("%d pending comment(s), [%s] to submit the review", len(v.pending), keyHint(v.keys, keymap.DiffSubmit))
+			v.ensureVisible()
+			return v, nil
+		}
+	}
+
+	input, cmd := v.composer.Update(msg)
+	v.composer = &input
+	return v, cmd
+}
+
+// discardPending removes the pending comments anchored to the cursor row
+func (v *DiffViewer) discardPending() {
+	if len(v.display) == 0 || len(v.files) == 0 {
+		return
+	}
+	path := v.files[v.fileIdx].Path()
+	keys := v.keysAt(v.cursor)
+	kept := v.pending[:0:0]
+	for _, c := range v.pending {
+		anchored := false
+		for _, k := range keys {
+			if c.Path == path && c.Side == k.side && c.Line() == k.line {
+				anchored = true
+			}
+		}
+		if !anchored {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == len(v.pending) {
+		v.notice = "No pending comment on this line"
+		return
+	}
+	v.pending = kept
+	v.indexThreads()
+	v.notice = fmt.Sprintf("Pending comment discarded (%d left)", len(v.pending))
+	v.ensureVisible()
+}
+
+// requestSubmit asks for the pending comments to be submitted as one review
+func (v *DiffViewer) requestSubmit() {
+	if v.submitting {
+		return
+	}
+	if len(v.pending) == 0 {
+		v.notice = "No pending comments to submit"
+		return
+	}
+	v.wantsSubmit = true
+	v.submitting = true
+	v.notice = fmt.Sprintf("Submitting %d comment(s)...", len(v.pending))
+}
+
+// composerView renders the comment composer with a label naming its anchor
+func (v DiffViewer) composerView() string {
+	side := "new"
+	if v.draft.Side == platform.SideOld {
+		side = "old"
+	}
+	anchor := fmt.Sprintf("%s:%d", v.draft.Path, v.draft.Line())
+	if start := v.draft.StartLine(); start != 0 {
+		anchor = fmt.Sprintf("%s:%d-%d", v.draft.Path, start, v.draft.Line())
+	}
+	label := CommentStyle.Render("Comment on "+anchor) + DimStyle.Render(" ("+side+")")
+	return label + "\n" + v.composer.View()
+}
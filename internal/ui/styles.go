@@ -1,98 +1,210 @@
 package ui
 
-import "charm.land/lipgloss/v2"
+import (
+	"image/color"
+	"os"
 
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/theme"
+
+	"charm.land/lipgloss/v2"
+)
+
+// Colors of the active theme, set by SetTheme
 var (
-	// Colors - night-friendly terminal palette
-	primaryColor   = lipgloss.Color("36")  // Dark cyan
-	secondaryColor = lipgloss.Color("242") // Dim gray
-	successColor   = lipgloss.Color("35")  // Muted green
-	errorColor     = lipgloss.Color("167") // Soft red
-	selectedColor  = lipgloss.Color("35")  // Muted green
-	accentColor    = lipgloss.Color("72")  // Teal
+	palette        theme.Palette
+	primaryColor   color.Color
+	secondaryColor color.Color
+	successColor   color.Color
+	errorColor     color.Color
+	selectedColor  color.Color
+	accentColor    color.Color
+	textColor      color.Color // body text
+	brightColor    color.Color // text being typed
+	dimColor       color.Color
+)
 
+// Styles of the active theme, set by SetTheme
+var (
 	// Header styles
-	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(primaryColor).
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderBottom(true).
-			BorderForeground(secondaryColor).
-			Padding(0, 1)
+	HeaderStyle lipgloss.Style
 
 	// Tab styles
+	ActiveTabStyle   lipgloss.Style
+	InactiveTabStyle lipgloss.Style
+
+	// List item styles
+	SelectedItemStyle lipgloss.Style
+	SelectedRowStyle  lipgloss.Style
+	NormalRowStyle    lipgloss.Style
+	NormalItemStyle   lipgloss.Style
+
+	// Selection indicator
+	SelectedIndicator lipgloss.Style
+
+	// Dimmed style for descriptions
+	DimStyle lipgloss.Style
+
+	// Branch style - grey for branch names on second line
+	BranchStyle lipgloss.Style
+
+	// Status styles
+	StatusOpenStyle   lipgloss.Style
+	StatusDraftStyle  lipgloss.Style
+	StatusMergedStyle lipgloss.Style
+	StatusClosedStyle lipgloss.Style
+
+	// Footer style
+	FooterStyle lipgloss.Style
+
+	// Modal style, and the backdrop around modals
+	ModalStyle    lipgloss.Style
+	BackdropStyle lipgloss.Style
+
+	// Error style
+	ErrorStyle lipgloss.Style
+
+	// Success style
+	SuccessStyle lipgloss.Style
+
+	// Warning style
+	WarningStyle lipgloss.Style
+
+	// Diff hunk header style
+	HunkHeaderStyle lipgloss.Style
+
+	// Inline review comment style
+	CommentStyle lipgloss.Style
+
+	// Body text of descriptions, commits and unchanged diff lines
+	TextStyle lipgloss.Style
+)
+
+func init() {
+	p, _ := theme.Resolve(theme.Dark, nil, nil)
+	SetTheme(p)
+}
+
+// LoadTheme returns the palette of the theme cfg names, none with NO_COLOR.
+// With auto it asks the terminal for its background color.
+func LoadTheme(cfg config.Config) theme.Palette {
+	if os.Getenv("NO_COLOR") != "" {
+		return theme.None
+	}
+	dark := func() bool { return lipgloss.HasDarkBackground(os.Stdin, os.Stdout) }
+	// Load already reported an unknown theme, which falls back to dark
+	p, _ := theme.Resolve(cfg.UI.Theme, cfg.Themes, dark)
+	return p
+}
+
+// themeColor returns a palette color, no color if it's empty
+func themeColor(s string) color.Color {
+	if s == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(s)
+}
+
+// SetTheme rebuilds every style from p. Call it before the program starts.
+func SetTheme(p theme.Palette) {
+	palette = p
+	primaryColor = themeColor(p.Primary)
+	secondaryColor = themeColor(p.Secondary)
+	successColor = themeColor(p.Success)
+	errorColor = themeColor(p.Error)
+	selectedColor = themeColor(p.Selected)
+	accentColor = themeColor(p.Accent)
+	textColor = themeColor(p.Text)
+	brightColor = themeColor(p.Bright)
+	dimColor = themeColor(p.Dim)
+
+	HeaderStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(primaryColor).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		BorderForeground(secondaryColor).
+		Padding(0, 1)
+
 	ActiveTabStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(primaryColor).
-			Padding(0, 2)
+		Bold(true).
+		Foreground(primaryColor).
+		Padding(0, 2)
 
 	InactiveTabStyle = lipgloss.NewStyle().
-				Foreground(secondaryColor).
-				Padding(0, 2)
+		Foreground(secondaryColor).
+		Padding(0, 2)
 
-	// List item styles
 	SelectedItemStyle = lipgloss.NewStyle().
-				Foreground(selectedColor).
-				Bold(true)
+		Foreground(selectedColor).
+		Bold(true)
 
 	SelectedRowStyle = lipgloss.NewStyle().
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderLeft(true).
-				BorderForeground(accentColor).
-				PaddingLeft(1)
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).
+		BorderForeground(accentColor).
+		PaddingLeft(1)
 
 	NormalRowStyle = lipgloss.NewStyle().
-			PaddingLeft(2)
+		PaddingLeft(2)
 
 	NormalItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("250"))
+		Foreground(textColor)
 
-	// Selection indicator
 	SelectedIndicator = lipgloss.NewStyle().
-				Foreground(accentColor).
-				Bold(true)
+		Foreground(accentColor).
+		Bold(true)
 
-	// Dimmed style for descriptions
 	DimStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244"))
+		Foreground(dimColor)
 
-	// Branch style - grey for branch names on second line
 	BranchStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240"))
+		Foreground(themeColor(p.Faint))
 
-	// Status styles
 	StatusOpenStyle = lipgloss.NewStyle().
-			Foreground(successColor)
+		Foreground(successColor)
 
 	StatusDraftStyle = lipgloss.NewStyle().
-				Foreground(secondaryColor)
+		Foreground(secondaryColor)
 
 	StatusMergedStyle = lipgloss.NewStyle().
-				Foreground(accentColor) // Teal
+		Foreground(accentColor)
 
 	StatusClosedStyle = lipgloss.NewStyle().
-				Foreground(errorColor)
+		Foreground(errorColor)
 
-	// Footer style
 	FooterStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderTop(true).
-			BorderForeground(secondaryColor).
-			Padding(0, 1)
+		Foreground(secondaryColor).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderTop(true).
+		BorderForeground(secondaryColor).
+		Padding(0, 1)
 
-	// Modal style
 	ModalStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(accentColor).
-			Padding(0, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 2)
+
+	BackdropStyle = lipgloss.NewStyle().
+		Foreground(themeColor(p.Backdrop))
 
-	// Error style
 	ErrorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true)
+		Foreground(errorColor).
+		Bold(true)
 
-	// Success style
 	SuccessStyle = lipgloss.NewStyle().
-			Foreground(successColor)
-)
+		Foreground(successColor)
+
+	WarningStyle = lipgloss.NewStyle().
+		Foreground(themeColor(p.Warning)).
+		Bold(true)
+
+	HunkHeaderStyle = lipgloss.NewStyle().
+		Foreground(accentColor)
+
+	CommentStyle = lipgloss.NewStyle().
+		Foreground(themeColor(p.Comment))
+
+	TextStyle = lipgloss.NewStyle().
+		Foreground(textColor)
+}
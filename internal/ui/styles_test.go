@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/config"
+	"github.com/Constantine-Kostikas/GitQuick/internal/theme"
+
+	"github.com/charmbracelet/colorprofile"
+)
+
+func TestLoadTheme(t *testing.T) {
+	cfg := config.Default()
+	cfg.UI.Theme = "paper"
+	cfg.Themes = map[string]map[string]string{"paper": {"base": "light", "dim": "#777777"}}
+
+	t.Setenv("NO_COLOR", "")
+	p := LoadTheme(cfg)
+	if p.Dim != "#777777" || p.Text == "" {
+		t.Errorf("theme = %+v, want light with a custom dim", p)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if p := LoadTheme(cfg); p != theme.None {
+		t.Errorf("NO_COLOR gave %+v, want no colors", p)
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	defer SetTheme(palette)
+
+	SetTheme(theme.None)
+	if got := DimStyle.Render("x"); got != "x" {
+		t.Errorf("without colors DimStyle rendered %q", got)
+	}
+	if p := paletteFor(colorprofile.TrueColor); p.addedBg != nil {
+		t.Errorf("without colors diffs have a background: %v", p.addedBg)
+	}
+
+	dark, _ := theme.Resolve(theme.Dark, nil, nil)
+	SetTheme(dark)
+	if got := DimStyle.Render("x"); got == "x" {
+		t.Error("dark DimStyle has no color")
+	}
+}
@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	"charm.land/bubbles/v2/spinner"
+	tea "charm.land/bubbletea/v2"
+)
+
+// UpdateState is the state of an update from the default branch
+type UpdateState int
+
+const (
+	UpdateInProgress UpdateState = iota
+	UpdateConflicts              // the merge or rebase stopped on conflicts
+	UpdateDone                   // updated, offering to push
+	UpdatePushing
+	UpdatePushed
+	UpdateFailed
+	UpdateCancelled
+	UpdateAborted
+)
+
+// UpdateProgressMsg carries a step update from a running update or push
+type UpdateProgressMsg struct {
+	Progress git.Progress
+}
+
+// UpdateCompleteMsg is sent when an update, or a step taken on its
+// conflicts, finishes
+type UpdateCompleteMsg struct {
+	Result git.UpdateResult
+	Err    error
+	State  *git.RepoState // set when the update was cancelled
+}
+
+// UpdateAbortedMsg is sent when the merge or rebase has been aborted
+type UpdateAbortedMsg struct {
+	Err error
+}
+
+// PushCompleteMsg is sent when the force-push of the updated branch finishes
+type PushCompleteMsg struct {
+	Err error
+}
+
+// UpdateModal updates the current branch from the default branch by
+// rebasing or merging, as git config gq.updateStrategy says. Conflicts are
+// listed with options to abort or to resolve them in a shell, and an
+// updated branch can be force-pushed with lease.
+type UpdateModal struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	repoPath   string
+	base       string
+	state      UpdateState
+	spinner    spinner.Model
+	steps      []checkoutStep
+	result     git.UpdateResult
+	err        error
+	repo       *git.RepoState // state the repo was left in after a cancel
+	closed     bool
+	wantsShell bool
+	events     progressEvents
+	keys       keymap.Keymap
+}
+
+// NewUpdateModal creates a modal that updates the current branch from base
+func NewUpdateModal(ctx context.Context, repoPath, base string) UpdateModal {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
+
+	return UpdateModal{
+		ctx:      ctx,
+		cancel:   cancel,
+		repoPath: repoPath,
+		base:     base,
+		state:    UpdateInProgress,
+		spinner:  s,
+		events:   newProgressEvents(),
+	}
+}
+
+// WithKeys sets the keymap, whose update bindings the modal handles
+func (m UpdateModal) WithKeys(keys keymap.Keymap) UpdateModal {
+	m.keys = keys
+	return m
+}
+
+// Init starts the update
+func (m UpdateModal) Init() tea.Cmd {
+	ctx, repoPath, base := m.ctx, m.repoPath, m.base
+	return tea.Batch(m.spinner.Tick, m.background(func(report func(git.Progress)) tea.Msg {
+		strategy, err := git.GetUpdateStrategy(ctx, repoPath)
+		if err != nil {
+			return UpdateCompleteMsg{Err: err}
+		}
+		result, err := git.UpdateFromBase(ctx, repoPath, base, strategy, report)
+		return UpdateCompleteMsg{Result: result, Err: err, State: cancelledState(repoPath, err)}
+	}))
+}
+
+// background runs work in a goroutine, streaming its progress, and returns
+// the command waiting for the first event
+func (m UpdateModal) background(work func(report func(git.Progress)) tea.Msg) tea.Cmd {
+	wrap := func(p git.Progress) tea.Msg { return UpdateProgressMsg{Progress: p} }
+	return m.events.run(wrap, work)
+}
+
+// Recheck looks at the repo again after the user worked on the conflicts
+// in a shell
+func (m UpdateModal) Recheck() tea.Cmd {
+	if m.state != UpdateConflicts {
+		return nil
+	}
+	ctx, repoPath, result := m.ctx, m.repoPath, m.result
+	return func() tea.Msg {
+		result, err := git.CheckUpdate(ctx, repoPath, result)
+		return UpdateCompleteMsg{Result: result, Err: err}
+	}
+}
+
+// Update handles messages
+func (m UpdateModal) Update(msg tea.Msg) (UpdateModal, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		return m.handleKey(msg)
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case UpdateProgressMsg:
+		if m.state != UpdateInProgress && m.state != UpdatePushing {
+			return m, nil
+		}
+		m.steps = applyStep(m.steps, msg.Progress)
+		return m, m.events.next()
+
+	case UpdateCompleteMsg:
+		m.result = msg.Result
+		switch {
+		case errors.Is(msg.Err, context.Canceled):
+			m.state = UpdateCancelled
+			m.repo = msg.State
+		case msg.Err != nil:
+			m.state = UpdateFailed
+			m.err = msg.Err
+		case msg.Result.InProgress:
+			m.state = UpdateConflicts
+		case msg.Result.Updated:
+			m.state = UpdateDone
+		default:
+			// The user aborted the merge or rebase themselves
+			m.state = UpdateAborted
+		}
+		return m, nil
+
+	case UpdateAbortedMsg:
+		if msg.Err != nil {
+			m.state = UpdateFailed
+			m.err = msg.Err
+		} else {
+			m.state = UpdateAborted
+		}
+		return m, nil
+
+	case PushCompleteMsg:
+		if msg.Err != nil {
+			m.state = UpdateFailed
+			m.err = msg.Err
+		} else {
+			m.state = UpdatePushed
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// handleKey handles a key press in the current state
+func (m UpdateModal) handleKey(msg tea.KeyPressMsg) (UpdateModal, tea.Cmd) {
+	key := msg.String()
+	switch m.state {
+	case UpdateInProgress, UpdatePushing:
+		if m.keys.Matches(key, keymap.UpdateCancel) {
+			m.cancel()
+		}
+	case UpdateConflicts:
+		switch {
+		case m.keys.Matches(key, keymap.UpdateAbort):
+			ctx, repoPath := m.ctx, m.repoPath
+			m.state = UpdateInProgress
+			return m, func() tea.Msg {
+				return UpdateAbortedMsg{Err: git.AbortUpdate(ctx, repoPath)}
+			}
+		case m.keys.Matches(key, keymap.UpdateShell):
+			m.wantsShell = true
+		case m.keys.Matches(key, keymap.UpdateContinue):
+			if len(m.result.Conflicts) == 0 {
+				ctx, repoPath, result := m.ctx, m.repoPath, m.result
+				m.state = UpdateInProgress
+				return m, func() tea.Msg {
+					result, err := git.ContinueUpdate(ctx, repoPath, result)
+					return UpdateCompleteMsg{Result: result, Err: err}
+				}
+			}
+		case m.keys.Matches(key, keymap.UpdateCancel):
+			// Leave the merge or rebase in progress
+			m.closed = true
+		}
+	case UpdateDone:
+		if m.keys.Matches(key, keymap.UpdatePush) && m.canPush() {
+			ctx, repoPath, result := m.ctx, m.repoPath, m.result
+			m.state = UpdatePushing
+			return m, m.background(func(report func(git.Progress)) tea.Msg {
+				return PushCompleteMsg{Err: git.PushWithLease(ctx, repoPath, result, report)}
+			})
+		}
+		m.closed = true
+	default:
+		m.closed = true
+	}
+	return m, nil
+}
+
+// canPush reports whether the update brought in commits that can be pushed
+// to the branch's upstream
+func (m UpdateModal) canPush() bool {
+	return m.result.Remote != "" && m.result.Behind > 0
+}
+
+// View renders the modal
+func (m UpdateModal) View() string {
+	content := fmt.Sprintf("Update from %s\n", m.base)
+	if m.result.Branch != "" {
+		content += fmt.Sprintf("Branch: %s\n", m.result.Branch)
+	}
+	content += "\n" + renderSteps(m.steps, m.spinner)
+
+	switch m.state {
+	case UpdateInProgress, UpdatePushing:
+		if len(m.steps) == 0 || m.steps[len(m.steps)-1].state != git.StepRunning {
+			content += m.spinner.View() + " Working...\n"
+		}
+		content += "\n[" + keyHint(m.keys, keymap.UpdateCancel) + "] cancel"
+	case UpdateConflicts:
+		op := string(m.result.Strategy)
+		shell, abort, leave := keyHint(m.keys, keymap.UpdateShell), keyHint(m.keys, keymap.UpdateAbort), keyHint(m.keys, keymap.UpdateCancel)
+		if len(m.result.Conflicts) == 0 {
+			content += "\n" + SuccessStyle.Render("All conflicts resolved") + "\n"
+			content += fmt.Sprintf("\n[%s] Continue the %s  |  [%s] Open shell  |  [%s] Abort  |  [%s] Leave it in progress",
+				keyHint(m.keys, keymap.UpdateContinue), op, shell, abort, leave)
+			break
+		}
+		content += "\n" + WarningStyle.Render(fmt.Sprintf("The %s stopped on conflicts in:", op)) + "\n"
+		for i, f := range m.result.Conflicts {
+			if i == maxDirtyFiles {
+				content += DimStyle.Render(fmt.Sprintf("  ... and %d more", len(m.result.Conflicts)-maxDirtyFiles)) + "\n"
+				break
+			}
+			content += "  " + ErrorStyle.Render("U") + " " + f + "\n"
+		}
+		content += fmt.Sprintf("\n[%s] Open shell to resolve  |  [%s] Abort  |  [%s] Leave it in progress\n", shell, abort, leave)
+		content += DimStyle.Render("Stage the resolved files, exit the shell and gq picks up from there")
+	case UpdateDone:
+		content += "\n" + SuccessStyle.Render("✓ Updated "+m.result.Branch) + "\n"
+		if m.canPush() {
+			content += fmt.Sprintf("\n[%s] Force-push with lease to %s/%s  |  any other key to close",
+				keyHint(m.keys, keymap.UpdatePush), m.result.Remote, m.result.RemoteBranch)
+		} else {
+			content += "\nPress any key to continue"
+		}
+	case UpdatePushed:
+		content += "\n" + SuccessStyle.Render("✓ Pushed "+m.result.Branch) + "\n"
+		content += "\nPress any key to continue"
+	case UpdateAborted:
+		content += "\n" + WarningStyle.Render("Aborted, "+m.result.Branch+" is back where it was") + "\n"
+		content += "\nPress any key to continue"
+	case UpdateCancelled:
+		content += WarningStyle.Render("Cancelled") + "\n"
+		if m.repo != nil {
+			content += DimStyle.Render(m.repo.Summary()) + "\n"
+		}
+		content += "\nPress any key to continue"
+	case UpdateFailed:
+		content += "\n" + ErrorStyle.Render("Error: "+m.err.Error())
+		content += "\n\nPress any key to continue"
+	}
+	return ModalStyle.Render(content)
+}
+
+// Closed reports whether the user dismissed the modal
+func (m UpdateModal) Closed() bool {
+	return m.closed
+}
+
+// TakeShellRequest returns true once if the user asked for a shell to
+// resolve conflicts in
+func (m *UpdateModal) TakeShellRequest() bool {
+	wants := m.wantsShell
+	m.wantsShell = false
+	return wants
+}
+
+// Close cancels anything still running
+func (m UpdateModal) Close() {
+	m.cancel()
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestUpdateModalConflicts(t *testing.T) {
+	m := NewUpdateModal(context.Background(), t.TempDir(), "main")
+	m, _ = m.Update(UpdateCompleteMsg{Result: git.UpdateResult{
+		Branch:     "feature",
+		Base:       "origin/main",
+		Strategy:   git.UpdateRebase,
+		InProgress: true,
+		Conflicts:  []string{"app.go", "README.md"},
+	}})
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"The rebase stopped on conflicts in:", "U app.go", "U README.md", "[s] Open shell to resolve", "[a] Abort"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	// Continuing is only offered once nothing is conflicted
+	m, cmd := m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if cmd != nil {
+		t.Error("continue should wait for the conflicts to be resolved")
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 's', Text: "s"})
+	if !m.TakeShellRequest() || m.TakeShellRequest() {
+		t.Error("expected a single shell request")
+	}
+
+	// The user finished the rebase in the shell
+	m, _ = m.Update(UpdateCompleteMsg{Result: git.UpdateResult{
+		Branch:       "feature",
+		Base:         "origin/main",
+		Behind:       3,
+		Updated:      true,
+		Remote:       "origin",
+		RemoteBranch: "feature",
+	}})
+	view = ansi.Strip(m.View())
+	if !strings.Contains(view, "[p] Force-push with lease to origin/feature") {
+		t.Errorf("expected the push offer in view:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	if !m.Closed() {
+		t.Error("any other key should close the modal")
+	}
+}
@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+	"github.com/Constantine-Kostikas/GitQuick/internal/keymap"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// WorktreesLoadedMsg is sent when the worktree list is loaded
+type WorktreesLoadedMsg struct {
+	Worktrees []git.Worktree
+	Err       error
+}
+
+// WorktreeStatusMsg carries the state of the MR a worktree was created for
+type WorktreeStatusMsg struct {
+	Number int
+	Status string
+	Err    error
+}
+
+// WorktreeRemovedMsg is sent when a worktree has been removed
+type WorktreeRemovedMsg struct {
+	Path string
+	Err  error
+}
+
+// ExecFinishedMsg is sent when a shell or editor started from gq exits
+type ExecFinishedMsg struct {
+	Err error
+}
+
+// execRequest asks the dashboard to suspend and run program ("shell" or
+// "editor") in dir
+type execRequest struct {
+	dir     string
+	program string
+}
+
+// WorktreeModal lists the worktrees gq created for MRs, with the state of
+// each MR so worktrees of merged or closed MRs can be cleaned up
+type WorktreeModal struct {
+	worktrees []git.Worktree
+	statuses  map[int]string // MR state by number, "" while loading
+	loading   bool
+	err       error
+	cursor    int
+	notice    string
+	removing  map[string]bool
+	wantsExec *execRequest
+	wantsRm   []string
+	keys      keymap.Keymap
+	width     int
+	height    int
+}
+
+// NewWorktreeModal creates the worktree list, loading until SetWorktrees
+func NewWorktreeModal(width, height int) WorktreeModal {
+	return WorktreeModal{
+		statuses: make(map[int]string),
+		removing: make(map[string]bool),
+		loading:  true,
+		width:    width,
+		height:   height,
+	}
+}
+
+// WithKeys sets the keymap, whose worktree bindings the modal and the
+// dashboard handle
+func (m WorktreeModal) WithKeys(keys keymap.Keymap) WorktreeModal {
+	m.keys = keys
+	return m
+}
+
+// SetWorktrees replaces the list with the MR worktrees among worktrees
+func (m *WorktreeModal) SetWorktrees(worktrees []git.Worktree, err error) {
+	m.loading = false
+	m.err = err
+	m.worktrees = nil
+	for _, wt := range worktrees {
+		if !wt.Main && git.WorktreeMR(wt) != 0 {
+			m.worktrees = append(m.worktrees, wt)
+		}
+	}
+	m.cursor = max(0, min(m.cursor, len(m.worktrees)-1))
+}
+
+// Numbers returns the MR numbers of the listed worktrees
+func (m WorktreeModal) Numbers() []int {
+	var numbers []int
+	for _, wt := range m.worktrees {
+		numbers = append(numbers, git.WorktreeMR(wt))
+	}
+	return numbers
+}
+
+// SetStatus records the state of an MR
+func (m *WorktreeModal) SetStatus(msg WorktreeStatusMsg) {
+	if msg.Err != nil {
+		m.statuses[msg.Number] = "unknown"
+		return
+	}
+	m.statuses[msg.Number] = msg.Status
+}
+
+// SetRemoved reports the outcome of a removal
+func (m *WorktreeModal) SetRemoved(msg WorktreeRemovedMsg) {
+	delete(m.removing, msg.Path)
+	if msg.Err != nil {
+		m.notice = ErrorStyle.Render("Removing " + msg.Path + " failed: " + msg.Err.Error())
+		return
+	}
+	m.notice = SuccessStyle.Render("Removed " + msg.Path)
+}
+
+// Init returns the initial command
+func (m WorktreeModal) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m WorktreeModal) Update(msg tea.Msg) (WorktreeModal, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok || m.loading {
+		return m, nil
+	}
+	m.notice = ""
+
+	switch key := keyMsg.String(); {
+	case m.keys.Matches(key, keymap.WorktreeUp):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case m.keys.Matches(key, keymap.WorktreeDown):
+		if m.cursor < len(m.worktrees)-1 {
+			m.cursor++
+		}
+	case m.keys.Matches(key, keymap.WorktreeShell):
+		if wt := m.selected(); wt != nil {
+			m.wantsExec = &execRequest{dir: wt.Path, program: "shell"}
+		}
+	case m.keys.Matches(key, keymap.WorktreeEditor):
+		if wt := m.selected(); wt != nil {
+			m.wantsExec = &execRequest{dir: wt.Path, program: "editor"}
+		}
+	case m.keys.Matches(key, keymap.WorktreeRemove):
+		if wt := m.selected(); wt != nil && !m.removing[wt.Path] {
+			m.remove(wt.Path)
+		}
+	case m.keys.Matches(key, keymap.WorktreeClean):
+		// Clean up every worktree whose MR is done with
+		for _, wt := range m.worktrees {
+			status := m.statuses[git.WorktreeMR(wt)]
+			if (status == "merged" || status == "closed") && !m.removing[wt.Path] {
+				m.remove(wt.Path)
+			}
+		}
+		if len(m.wantsRm) == 0 {
+			m.notice = DimStyle.Render("No worktrees of merged or closed MRs")
+		}
+	}
+	return m, nil
+}
+
+// remove queues a worktree for removal
+func (m *WorktreeModal) remove(path string) {
+	m.removing[path] = true
+	m.wantsRm = append(m.wantsRm, path)
+}
+
+// selected returns the worktree under the cursor
+func (m WorktreeModal) selected() *git.Worktree {
+	if m.cursor < 0 || m.cursor >= len(m.worktrees) {
+		return nil
+	}
+	return &m.worktrees[m.cursor]
+}
+
+// TakeExecRequest returns the requested shell or editor launch, once
+func (m *WorktreeModal) TakeExecRequest() *execRequest {
+	req := m.wantsExec
+	m.wantsExec = nil
+	return req
+}
+
+// TakeRemoveRequest returns the worktree paths to remove, once
+func (m *WorktreeModal) TakeRemoveRequest() []string {
+	paths := m.wantsRm
+	m.wantsRm = nil
+	return paths
+}
+
+// View renders the modal
+func (m WorktreeModal) View() string {
+	width := max(60, min(m.width-10, 120))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(accentColor)
+	content := titleStyle.Render("MR Worktrees") + "\n\n"
+
+	switch {
+	case m.loading:
+		content += DimStyle.Render("Loading worktrees...") + "\n"
+	case m.err != nil:
+		content += ErrorStyle.Render("Error: "+DescribeError(m.err)) + "\n"
+	case len(m.worktrees) == 0:
+		content += DimStyle.Render("No MR worktrees yet, press "+keyHint(m.keys, keymap.DetailWorktree)+" in an MR to create one") + "\n"
+	}
+
+	for i, wt := range m.worktrees {
+		number := git.WorktreeMR(wt)
+		status := m.statuses[number]
+		switch {
+		case m.removing[wt.Path]:
+			status = "removing"
+		case status == "":
+			status = "..."
+		}
+		line := fmt.Sprintf("#%-5d %-8s %s", number, status, truncateString(wt.Branch, 30))
+		path := DimStyle.Render("  " + truncateString(wt.Path, width-len(line)-6))
+		if i == m.cursor {
+			line = SelectedItemStyle.Render("> " + line)
+		} else {
+			line = "  " + statusStyle(status).Render(line)
+		}
+		content += line + path + "\n"
+	}
+
+	if m.notice != "" {
+		content += "\n" + m.notice + "\n"
+	}
+	content += "\n" + DimStyle.Render(strings.Join(footerHelp(m.keys, keymap.Worktree, "[%s] %s"), " | "))
+	return ModalStyle.Width(width).Render(content)
+}
+
+// statusStyle returns the list style for an MR state
+func statusStyle(status string) lipgloss.Style {
+	switch status {
+	case "merged":
+		return StatusMergedStyle
+	case "closed":
+		return StatusClosedStyle
+	}
+	return NormalItemStyle
+}
+
+// execIn suspends the TUI and runs the user's shell or editor in dir
+func execIn(req execRequest) tea.Cmd {
+	var c *exec.Cmd
+	switch req.program {
+	case "editor":
+		editor := strings.Fields(os.Getenv("EDITOR"))
+		if len(editor) == 0 {
+			editor = []string{"vi"}
+		}
+		c = exec.Command(editor[0], append(editor[1:], ".")...)
+	default:
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		c = exec.Command(shell)
+	}
+	c.Dir = req.dir
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return ExecFinishedMsg{Err: err}
+	})
+}
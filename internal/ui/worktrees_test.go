@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/git"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestWorktreeModalCleanup(t *testing.T) {
+	m := NewWorktreeModal(100, 30)
+	m.SetWorktrees([]git.Worktree{
+		{Path: "/repo", Branch: "main", Main: true},
+		{Path: "/wt/mr-1", Branch: "one"},
+		{Path: "/wt/mr-2", Branch: "two"},
+		{Path: "/wt/mr-3", Branch: "three"},
+		{Path: "/elsewhere/scratch", Branch: "scratch"},
+	}, nil)
+	if got := m.Numbers(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("got MR worktrees %v, want 1, 2, 3", got)
+	}
+
+	m.SetStatus(WorktreeStatusMsg{Number: 1, Status: "merged"})
+	m.SetStatus(WorktreeStatusMsg{Number: 2, Status: "open"})
+	m.SetStatus(WorktreeStatusMsg{Number: 3, Status: "closed"})
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	if got := m.TakeRemoveRequest(); !slices.Equal(got, []string{"/wt/mr-1", "/wt/mr-3"}) {
+		t.Errorf("got removals %v, want the merged and closed MRs' worktrees", got)
+	}
+	if got := m.TakeRemoveRequest(); got != nil {
+		t.Errorf("removal request should be taken once, got %v", got)
+	}
+
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	m, _ = m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	if req := m.TakeExecRequest(); req == nil || req.dir != "/wt/mr-2" || req.program != "editor" {
+		t.Errorf("got exec request %+v, want the editor in /wt/mr-2", req)
+	}
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// ghPullRequest is the pull request in GitHub's pull_request and
+// pull_request_review payloads
+type ghPullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"` // "open" or "closed"
+	Draft   bool   `json:"draft"`
+	Merged  bool   `json:"merged"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// ghPayload holds the fields gq reads of every GitHub event it handles
+type ghPayload struct {
+	Action      string        `json:"action"`
+	PullRequest ghPullRequest `json:"pull_request"`
+	Review      struct {
+		State string `json:"state"` // "approved", "changes_requested" or "commented"
+	} `json:"review"`
+	WorkflowRun struct {
+		Status       string `json:"status"`     // "queued", "in_progress" or "completed"
+		Conclusion   string `json:"conclusion"` // set once completed
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"` // empty for fork PRs
+	} `json:"workflow_run"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func (pr ghPullRequest) toMR() platform.MR {
+	status := pr.State
+	switch {
+	case pr.Merged:
+		status = "merged"
+	case pr.State == "open" && pr.Draft:
+		status = "draft"
+	}
+	return platform.MR{
+		Number:  pr.Number,
+		Title:   pr.Title,
+		Branch:  pr.Head.Ref,
+		Status:  status,
+		URL:     pr.HTMLURL,
+		HeadRef: fmt.Sprintf("refs/pull/%d/head", pr.Number),
+		HeadSHA: pr.Head.SHA,
+	}
+}
+
+// parseGitHub returns the MR events of a GitHub webhook, event being its
+// X-GitHub-Event header
+func parseGitHub(event string, body []byte) ([]Event, error) {
+	switch event {
+	case "pull_request", "pull_request_review", "pull_request_review_comment", "workflow_run":
+	default:
+		// ping and everything else
+		return nil, nil
+	}
+	var p ghPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	repo := p.Repository.FullName
+
+	switch event {
+	case "pull_request":
+		return []Event{{Kind: KindMR, Repo: repo, MR: p.PullRequest.toMR()}}, nil
+	case "pull_request_review":
+		if p.Action != "submitted" {
+			return nil, nil
+		}
+		return []Event{{Kind: KindReview, Repo: repo, MR: platform.MR{Number: p.PullRequest.Number}, State: state(p.Review.State)}}, nil
+	case "pull_request_review_comment":
+		return []Event{{Kind: KindReview, Repo: repo, MR: platform.MR{Number: p.PullRequest.Number}, State: "commented"}}, nil
+	}
+
+	run := p.WorkflowRun
+	status := run.Status
+	if status == "completed" {
+		status = run.Conclusion
+	}
+	var events []Event
+	for _, pr := range run.PullRequests {
+		events = append(events, Event{Kind: KindPipeline, Repo: repo, MR: platform.MR{Number: pr.Number}, State: state(status)})
+	}
+	return events, nil
+}
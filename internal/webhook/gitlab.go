@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// glPayload holds the fields gq reads of every GitLab event it handles
+type glPayload struct {
+	ObjectAttributes struct {
+		// Merge request events
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		SourceBranch string `json:"source_branch"`
+		State        string `json:"state"` // "opened", "closed", "locked" or "merged"
+		Draft        bool   `json:"draft"`
+		URL          string `json:"url"`
+		Action       string `json:"action"` // "open", "update", "approved", "merge"...
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+
+		// Pipeline events
+		Status string `json:"status"`
+
+		// Comment events
+		NoteableType string `json:"noteable_type"`
+	} `json:"object_attributes"`
+	MergeRequest *struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"` // the MR a pipeline or comment belongs to, if any
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+// parseGitLab returns the MR events of a GitLab webhook, event being its
+// X-Gitlab-Event header
+func parseGitLab(event string, body []byte) ([]Event, error) {
+	switch event {
+	case "Merge Request Hook", "Pipeline Hook", "Note Hook":
+	default:
+		return nil, nil
+	}
+	var p glPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	repo := p.Project.PathWithNamespace
+	attrs := p.ObjectAttributes
+
+	switch event {
+	case "Merge Request Hook":
+		if attrs.Action == "approved" || attrs.Action == "unapproved" {
+			return []Event{{Kind: KindReview, Repo: repo, MR: platform.MR{Number: attrs.IID}, State: attrs.Action}}, nil
+		}
+		status := attrs.State
+		switch {
+		case status == "opened" && attrs.Draft:
+			status = "draft"
+		case status == "opened":
+			status = "open"
+		}
+		mr := platform.MR{
+			Number:  attrs.IID,
+			Title:   attrs.Title,
+			Branch:  attrs.SourceBranch,
+			Status:  status,
+			URL:     attrs.URL,
+			HeadRef: fmt.Sprintf("refs/merge-requests/%d/head", attrs.IID),
+			HeadSHA: attrs.LastCommit.ID,
+		}
+		return []Event{{Kind: KindMR, Repo: repo, MR: mr}}, nil
+	case "Pipeline Hook":
+		if p.MergeRequest == nil {
+			// A branch or tag pipeline
+			return nil, nil
+		}
+		return []Event{{Kind: KindPipeline, Repo: repo, MR: platform.MR{Number: p.MergeRequest.IID}, State: state(attrs.Status)}}, nil
+	}
+
+	if attrs.NoteableType != "MergeRequest" || p.MergeRequest == nil {
+		return nil, nil
+	}
+	return []Event{{Kind: KindReview, Repo: repo, MR: platform.MR{Number: p.MergeRequest.IID}, State: "commented"}}, nil
+}
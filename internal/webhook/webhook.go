@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+// Kinds of events
+const (
+	KindMR       = "mr"       // an MR was opened, edited, pushed to, merged or closed
+	KindReview   = "review"   // an MR was reviewed, approved or commented on
+	KindPipeline = "pipeline" // an MR's pipeline changed status
+)
+
+// Event is what a webhook says happened to an MR
+type Event struct {
+	Kind  string
+	Repo  string      // the repository's path on its forge, e.g. "owner/repo"
+	MR    platform.MR // with KindMR every field the payload has, otherwise the number
+	State string      // the review's state or the pipeline's status
+}
+
+// maxBody bounds the payloads read, GitHub sends at most 25MB
+const maxBody = 25 << 20
+
+// Handler receives GitHub and GitLab webhooks, verifies them with the shared
+// secret and passes the MR events they carry to send. Other events are
+// accepted and dropped.
+func Handler(secret string, send func(Event)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "webhooks are POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+		if err != nil {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		var events []Event
+		switch {
+		case r.Header.Get("X-GitHub-Event") != "":
+			if !validSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+			events, err = parseGitHub(r.Header.Get("X-GitHub-Event"), body)
+		case r.Header.Get("X-Gitlab-Event") != "":
+			if !validToken(secret, r.Header.Get("X-Gitlab-Token")) {
+				http.Error(w, "bad token", http.StatusUnauthorized)
+				return
+			}
+			events, err = parseGitLab(r.Header.Get("X-Gitlab-Event"), body)
+		default:
+			http.Error(w, "not a GitHub or GitLab webhook", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "bad payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, e := range events {
+			send(e)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// validSignature checks GitHub's "sha256=<hex>" HMAC of the body. Without a
+// secret nothing is valid.
+func validSignature(secret string, body []byte, signature string) bool {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || secret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// validToken checks GitLab's secret token. Without a secret nothing is valid.
+func validToken(secret, token string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+}
+
+// Listen receives webhooks on addr, e.g. "127.0.0.1:8765", in the
+// background. It returns once the address is bound, the server's Addr
+// being the one it got, and Close stops it.
+func Listen(addr, secret string, send func(Event)) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Addr: ln.Addr().String(), Handler: Handler(secret, send), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	return srv, nil
+}
+
+// state turns a forge's state name into words, e.g. "changes requested"
+func state(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), "_", " ")
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
+)
+
+const secret = "s3cret"
+
+// post sends a recorded payload to the handler and returns the status and
+// the events it passed on
+func post(t *testing.T, file string, headers map[string]string) (int, []Event) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	var events []Event
+	rec := httptest.NewRecorder()
+	Handler(secret, func(e Event) { events = append(events, e) }).ServeHTTP(rec, req)
+	return rec.Code, events
+}
+
+// sign returns GitHub's signature of a recorded payload
+func sign(t *testing.T, file, key string) string {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandler_GitHub(t *testing.T) {
+	tests := []struct {
+		event, file string
+		want        []Event
+	}{
+		{"pull_request", "github_pull_request.json", []Event{{Kind: KindMR, Repo: "acme/widgets", MR: platform.MR{
+			Number: 42, Title: "Fix login timeout", Branch: "fix/login-timeout", Status: "open",
+			URL: "https://github.com/acme/widgets/pull/42", HeadRef: "refs/pull/42/head",
+			HeadSHA: "3f2a9c1e8b7d6a5c4b3a2f1e0d9c8b7a6f5e4d3c",
+		}}}},
+		{"pull_request_review", "github_pull_request_review.json", []Event{
+			{Kind: KindReview, Repo: "acme/widgets", MR: platform.MR{Number: 42}, State: "changes requested"},
+		}},
+		{"workflow_run", "github_workflow_run.json", []Event{
+			{Kind: KindPipeline, Repo: "acme/widgets", MR: platform.MR{Number: 42}, State: "failure"},
+		}},
+		{"ping", "github_pull_request.json", nil},
+	}
+	for _, tt := range tests {
+		code, events := post(t, tt.file, map[string]string{
+			"X-GitHub-Event":      tt.event,
+			"X-Hub-Signature-256": sign(t, tt.file, secret),
+		})
+		if code != http.StatusNoContent || !reflect.DeepEqual(events, tt.want) {
+			t.Errorf("%s: got %d %+v, want %+v", tt.event, code, events, tt.want)
+		}
+	}
+}
+
+func TestHandler_GitLab(t *testing.T) {
+	tests := []struct {
+		event, file string
+		want        []Event
+	}{
+		{"Merge Request Hook", "gitlab_merge_request.json", []Event{{Kind: KindMR, Repo: "acme/platform/widgets", MR: platform.MR{
+			Number: 17, Title: "Draft: Move config to TOML", Branch: "carol/toml-config", Status: "draft",
+			URL: "https://gitlab.example.com/acme/platform/widgets/-/merge_requests/17", HeadRef: "refs/merge-requests/17/head",
+			HeadSHA: "c0ffee1234567890abcdef1234567890abcdef12",
+		}}}},
+		{"Merge Request Hook", "gitlab_merge_request_approved.json", []Event{
+			{Kind: KindReview, Repo: "acme/platform/widgets", MR: platform.MR{Number: 17}, State: "approved"},
+		}},
+		{"Pipeline Hook", "gitlab_pipeline.json", []Event{
+			{Kind: KindPipeline, Repo: "acme/platform/widgets", MR: platform.MR{Number: 17}, State: "running"},
+		}},
+		{"Note Hook", "gitlab_note.json", []Event{
+			{Kind: KindReview, Repo: "acme/platform/widgets", MR: platform.MR{Number: 17}, State: "commented"},
+		}},
+		{"Push Hook", "gitlab_pipeline.json", nil},
+	}
+	for _, tt := range tests {
+		code, events := post(t, tt.file, map[string]string{"X-Gitlab-Event": tt.event, "X-Gitlab-Token": secret})
+		if code != http.StatusNoContent || !reflect.DeepEqual(events, tt.want) {
+			t.Errorf("%s (%s): got %d %+v, want %+v", tt.event, tt.file, code, events, tt.want)
+		}
+	}
+}
+
+func TestHandler_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"wrong GitHub secret", map[string]string{
+			"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": sign(t, "github_pull_request.json", "guess"),
+		}, http.StatusUnauthorized},
+		{"unsigned GitHub payload", map[string]string{"X-GitHub-Event": "pull_request"}, http.StatusUnauthorized},
+		{"wrong GitLab token", map[string]string{"X-Gitlab-Event": "Merge Request Hook", "X-Gitlab-Token": "guess"}, http.StatusUnauthorized},
+		{"unknown sender", map[string]string{}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		code, events := post(t, "github_pull_request.json", tt.headers)
+		if code != tt.want || events != nil {
+			t.Errorf("%s: got %d with %d events, want %d", tt.name, code, len(events), tt.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	Handler(secret, func(Event) { t.Error("a GET sent an event") }).ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET got %d", rec.Code)
+	}
+}
+
+func TestListen(t *testing.T) {
+	events := make(chan Event, 1)
+	srv, err := Listen("127.0.0.1:0", secret, func(e Event) { events <- e })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	if _, err := Listen(srv.Addr, secret, nil); err == nil {
+		t.Error("a second listener on the same address started")
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "gitlab_note.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://"+srv.Addr+"/", bytes.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", "Note Hook")
+	req.Header.Set("X-Gitlab-Token", secret)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %s", resp.Status)
+	}
+	if e := <-events; e.Kind != KindReview || e.MR.Number != 17 {
+		t.Errorf("got %+v", e)
+	}
+}
@@ -3,14 +3,22 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Constantine-Kostikas/GitQuick/internal/boot"
+	"github.com/Constantine-Kostikas/GitQuick/internal/cache"
+	"github.com/Constantine-Kostikas/GitQuick/internal/cli"
+	"github.com/Constantine-Kostikas/GitQuick/internal/platform"
 	"github.com/Constantine-Kostikas/GitQuick/internal/ui"
+	"github.com/Constantine-Kostikas/GitQuick/internal/webhook"
 
 	tea "charm.land/bubbletea/v2"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
 
 	system := boot.Bootstrap()
 
@@ -22,11 +30,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Pick the theme before the program starts, it may ask the terminal for its background
+	ui.SetTheme(ui.LoadTheme(system.Config))
+
+	// Start from what the last run cached, per repository and host
+	p := system.Platform
+	if cfg := system.Config.Cache; cfg.Enabled && system.CacheDir != "" {
+		p = platform.NewCached(p, cache.Open(system.CacheDir), platform.TTLs{
+			MRs:      cfg.MRs,
+			RepoInfo: cfg.RepoInfo,
+			Authors:  cfg.Authors,
+			Details:  cfg.Details,
+		})
+	}
+
 	// Create and run the dashboard
-	dashboard := ui.NewDashboard(system.Platform, system.WorkingDir)
+	dashboard := ui.NewDashboard(p, system.WorkingDir, system.Config)
 	prog := tea.NewProgram(dashboard)
 
-	if _, err := prog.Run(); err != nil {
+	// Forward the repository's webhook events to the dashboard
+	closeListener := func() error { return nil }
+	if cfg := system.Config.Webhook; cfg.Enabled {
+		srv, err := webhook.Listen(cfg.Listen, cfg.Secret, func(e webhook.Event) {
+			if strings.EqualFold(e.Repo, system.RepoPath) {
+				prog.Send(ui.WebhookMsg{Event: e})
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: webhook listener: %v\n", err)
+			os.Exit(1)
+		}
+		// Closed before returning, os.Exit skips deferred calls
+		closeListener = srv.Close
+	}
+
+	_, err := prog.Run()
+	_ = closeListener()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}